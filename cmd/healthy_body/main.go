@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"healthy_body/internal/config"
 	"healthy_body/internal/models"
@@ -10,6 +11,7 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"strconv"
 	"time"
 
 	_ "healthy_body/internal/docs"
@@ -20,6 +22,10 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access-токен в формате "Bearer <token>"
 func main() {
 	db := config.SetUpDatabaseConnection()
 	server := gin.Default()
@@ -28,28 +34,63 @@ func main() {
 	server.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PATCH", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{}))
+
+	if err := service.MigrateLegacyExerciseSchedule(db, logger); err != nil {
+		log.Fatalf("не удалось перенести расписание тренировочных планов: %v", err)
+	}
+
 	if err := db.AutoMigrate(
 		&models.Categories{},
 		&models.Subscription{},
 		&models.User{},
 		&models.UserPlan{},
 		&models.UserSubscription{},
+		&models.SubscriptionEvent{},
 		&models.ExercisePlan{},
 		&models.ExercisePlanItem{},
 		&models.MealPlan{},
 		&models.MealPlanItem{},
 		&models.Reviews{},
+		&models.RefreshToken{},
+		&models.LedgerAccount{},
+		&models.JournalEntry{},
+		&models.Posting{},
+		&models.TopUp{},
+		&models.IdempotencyKey{},
+		&models.RefundRequest{},
+		&models.PromoCode{},
+		&models.PromoCodeScope{},
+		&models.PromoRedemption{},
+		&models.Gift{},
+		&models.WorkoutSession{},
+		&models.WorkoutSet{},
+		&models.BodyMeasurement{},
+		&models.BodyGoal{},
+		&models.Food{},
+		&models.Recipe{},
+		&models.RecipeIngredient{},
+		&models.Exercise{},
+		&models.PlanVersion{},
+		&models.TrainerProfile{},
+		&models.TrainerPayout{},
+		&models.TrainerClient{},
+		&models.WorkoutComment{},
 	); err != nil {
 		log.Fatalf("не удалось выполнить миграции: %v", err)
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{}))
+	ledgerRepo := repository.NewLedgerRepository(db, logger)
+	ledgerService := service.NewLedgerService(ledgerRepo, db, logger)
+	if err := ledgerService.ImportLegacyBalances(); err != nil {
+		log.Fatalf("не удалось перенести балансы в леджер: %v", err)
+	}
 
 	categoryRepo := repository.NewCategoryRepo(db, logger)
 	planRepo := repository.NewExercisePlanRepo(db, logger)
@@ -59,9 +100,12 @@ func main() {
 	reviewsRepo := repository.NewReviewsRepository(db, logger)
 
 	categoryServices := service.NewCategoryServices(categoryRepo, logger)
-	planServices := service.NewExercisePlanServices(planRepo, logger, categoryServices)
-	mealPlanService := service.NewMealPlanService(mealPlanRepo, logger, categoryServices)
-	mealPlanItemService := service.NewMealPlanItemsService(mealPlanItemRepo, logger)
+	exerciseLibraryRepo := repository.NewExerciseLibraryRepository(db, logger)
+	planServices := service.NewExercisePlanServices(planRepo, logger, categoryServices, exerciseLibraryRepo)
+	exerciseLibraryService := service.NewExerciseLibraryService(exerciseLibraryRepo, db, logger)
+	foodService := service.NewFoodService(repository.NewFoodRepository(db, logger), mealPlanItemRepo, db, logger)
+	mealPlanService := service.NewMealPlanService(mealPlanRepo, logger, categoryServices, foodService)
+	mealPlanItemService := service.NewMealPlanItemsService(mealPlanItemRepo, mealPlanRepo, foodService, logger)
 	userRepo := repository.NewUserRepository(db, logger)
 	subService := service.NewSubscriptionService(subRepo, logger, categoryServices)
 	notificationService := service.NewEmailNotificationService(
@@ -70,9 +114,121 @@ func main() {
 		os.Getenv("EMAIL_HOST"),
 		587,
 		logger)
-	userService := service.NewUserService(userRepo, logger, db, subService, categoryRepo, notificationService)
+	giftExpiryDays := 30
+	if value := os.Getenv("GIFT_EXPIRY_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			log.Fatalf("некорректный GIFT_EXPIRY_DAYS: %q", value)
+		}
+		giftExpiryDays = days
+	}
+	commissionPercent := 20
+	if value := os.Getenv("PLATFORM_COMMISSION_PERCENT"); value != "" {
+		percent, err := strconv.Atoi(value)
+		if err != nil || percent < 0 || percent > 100 {
+			log.Fatalf("некорректный PLATFORM_COMMISSION_PERCENT: %q", value)
+		}
+		commissionPercent = percent
+	}
+	trainerRepo := repository.NewTrainerRepository(db, logger)
+	trainerService := service.NewTrainerService(trainerRepo, ledgerService, db, commissionPercent, logger)
+	giftService := service.NewGiftService(
+		repository.NewGiftRepository(db, logger),
+		ledgerService,
+		trainerService,
+		notificationService,
+		db,
+		time.Duration(giftExpiryDays)*24*time.Hour,
+		logger)
+	promoService := service.NewPromoService(repository.NewPromoRepository(db, logger), db, logger)
+	userSubRepo := repository.NewUserSubscriptionRepository(db, logger)
+	subscriptionLifecycle := service.NewSubscriptionLifecycle(userSubRepo, subService, ledgerService, promoService, trainerService, db, logger)
+	accessPolicy := service.NewAccessPolicy()
+	planVersionService := service.NewPlanVersionService(
+		repository.NewPlanVersionRepository(db, logger),
+		planRepo,
+		mealPlanRepo,
+		accessPolicy,
+		db,
+		logger)
+	if err := planVersionService.ImportLegacyPlanVersions(); err != nil {
+		log.Fatalf("не удалось опубликовать существующие планы: %v", err)
+	}
+	userService := service.NewUserService(userRepo, logger, db, subService, categoryRepo, notificationService, ledgerService, subscriptionLifecycle, promoService, giftService, trainerService, planVersionService)
+	if err := userService.ImportLegacyActiveProgrammes(); err != nil {
+		log.Fatalf("не удалось перенести активные программы пользователей: %v", err)
+	}
 	reviewsService := service.NewReviewsService(reviewsRepo, logger)
 
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		log.Fatal("не задан JWT_SECRET")
+	}
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db, logger)
+	tokenManager := service.NewTokenManager(jwtSecret, 15*time.Minute, 30*24*time.Hour)
+	authService := service.NewAuthService(userService, userRepo, tokenManager, refreshTokenRepo, os.Getenv("ADMIN_EMAIL"), logger)
+	entitlementService := service.NewEntitlementService(repository.NewEntitlementRepository(db, logger), accessPolicy, logger)
+	bodyService := service.NewBodyMetricsService(repository.NewBodyRepository(db, logger), logger)
+	recommendationService := service.NewRecommendationService(categoryRepo, repository.NewBodyRepository(db, logger), logger)
+	workoutRepo := repository.NewWorkoutRepository(db, logger)
+	workoutService := service.NewWorkoutService(workoutRepo, planRepo, entitlementService, logger)
+	coachingService := service.NewCoachingService(
+		repository.NewCoachingRepository(db, logger),
+		trainerRepo,
+		planRepo,
+		mealPlanRepo,
+		workoutRepo,
+		planVersionService,
+		logger)
+
+	// реальный шлюз подключается реализацией service.PaymentProvider; без провайдера
+	// пополнение баланса отключено. Тестовый провайдер зачисляет деньги без оплаты,
+	// поэтому работает только в окружении разработки при PAYMENT_FAKE_ENABLED=true
+	var fakePayments *service.FakePaymentProvider
+	var paymentProvider service.PaymentProvider
+	providerName := os.Getenv("PAYMENT_PROVIDER")
+	if providerName != "" {
+		webhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+		if webhookSecret == "" {
+			log.Fatal("не задан PAYMENT_WEBHOOK_SECRET")
+		}
+		if webhookSecret == jwtSecret {
+			log.Fatal("PAYMENT_WEBHOOK_SECRET должен отличаться от JWT_SECRET")
+		}
+
+		switch providerName {
+		case service.FakeProviderName:
+			if os.Getenv("PAYMENT_FAKE_ENABLED") != "true" {
+				log.Fatal("тестовый платёжный провайдер включается только при PAYMENT_FAKE_ENABLED=true")
+			}
+			fakePayments = service.NewFakePaymentProvider(webhookSecret)
+			paymentProvider = fakePayments
+		default:
+			log.Fatalf("неизвестный платёжный провайдер: %s", providerName)
+		}
+	} else {
+		logger.Warn("PAYMENT_PROVIDER не задан, пополнение баланса отключено")
+	}
+	topUpRepo := repository.NewTopUpRepository(db, logger)
+	topUpService := service.NewTopUpService(topUpRepo, ledgerService, db, logger, paymentProvider)
+	refundWindowDays := 14
+	if value := os.Getenv("REFUND_WINDOW_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			log.Fatalf("некорректный REFUND_WINDOW_DAYS: %q", value)
+		}
+		refundWindowDays = days
+	}
+	refundService := service.NewRefundService(
+		repository.NewRefundRepository(db, logger),
+		ledgerService,
+		promoService,
+		notificationService,
+		db,
+		time.Duration(refundWindowDays)*24*time.Hour,
+		logger)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db, logger), logger)
+
 	if tableList, err := db.Migrator().GetTables(); err == nil {
 		fmt.Println("tables:", tableList)
 	}
@@ -85,10 +241,51 @@ func main() {
 		mealPlanService,
 		mealPlanItemService,
 		userService,
+		ledgerService,
+		subscriptionLifecycle,
 		subService,
 		reviewsService,
+		topUpService,
+		refundService,
+		promoService,
+		giftService,
+		workoutService,
+		bodyService,
+		foodService,
+		exerciseLibraryService,
+		recommendationService,
+		planVersionService,
+		trainerService,
+		coachingService,
+		idempotencyService,
+		fakePayments,
+		authService,
+		accessPolicy,
+		entitlementService,
 	)
 
+	sweepInterval := time.Minute
+	if value := os.Getenv("SUBSCRIPTION_SWEEP_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			log.Fatalf("некорректный SUBSCRIPTION_SWEEP_INTERVAL: %q", value)
+		}
+		sweepInterval = interval
+	}
+
+	scheduler := service.NewScheduler(logger)
+	scheduler.Add(service.Job{
+		Name:     "subscriptions",
+		Interval: sweepInterval,
+		Run:      subscriptionLifecycle.ProcessDue,
+	})
+	scheduler.Add(service.Job{
+		Name:     "gifts",
+		Interval: sweepInterval,
+		Run:      giftService.ExpireDue,
+	})
+	scheduler.Start(context.Background())
+
 	server.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	if err := server.Run(":8888"); err != nil {
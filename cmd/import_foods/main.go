@@ -0,0 +1,62 @@
+// import_foods загружает справочник продуктов из CSV:
+//
+//	go run ./cmd/import_foods foods.csv
+//
+// Первая строка — заголовок. Обязательные столбцы: name, calories, protein, fat, carbs;
+// необязательные: fibre, piece_grams и aisle (отдел магазина). Значения указываются
+// на 100 г, разделитель — запятая или точка с запятой. Продукты с уже существующим
+// названием обновляются.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"healthy_body/internal/config"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"healthy_body/internal/service"
+	"log"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "использование: %s <файл.csv>\n", os.Args[0])
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("не удалось открыть файл: %v", err)
+	}
+	defer file.Close()
+
+	db := config.SetUpDatabaseConnection()
+	if err := db.AutoMigrate(
+		&models.MealPlanItem{},
+		&models.Food{},
+		&models.Recipe{},
+		&models.RecipeIngredient{},
+	); err != nil {
+		log.Fatalf("не удалось выполнить миграции: %v", err)
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	foodService := service.NewFoodService(
+		repository.NewFoodRepository(db, logger),
+		repository.NewMealPlanItemRepository(db, logger),
+		db,
+		logger)
+
+	result, err := foodService.ImportCSV(file)
+	if err != nil {
+		log.Fatalf("загрузка не выполнена: %v", err)
+	}
+
+	fmt.Printf("добавлено продуктов: %d, обновлено: %d\n", result.Created, result.Updated)
+}
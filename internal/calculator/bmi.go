@@ -0,0 +1,108 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+)
+
+// BmiClass — класс индекса массы тела по классификации ВОЗ
+type BmiClass struct {
+	Code  string  `json:"code"`
+	Label string  `json:"label"`
+	Min   float64 `json:"min"`
+	// Max не входит в класс; 0 — без верхней границы
+	Max float64 `json:"max"`
+}
+
+var bmiClasses = []BmiClass{
+	{Code: "severe_thinness", Label: "Выраженный дефицит массы тела", Min: 0, Max: 16},
+	{Code: "moderate_thinness", Label: "Умеренный дефицит массы тела", Min: 16, Max: 17},
+	{Code: "mild_thinness", Label: "Лёгкий дефицит массы тела", Min: 17, Max: 18.5},
+	{Code: "normal", Label: "Нормальный вес", Min: 18.5, Max: 25},
+	{Code: "pre_obese", Label: "Избыточный вес (предожирение)", Min: 25, Max: 30},
+	{Code: "obese_1", Label: "Ожирение I степени", Min: 30, Max: 35},
+	{Code: "obese_2", Label: "Ожирение II степени", Min: 35, Max: 40},
+	{Code: "obese_3", Label: "Ожирение III степени", Min: 40},
+}
+
+const (
+	normalBmiMin = 18.5
+	normalBmiMax = 24.9
+)
+
+// BmiCalc принимает вес в килограммах и рост в сантиметрах,
+// возвращает название класса ВОЗ и индекс массы тела
+func BmiCalc(weight, height float64) (string, float64) {
+	bmi := Bmi(weight, height)
+	return ClassifyBmi(bmi).Label, bmi
+}
+
+// Bmi возвращает индекс массы тела, округлённый до сотых
+func Bmi(weight, height float64) float64 {
+	hMeters := height / 100
+	return Round(weight / (hMeters * hMeters))
+}
+
+func ClassifyBmi(bmi float64) BmiClass {
+	for _, class := range bmiClasses {
+		if class.Max == 0 || bmi < class.Max {
+			return class
+		}
+	}
+
+	return bmiClasses[len(bmiClasses)-1]
+}
+
+// BmiClasses возвращает все классы ВОЗ по возрастанию BMI
+func BmiClasses() []BmiClass {
+	return append([]BmiClass(nil), bmiClasses...)
+}
+
+// IdealWeight — диапазон нормального веса для роста и вес по формуле Девина
+type IdealWeight struct {
+	MinKg    float64 `json:"min_kg"`
+	MaxKg    float64 `json:"max_kg"`
+	DevineKg float64 `json:"devine_kg"`
+}
+
+// IdealWeightRange возвращает диапазон веса, при котором BMI остаётся нормальным
+func IdealWeightRange(sex Sex, heightCm float64) (*IdealWeight, error) {
+	if err := validateHeight(heightCm); err != nil {
+		return nil, err
+	}
+
+	hMeters := heightCm / 100
+	result := &IdealWeight{
+		MinKg: Round(normalBmiMin * hMeters * hMeters),
+		MaxKg: Round(normalBmiMax * hMeters * hMeters),
+	}
+
+	// формула Девина определена для роста выше пяти футов
+	inchesOverFiveFeet := math.Max(heightCm/2.54-60, 0)
+	switch sex {
+	case SexMale:
+		result.DevineKg = Round(50 + 2.3*inchesOverFiveFeet)
+	case SexFemale:
+		result.DevineKg = Round(45.5 + 2.3*inchesOverFiveFeet)
+	default:
+		return nil, ErrInvalidSex
+	}
+
+	return result, nil
+}
+
+func validateHeight(heightCm float64) error {
+	if heightCm < 50 || heightCm > 280 {
+		return errors.New("рост должен быть от 50 до 280 см")
+	}
+
+	return nil
+}
+
+func validateWeight(weightKg float64) error {
+	if weightKg <= 0 || weightKg > 500 {
+		return errors.New("вес должен быть от 0 до 500 кг")
+	}
+
+	return nil
+}
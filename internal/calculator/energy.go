@@ -0,0 +1,118 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Sex string
+
+const (
+	SexMale   Sex = "male"
+	SexFemale Sex = "female"
+)
+
+type BmrFormula string
+
+const (
+	FormulaMifflinStJeor  BmrFormula = "mifflin_st_jeor"
+	FormulaHarrisBenedict BmrFormula = "harris_benedict"
+)
+
+type ActivityLevel string
+
+const (
+	ActivitySedentary  ActivityLevel = "sedentary"
+	ActivityLight      ActivityLevel = "light"
+	ActivityModerate   ActivityLevel = "moderate"
+	ActivityActive     ActivityLevel = "active"
+	ActivityVeryActive ActivityLevel = "very_active"
+)
+
+var activityFactors = map[ActivityLevel]float64{
+	ActivitySedentary:  1.2,
+	ActivityLight:      1.375,
+	ActivityModerate:   1.55,
+	ActivityActive:     1.725,
+	ActivityVeryActive: 1.9,
+}
+
+var (
+	ErrInvalidSex      = errors.New("пол должен быть male или female")
+	ErrInvalidFormula  = errors.New("формула должна быть mifflin_st_jeor или harris_benedict")
+	ErrInvalidActivity = errors.New("уровень активности должен быть sedentary, light, moderate, active или very_active")
+)
+
+// Profile — параметры человека, по которым считается расход энергии
+type Profile struct {
+	Sex      Sex
+	AgeYears int
+	WeightKg float64
+	HeightCm float64
+}
+
+func (p Profile) Validate() error {
+	if p.Sex != SexMale && p.Sex != SexFemale {
+		return ErrInvalidSex
+	}
+	if p.AgeYears < 10 || p.AgeYears > 120 {
+		return errors.New("возраст должен быть от 10 до 120 лет")
+	}
+	if err := validateWeight(p.WeightKg); err != nil {
+		return err
+	}
+
+	return validateHeight(p.HeightCm)
+}
+
+// BMR возвращает базовый обмен веществ в ккал в сутки.
+// Пустая формула означает Миффлина — Сан Жеора
+func BMR(profile Profile, formula BmrFormula) (float64, error) {
+	if err := profile.Validate(); err != nil {
+		return 0, err
+	}
+
+	weight, height, age := profile.WeightKg, profile.HeightCm, float64(profile.AgeYears)
+
+	switch formula {
+	case FormulaMifflinStJeor, "":
+		bmr := 10*weight + 6.25*height - 5*age
+		if profile.Sex == SexMale {
+			return Round(bmr + 5), nil
+		}
+		return Round(bmr - 161), nil
+	case FormulaHarrisBenedict:
+		// пересмотренная формула Рози и Шизгала (1984)
+		if profile.Sex == SexMale {
+			return Round(88.362 + 13.397*weight + 4.799*height - 5.677*age), nil
+		}
+		return Round(447.593 + 9.247*weight + 3.098*height - 4.330*age), nil
+	default:
+		return 0, ErrInvalidFormula
+	}
+}
+
+// ActivityFactor возвращает коэффициент активности для расчёта TDEE
+func ActivityFactor(level ActivityLevel) (float64, error) {
+	factor, ok := activityFactors[level]
+	if !ok {
+		return 0, ErrInvalidActivity
+	}
+
+	return factor, nil
+}
+
+// TDEE возвращает суточный расход энергии с учётом активности
+func TDEE(profile Profile, formula BmrFormula, level ActivityLevel) (float64, error) {
+	factor, err := ActivityFactor(level)
+	if err != nil {
+		return 0, err
+	}
+
+	bmr, err := BMR(profile, formula)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при расчёте BMR: %w", err)
+	}
+
+	return Round(bmr * factor), nil
+}
@@ -0,0 +1,58 @@
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBMR(t *testing.T) {
+	man := Profile{Sex: SexMale, AgeYears: 30, WeightKg: 80, HeightCm: 180}
+	woman := Profile{Sex: SexFemale, AgeYears: 25, WeightKg: 60, HeightCm: 165}
+
+	tests := []struct {
+		name    string
+		profile Profile
+		formula BmrFormula
+		want    float64
+		wantErr error
+	}{
+		{name: "mifflin male", profile: man, formula: FormulaMifflinStJeor, want: 1780},
+		{name: "mifflin female", profile: woman, formula: FormulaMifflinStJeor, want: 1345.25},
+		{name: "default formula is mifflin", profile: man, want: 1780},
+		{name: "harris-benedict male", profile: man, formula: FormulaHarrisBenedict, want: 1853.63},
+		{name: "harris-benedict female", profile: woman, formula: FormulaHarrisBenedict, want: 1405.33},
+		{name: "unknown formula", profile: man, formula: "katch_mcardle", wantErr: ErrInvalidFormula},
+		{name: "unknown sex", profile: Profile{AgeYears: 30, WeightKg: 80, HeightCm: 180}, wantErr: ErrInvalidSex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BMR(tt.profile, tt.formula)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BMR() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("BMR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBMRRejectsInvalidProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+	}{
+		{name: "too young", profile: Profile{Sex: SexMale, AgeYears: 9, WeightKg: 30, HeightCm: 130}},
+		{name: "zero weight", profile: Profile{Sex: SexMale, AgeYears: 30, HeightCm: 180}},
+		{name: "zero height", profile: Profile{Sex: SexFemale, AgeYears: 30, WeightKg: 60}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BMR(tt.profile, FormulaMifflinStJeor); err == nil {
+				t.Error("BMR() должен вернуть ошибку")
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+)
+
+type Goal string
+
+const (
+	GoalCut      Goal = "cut"
+	GoalMaintain Goal = "maintain"
+	GoalBulk     Goal = "bulk"
+)
+
+const (
+	caloriesPerGramProtein = 4
+	caloriesPerGramCarbs   = 4
+	caloriesPerGramFat     = 9
+	// fibrePer1000Calories — рекомендуемая клетчатка на каждые 1000 ккал рациона
+	fibrePer1000Calories = 14
+)
+
+var ErrInvalidGoal = errors.New("цель должна быть cut, maintain или bulk")
+
+// goalRules — поправка к TDEE, белок на килограмм веса и доля жиров в калориях
+var goalRules = map[Goal]struct {
+	calorieFactor  float64
+	proteinPerKg   float64
+	fatCalorieRate float64
+}{
+	GoalCut:      {calorieFactor: 0.8, proteinPerKg: 2.2, fatCalorieRate: 0.25},
+	GoalMaintain: {calorieFactor: 1.0, proteinPerKg: 1.8, fatCalorieRate: 0.3},
+	GoalBulk:     {calorieFactor: 1.1, proteinPerKg: 2.0, fatCalorieRate: 0.25},
+}
+
+// MacroTargets — суточные цели по калориям и макронутриентам
+type MacroTargets struct {
+	Goal     Goal    `json:"goal"`
+	TDEE     float64 `json:"tdee"`
+	Calories float64 `json:"calories"`
+	ProteinG float64 `json:"protein_g"`
+	FatG     float64 `json:"fat_g"`
+	CarbsG   float64 `json:"carbs_g"`
+	FibreG   float64 `json:"fibre_g"`
+}
+
+func IsValidGoal(goal Goal) bool {
+	_, ok := goalRules[goal]
+	return ok
+}
+
+// Macros распределяет калории для цели: белок считается от веса,
+// жиры — как доля калорий, углеводы получают остаток
+func Macros(tdee, weightKg float64, goal Goal) (*MacroTargets, error) {
+	rules, ok := goalRules[goal]
+	if !ok {
+		return nil, ErrInvalidGoal
+	}
+	if tdee <= 0 {
+		return nil, errors.New("суточный расход энергии должен быть положительным")
+	}
+	if err := validateWeight(weightKg); err != nil {
+		return nil, err
+	}
+
+	calories := tdee * rules.calorieFactor
+	protein := weightKg * rules.proteinPerKg
+	fat := calories * rules.fatCalorieRate / caloriesPerGramFat
+	carbs := math.Max(calories-protein*caloriesPerGramProtein-fat*caloriesPerGramFat, 0) / caloriesPerGramCarbs
+
+	return &MacroTargets{
+		Goal:     goal,
+		TDEE:     Round(tdee),
+		Calories: math.Round(calories),
+		ProteinG: math.Round(protein),
+		FatG:     math.Round(fat),
+		CarbsG:   math.Round(carbs),
+		FibreG:   math.Round(calories / 1000 * fibrePer1000Calories),
+	}, nil
+}
+
+// EnergySplit возвращает доли белков, жиров и углеводов в калориях, в процентах.
+// Калории считаются по граммам, а не берутся из продукта, чтобы доли давали в сумме 100.
+func EnergySplit(proteinG, fatG, carbsG float64) (protein, fat, carbs float64) {
+	proteinCal := proteinG * caloriesPerGramProtein
+	fatCal := fatG * caloriesPerGramFat
+	carbsCal := carbsG * caloriesPerGramCarbs
+
+	total := proteinCal + fatCal + carbsCal
+	if total <= 0 {
+		return 0, 0, 0
+	}
+
+	return Round(proteinCal / total * 100), Round(fatCal / total * 100), Round(carbsCal / total * 100)
+}
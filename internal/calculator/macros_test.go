@@ -0,0 +1,72 @@
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMacros(t *testing.T) {
+	tests := []struct {
+		name     string
+		tdee     float64
+		weightKg float64
+		goal     Goal
+		want     MacroTargets
+	}{
+		{
+			name: "maintain", tdee: 2500, weightKg: 80, goal: GoalMaintain,
+			want: MacroTargets{Goal: GoalMaintain, TDEE: 2500, Calories: 2500, ProteinG: 144, FatG: 83, CarbsG: 294, FibreG: 35},
+		},
+		{
+			name: "cut", tdee: 2500, weightKg: 80, goal: GoalCut,
+			want: MacroTargets{Goal: GoalCut, TDEE: 2500, Calories: 2000, ProteinG: 176, FatG: 56, CarbsG: 199, FibreG: 28},
+		},
+		{
+			name: "bulk", tdee: 2500, weightKg: 80, goal: GoalBulk,
+			want: MacroTargets{Goal: GoalBulk, TDEE: 2500, Calories: 2750, ProteinG: 160, FatG: 76, CarbsG: 356, FibreG: 39},
+		},
+		{
+			// белок и жиры уже превышают калории — углеводы не уходят в минус
+			name: "carbs never negative", tdee: 1000, weightKg: 200, goal: GoalCut,
+			want: MacroTargets{Goal: GoalCut, TDEE: 1000, Calories: 800, ProteinG: 440, FatG: 22, CarbsG: 0, FibreG: 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Macros(tt.tdee, tt.weightKg, tt.goal)
+			if err != nil {
+				t.Fatalf("Macros: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("Macros() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMacrosRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		tdee     float64
+		weightKg float64
+		goal     Goal
+		wantErr  error
+	}{
+		{name: "unknown goal", tdee: 2500, weightKg: 80, goal: "recomp", wantErr: ErrInvalidGoal},
+		{name: "zero tdee", weightKg: 80, goal: GoalMaintain},
+		{name: "zero weight", tdee: 2500, goal: GoalMaintain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Macros(tt.tdee, tt.weightKg, tt.goal)
+			if err == nil {
+				t.Fatal("Macros() должен вернуть ошибку")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Macros() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package calculator
+
+import (
+	"math"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// WeightPoint — одно взвешивание
+type WeightPoint struct {
+	Date     time.Time
+	WeightKg float64
+}
+
+// MovingAverage для каждой точки считает средний вес за window дней,
+// заканчивающихся датой этой точки. Точки должны идти по возрастанию даты
+func MovingAverage(points []WeightPoint, window int) []float64 {
+	if window < 1 {
+		window = 1
+	}
+
+	result := make([]float64, len(points))
+	start, sum := 0, 0.0
+	for i, point := range points {
+		sum += point.WeightKg
+		from := point.Date.Add(-time.Duration(window) * day)
+		for !points[start].Date.After(from) {
+			sum -= points[start].WeightKg
+			start++
+		}
+		result[i] = Round(sum / float64(i-start+1))
+	}
+
+	return result
+}
+
+// WeeklyChange оценивает изменение веса за неделю по наклону линейной регрессии.
+// Для оценки нужны минимум две точки в разные дни
+func WeeklyChange(points []WeightPoint) (float64, bool) {
+	if len(points) < 2 {
+		return 0, false
+	}
+
+	origin := points[0].Date
+	var sumX, sumY, sumXY, sumXX float64
+	for _, point := range points {
+		x := point.Date.Sub(origin).Hours() / 24
+		sumX += x
+		sumY += point.WeightKg
+		sumXY += x * point.WeightKg
+		sumXX += x * x
+	}
+
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+
+	slope := (n*sumXY - sumX*sumY) / denominator
+	return Round(slope * 7), true
+}
+
+// EstimateGoalDate прогнозирует дату достижения целевого веса при текущем темпе.
+// Возвращает false, если вес не меняется или меняется в обратную сторону
+func EstimateGoalDate(from time.Time, currentKg, targetKg, weeklyChangeKg float64) (time.Time, bool) {
+	distance := targetKg - currentKg
+	if distance == 0 {
+		return from, true
+	}
+	if weeklyChangeKg == 0 || math.Signbit(distance) != math.Signbit(weeklyChangeKg) {
+		return time.Time{}, false
+	}
+
+	days := distance / weeklyChangeKg * 7
+	return from.Add(time.Duration(math.Ceil(days)) * day), true
+}
+
+// Round округляет до сотых
+func Round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
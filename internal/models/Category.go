@@ -7,6 +7,10 @@ type Categories struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Price       int    `json:"price"`
+	// Goal — на какую цель рассчитана программа: cut, maintain или bulk; пустая — не указана
+	Goal string `json:"goal"`
+	// TrainerID — пользователь-тренер, который владеет категорией и получает долю от её продаж
+	TrainerID *uint `json:"trainer_id" gorm:"index"`
 
 	ExercisePlans []ExercisePlan `json:"exercise_plans"`
 	MealPlans     []MealPlan     `json:"meal_plans"`
@@ -16,10 +20,15 @@ type CreateCategoryRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Price       int    `json:"price"`
+	Goal        string `json:"goal"`
+	TrainerID   *uint  `json:"trainer_id"`
 }
 
 type UpdateCategoryRequest struct {
 	Name        *string `json:"name"`
 	Description *string `json:"description"`
 	Price       *int    `json:"price"`
+	Goal        *string `json:"goal"`
+	// TrainerID — новый владелец категории; 0 снимает владельца
+	TrainerID *uint `json:"trainer_id"`
 }
@@ -1,11 +1,20 @@
 package models
 
-import "gorm.io/gorm"
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
 
 type UserPlan struct {
     gorm.Model
     UserID     uint
     CategoriesID uint
+    JournalEntryID *uint // проводка, которой оплачена покупка
+    Source     string `gorm:"default:purchase"` // purchase или gift
+    // VersionPinnedAt — покупатель видит версии планов, опубликованные до этого момента;
+    // пусто — до момента покупки. Сдвигается вперёд только по желанию пользователя
+    VersionPinnedAt *time.Time
 
     User     *User     		`gorm:"foreignKey:UserID"`
     Categories *Categories 	`gorm:"foreignKey:CategoriesID"` // обязательно указать foreignKey
@@ -6,14 +6,53 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	SubscriptionStatusActive  = "active"
+	SubscriptionStatusExpired = "expired"
+)
+
 type UserSubscription struct {
 	gorm.Model
-	UserID         uint      `json:"user_id"`
-	SubscriptionID uint      `json:"subscription_id"`
-	StartDate      time.Time `json:"start_date"`
-	EndDate        time.Time `json:"end_date"`
-	IsActive       bool      `json:"is_active"`
-
-	User         *User         `json:"-" gorm:"foreignKey:UserID"`
-	Subscription *Subscription `json:"-" gorm:"foreignKey:SubscriptionID"`
+	UserID            uint       `json:"user_id" gorm:"index"`
+	SubscriptionID    uint       `json:"subscription_id"`
+	StartDate         time.Time  `json:"start_date"`
+	EndDate           time.Time  `json:"end_date" gorm:"index"`
+	IsActive          bool       `json:"is_active"`
+	Status            string     `json:"status" gorm:"index;default:active"`
+	AutoRenew         bool       `json:"auto_renew"`
+	CancelAtPeriodEnd bool       `json:"cancel_at_period_end"`
+	CanceledAt        *time.Time `json:"canceled_at"`
+	RenewedAt         *time.Time `json:"renewed_at"`
+	ExpiredAt         *time.Time `json:"expired_at"`
+	JournalEntryID    *uint      `json:"journal_entry_id"`
+
+	User         *User               `json:"-" gorm:"foreignKey:UserID"`
+	Subscription *Subscription       `json:"-" gorm:"foreignKey:SubscriptionID"`
+	Events       []SubscriptionEvent `json:"events,omitempty" gorm:"foreignKey:UserSubscriptionID"`
+}
+
+const (
+	SubscriptionEventCreated       = "created"
+	SubscriptionEventRenewed       = "renewed"
+	SubscriptionEventCanceled      = "canceled"
+	SubscriptionEventAutoRenewOn   = "auto_renew_on"
+	SubscriptionEventAutoRenewOff  = "auto_renew_off"
+	SubscriptionEventRenewalFailed = "renewal_failed"
+	SubscriptionEventExpired       = "expired"
+)
+
+// SubscriptionEvent — запись о смене состояния подписки пользователя
+type SubscriptionEvent struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt          time.Time `json:"created_at"`
+	UserSubscriptionID uint      `json:"user_subscription_id" gorm:"index"`
+	Event              string    `json:"event"`
+	FromStatus         string    `json:"from_status"`
+	ToStatus           string    `json:"to_status"`
+	Details            string    `json:"details"`
+	JournalEntryID     *uint     `json:"journal_entry_id"`
+}
+
+type AutoRenewRequest struct {
+	AutoRenew bool `json:"auto_renew"`
 }
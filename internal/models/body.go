@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BodyMeasurement — замер тела пользователя; BMI рассчитывается при сохранении
+type BodyMeasurement struct {
+	gorm.Model
+	UserID         uint      `json:"user_id" gorm:"index"`
+	MeasuredAt     time.Time `json:"measured_at" gorm:"index"`
+	WeightKg       float64   `json:"weight_kg"`
+	HeightCm       float64   `json:"height_cm"`
+	WaistCm        *float64  `json:"waist_cm"`
+	BodyFatPercent *float64  `json:"body_fat_percent"`
+	Bmi            float64   `json:"bmi"`
+	BmiCategory    string    `json:"bmi_category"`
+}
+
+type CreateBodyMeasurementRequest struct {
+	WeightKg float64 `json:"weight_kg"`
+	// если рост не указан, берётся из последнего замера
+	HeightCm       *float64   `json:"height_cm"`
+	WaistCm        *float64   `json:"waist_cm"`
+	BodyFatPercent *float64   `json:"body_fat_percent"`
+	MeasuredAt     *time.Time `json:"measured_at"`
+}
+
+// BodyGoal — целевой вес пользователя
+type BodyGoal struct {
+	gorm.Model
+	UserID         uint    `json:"user_id" gorm:"uniqueIndex"`
+	StartWeightKg  float64 `json:"start_weight_kg"`
+	TargetWeightKg float64 `json:"target_weight_kg"`
+}
+
+type SetBodyGoalRequest struct {
+	TargetWeightKg float64 `json:"target_weight_kg"`
+}
+
+type BodyTrendPoint struct {
+	MeasuredAt    time.Time `json:"measured_at"`
+	WeightKg      float64   `json:"weight_kg"`
+	MovingAverage float64   `json:"moving_average"`
+	Bmi           float64   `json:"bmi"`
+}
+
+// BodyGoalProgress — насколько пользователь приблизился к целевому весу
+type BodyGoalProgress struct {
+	TargetWeightKg float64    `json:"target_weight_kg"`
+	StartWeightKg  float64    `json:"start_weight_kg"`
+	DistanceKg     float64    `json:"distance_kg"`
+	Percent        float64    `json:"percent"`
+	EstimatedDate  *time.Time `json:"estimated_date"`
+}
+
+type BodyTrend struct {
+	WindowDays     int               `json:"window_days"`
+	CurrentKg      *float64          `json:"current_kg"`
+	WeeklyChangeKg *float64          `json:"weekly_change_kg"`
+	Points         []BodyTrendPoint  `json:"points"`
+	Goal           *BodyGoalProgress `json:"goal,omitempty"`
+}
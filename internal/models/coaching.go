@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CoachingStatusPending  = "pending"
+	CoachingStatusActive   = "active"
+	CoachingStatusDeclined = "declined"
+	CoachingStatusEnded    = "ended"
+)
+
+// TrainerClient — связь тренера и клиента. Клиент отправляет заявку, тренер принимает её
+// или отклоняет; активную связь может завершить любая из сторон. Пока связь активна,
+// тренер составляет клиенту личные планы и комментирует его тренировки
+type TrainerClient struct {
+	gorm.Model
+	TrainerID   uint       `json:"trainer_id" gorm:"index"`
+	ClientID    uint       `json:"client_id" gorm:"index"`
+	Status      string     `json:"status" gorm:"index"`
+	Message     string     `json:"message"` // сопроводительное сообщение клиента к заявке
+	RespondedAt *time.Time `json:"responded_at"`
+	EndedAt     *time.Time `json:"ended_at"`
+}
+
+type CoachingRequest struct {
+	Message string `json:"message"`
+}
+
+// AssignPlanRequest — тренер копирует план PlanID в личный план клиента;
+// пустые Name и Description берутся из исходного плана
+type AssignPlanRequest struct {
+	PlanID      uint   `json:"plan_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// AssignedPlans — личные планы, которые тренер составил клиенту
+type AssignedPlans struct {
+	ExercisePlans []ExercisePlan `json:"exercise_plans"`
+	MealPlans     []MealPlan     `json:"meal_plans"`
+}
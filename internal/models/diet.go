@@ -0,0 +1,57 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// диетические теги блюд и планов питания
+const (
+	DietVegetarian  = "vegetarian"
+	DietVegan       = "vegan"
+	DietHalal       = "halal"
+	DietGlutenFree  = "gluten_free"
+	DietLactoseFree = "lactose_free"
+)
+
+// аллергены, которые отмечаются у блюд
+const (
+	AllergenGluten    = "gluten"
+	AllergenMilk      = "milk"
+	AllergenEggs      = "eggs"
+	AllergenNuts      = "nuts"
+	AllergenPeanuts   = "peanuts"
+	AllergenSoy       = "soy"
+	AllergenFish      = "fish"
+	AllergenShellfish = "shellfish"
+	AllergenSesame    = "sesame"
+)
+
+var DietTags = []string{DietVegetarian, DietVegan, DietHalal, DietGlutenFree, DietLactoseFree}
+
+var Allergens = []string{
+	AllergenGluten, AllergenMilk, AllergenEggs, AllergenNuts, AllergenPeanuts,
+	AllergenSoy, AllergenFish, AllergenShellfish, AllergenSesame,
+}
+
+// DietaryPreferences — диеты, которых придерживается пользователь, и аллергены, которых он избегает
+type DietaryPreferences struct {
+	DietTags  []string `json:"diet_tags"`
+	Allergens []string `json:"allergens"`
+}
+
+// NormalizeTags приводит теги к нижнему регистру, убирает повторы и проверяет их по списку allowed
+func NormalizeTags(tags []string, allowed []string) ([]string, bool) {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !slices.Contains(allowed, tag) {
+			return nil, false
+		}
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	slices.Sort(result)
+	return result, true
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// уровни сложности упражнений
+const (
+	DifficultyBeginner     = "beginner"
+	DifficultyIntermediate = "intermediate"
+	DifficultyAdvanced     = "advanced"
+)
+
+var Difficulties = []string{DifficultyBeginner, DifficultyIntermediate, DifficultyAdvanced}
+
+// группы мышц, по которым ищутся упражнения
+var MuscleGroups = []string{
+	"chest", "back", "shoulders", "biceps", "triceps", "forearms", "core",
+	"glutes", "quads", "hamstrings", "calves", "full_body", "cardio",
+}
+
+// EquipmentTypes — инвентарь; bodyweight означает, что инвентарь не нужен
+var EquipmentTypes = []string{
+	"bodyweight", "barbell", "dumbbell", "kettlebell", "machine", "cable",
+	"resistance_band", "bench", "pull_up_bar", "mat", "cardio_machine",
+}
+
+// Exercise — упражнение из общей библиотеки, на которое ссылаются пункты тренировочных планов
+type Exercise struct {
+	gorm.Model
+	Name         string   `json:"name" gorm:"uniqueIndex"`
+	MuscleGroups []string `json:"muscle_groups" gorm:"serializer:json"`
+	Equipment    []string `json:"equipment" gorm:"serializer:json"`
+	Difficulty   string   `json:"difficulty"`
+	Instructions string   `json:"instructions"`
+	MediaURL     string   `json:"media_url"`
+}
+
+type CreateExerciseRequest struct {
+	Name         string   `json:"name"`
+	MuscleGroups []string `json:"muscle_groups"`
+	Equipment    []string `json:"equipment"`
+	Difficulty   string   `json:"difficulty"`
+	Instructions string   `json:"instructions"`
+	MediaURL     string   `json:"media_url"`
+}
+
+type UpdateExerciseRequest struct {
+	Name         *string   `json:"name"`
+	MuscleGroups *[]string `json:"muscle_groups"`
+	Equipment    *[]string `json:"equipment"`
+	Difficulty   *string   `json:"difficulty"`
+	Instructions *string   `json:"instructions"`
+	MediaURL     *string   `json:"media_url"`
+}
+
+// ExerciseFilter — поиск по библиотеке; пустые поля не ограничивают выборку
+type ExerciseFilter struct {
+	Query       string
+	MuscleGroup string
+	Equipment   string
+	Difficulty  string
+}
+
+// IsValidTempo проверяет темп в виде четырёх фаз через дефис: опускание, пауза внизу,
+// подъём, пауза вверху. Каждая фаза — секунды от 0 до 9 или X (взрывное выполнение)
+func IsValidTempo(tempo string) bool {
+	phases := strings.Split(tempo, "-")
+	if len(phases) != 4 {
+		return false
+	}
+	for _, phase := range phases {
+		if len(phase) != 1 || !strings.ContainsAny(phase, "0123456789Xx") {
+			return false
+		}
+	}
+	return true
+}
@@ -12,6 +12,16 @@ type ExercisePlan struct {
 
 	CategoriesID uint        `json:"categories_id"`
 	Categories   *Categories `json:"-"`
+
+	// ClientID — личный план, который тренер TrainerID составил для одного клиента на основе
+	// плана SourcePlanID; в каталоге и программах категории такие планы не показываются
+	ClientID     *uint `json:"client_id,omitempty" gorm:"index"`
+	TrainerID    *uint `json:"trainer_id,omitempty" gorm:"index"`
+	SourcePlanID *uint `json:"source_plan_id,omitempty"`
+
+	// Version — показанная опубликованная версия, 0 у черновика
+	Version       int `json:"version" gorm:"-"`
+	LatestVersion int `json:"latest_version" gorm:"-"`
 }
 
 type CreateExercesicePlanRequest struct {
@@ -1,13 +1,45 @@
 package models
 
+const (
+	DayMonday    = "monday"
+	DayTuesday   = "tuesday"
+	DayWednesday = "wednesday"
+	DayThursday  = "thursday"
+	DayFriday    = "friday"
+	DaySaturday  = "saturday"
+	DaySunday    = "sunday"
+)
+
+// Weekdays — дни недели в порядке, в котором они идут в расписании плана
+var Weekdays = []string{DayMonday, DayTuesday, DayWednesday, DayThursday, DayFriday, DaySaturday, DaySunday}
+
+func IsValidWeekday(day string) bool {
+	for _, d := range Weekdays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
 type ExercisePlanItem struct {
 	ID              uint   `json:"id" gorm:"primaryKey;autoIncrement"`
 	Name            string `json:"name"`
 	Sets            int    `json:"sets"`
 	Reps            int    `json:"reps"`
-	DurationMinutes string `json:"duration_minutes"`
+	DurationMinutes int    `json:"duration_minutes"`
 	EquipmentNeeded string `json:"equipment_needed"`
-	DayOfWeek       string `json:"day_of_week"`
+	Week            int    `json:"week" gorm:"default:1"` // от 1 до DurationWeeks плана
+	DayOfWeek       string `json:"day_of_week"`           // monday … sunday
+	RestSeconds     int    `json:"rest_seconds"`          // отдых между подходами
+	Tempo           string `json:"tempo"`                 // например 3-1-X-0, см. IsValidTempo
+	// WeightKg — рабочий вес в первую неделю; без него прогрессия нагрузки считается в процентах
+	WeightKg    *float64        `json:"weight_kg"`
+	Progression ProgressionRule `json:"progression" gorm:"embedded;embeddedPrefix:progression_"`
+
+	// ExerciseID — упражнение из библиотеки; Name и EquipmentNeeded тогда заполняются из неё
+	ExerciseID *uint     `json:"exercise_id" gorm:"index"`
+	Exercise   *Exercise `json:"exercise,omitempty"`
 
 	ExercisePlanID uint          `json:"exercise_plan_id"`
 	ExercisePlan   *ExercisePlan `json:"-"`
@@ -17,18 +49,92 @@ type CreateExercisePlanItemRequest struct {
 	Name            string `json:"name"`
 	Sets            int    `json:"sets"`
 	Reps            int    `json:"reps"`
-	DurationMinutes string `json:"duration_minutes"`
+	DurationMinutes int    `json:"duration_minutes"`
 	EquipmentNeeded string `json:"equipment_needed"`
+	Week            int    `json:"week"` // по умолчанию 1
 	DayOfWeek       string `json:"day_of_week"`
+	RestSeconds     int    `json:"rest_seconds"`
+	Tempo           string `json:"tempo"`
+	ExerciseID      *uint  `json:"exercise_id"`
 	ExercisePlanID  uint   `json:"exercise_plan_id"`
+
+	WeightKg    *float64        `json:"weight_kg"`
+	Progression ProgressionRule `json:"progression"`
 }
 
 type UpdateExercisePlanItemRequest struct {
 	Name            *string `json:"name"`
 	Sets            *int    `json:"sets"`
 	Reps            *int    `json:"reps"`
-	DurationMinutes *string `json:"duration_minutes"`
+	DurationMinutes *int    `json:"duration_minutes"`
 	EquipmentNeeded *string `json:"equipment_needed"`
+	Week            *int    `json:"week"`
 	DayOfWeek       *string `json:"day_of_week"`
+	RestSeconds     *int    `json:"rest_seconds"`
+	Tempo           *string `json:"tempo"`
+	ExerciseID      *uint   `json:"exercise_id"`
 	ExercisePlanID  *uint   `json:"exercise_plan_id"`
+
+	WeightKg *float64 `json:"weight_kg"`
+	// Progression заменяет правило целиком
+	Progression *ProgressionRule `json:"progression"`
+}
+
+// ProgressionRule — как меняется пункт плана от недели к неделе. Пункт с правилом
+// повторяется каждую неделю, начиная с Week, до конца плана; без правила он стоит только на Week
+type ProgressionRule struct {
+	RepsPerWeek        int     `json:"reps_per_week"`
+	MaxReps            int     `json:"max_reps"`              // 0 — без ограничения
+	LoadPercentPerWeek float64 `json:"load_percent_per_week"` // прибавка к нагрузке первой недели
+	// DeloadEvery — каждая N-я неделя от начала пункта разгрузочная: подходы и нагрузка
+	// снижаются на DeloadPercent, прогрессия в эту неделю не растёт
+	DeloadEvery   int     `json:"deload_every"`
+	DeloadPercent float64 `json:"deload_percent"`
+}
+
+// Active сообщает, задано ли правило прогрессии
+func (r ProgressionRule) Active() bool {
+	return r != ProgressionRule{}
+}
+
+// ExercisePrescription — пункт плана с подходами, повторами и нагрузкой конкретной недели
+type ExercisePrescription struct {
+	ExercisePlanItemID uint      `json:"exercise_plan_item_id"`
+	Name               string    `json:"name"`
+	Sets               int       `json:"sets"`
+	Reps               int       `json:"reps"`
+	WeightKg           *float64  `json:"weight_kg"`
+	LoadPercent        float64   `json:"load_percent"` // от нагрузки первой недели пункта
+	DurationMinutes    int       `json:"duration_minutes"`
+	RestSeconds        int       `json:"rest_seconds"`
+	Tempo              string    `json:"tempo"`
+	EquipmentNeeded    string    `json:"equipment_needed"`
+	Deload             bool      `json:"deload"`
+	ExerciseID         *uint     `json:"exercise_id"`
+	Exercise           *Exercise `json:"exercise,omitempty"`
+}
+
+// ScheduleDay — упражнения одного дня недели; день без упражнений — день отдыха
+type ScheduleDay struct {
+	DayOfWeek       string                 `json:"day_of_week"`
+	Rest            bool                   `json:"rest"`
+	DurationMinutes int                    `json:"duration_minutes"`
+	Exercises       []ExercisePrescription `json:"exercises"`
+}
+
+type ScheduleWeek struct {
+	ExercisePlanID  uint          `json:"exercise_plan_id"`
+	Week            int           `json:"week"`
+	DurationMinutes int           `json:"duration_minutes"`
+	Days            []ScheduleDay `json:"days"`
+}
+
+// ExercisePlanSchedule — календарь тренировочного плана по неделям
+type ExercisePlanSchedule struct {
+	ExercisePlanID uint           `json:"exercise_plan_id"`
+	Name           string         `json:"name"`
+	DurationWeeks  int            `json:"duration_weeks"`
+	Weeks          []ScheduleWeek `json:"weeks"`
+	// Unscheduled — пункты, перенесённые из старых планов без корректной недели или дня
+	Unscheduled []ExercisePlanItem `json:"unscheduled"`
 }
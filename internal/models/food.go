@@ -0,0 +1,126 @@
+package models
+
+import "gorm.io/gorm"
+
+// единицы количества ингредиента в рецепте
+const (
+	UnitGram       = "g"
+	UnitKilogram   = "kg"
+	UnitMillilitre = "ml"
+	UnitLitre      = "l"
+	UnitTeaspoon   = "tsp"
+	UnitTablespoon = "tbsp"
+	UnitPiece      = "pcs" // требует PieceGrams у продукта
+)
+
+// DefaultAisle — отдел магазина для продуктов, у которых он не указан
+const DefaultAisle = "other"
+
+// Food — продукт из справочника; пищевая ценность указана на 100 г
+type Food struct {
+	gorm.Model
+	Name     string  `json:"name" gorm:"uniqueIndex"`
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Fat      float64 `json:"fat"`
+	Carbs    float64 `json:"carbs"`
+	Fibre    float64 `json:"fibre"`
+	// PieceGrams — вес одной штуки, нужен для единицы pcs
+	PieceGrams *float64 `json:"piece_grams"`
+	// Aisle — отдел магазина, по которому группируется список покупок
+	Aisle string `json:"aisle"`
+}
+
+// Recipe — блюдо из продуктов справочника на Servings порций
+type Recipe struct {
+	gorm.Model
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Servings    int                `json:"servings" gorm:"default:1"`
+	Ingredients []RecipeIngredient `json:"ingredients" gorm:"foreignKey:RecipeID"`
+
+	// рассчитываются сервисом по ингредиентам
+	Nutrition  NutritionTotals `json:"nutrition" gorm:"-"`
+	PerServing NutritionTotals `json:"per_serving" gorm:"-"`
+}
+
+type RecipeIngredient struct {
+	ID       uint    `json:"id" gorm:"primaryKey"`
+	RecipeID uint    `json:"recipe_id" gorm:"index"`
+	FoodID   uint    `json:"food_id" gorm:"index"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Food     *Food   `json:"food,omitempty"`
+}
+
+type CreateFoodRequest struct {
+	Name       string   `json:"name"`
+	Calories   float64  `json:"calories"`
+	Protein    float64  `json:"protein"`
+	Fat        float64  `json:"fat"`
+	Carbs      float64  `json:"carbs"`
+	Fibre      float64  `json:"fibre"`
+	PieceGrams *float64 `json:"piece_grams"`
+	Aisle      string   `json:"aisle"`
+}
+
+type UpdateFoodRequest struct {
+	Name       *string  `json:"name"`
+	Calories   *float64 `json:"calories"`
+	Protein    *float64 `json:"protein"`
+	Fat        *float64 `json:"fat"`
+	Carbs      *float64 `json:"carbs"`
+	Fibre      *float64 `json:"fibre"`
+	PieceGrams *float64 `json:"piece_grams"`
+	Aisle      *string  `json:"aisle"`
+}
+
+type RecipeIngredientRequest struct {
+	FoodID   uint    `json:"food_id"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"` // g, kg, ml, l, tsp, tbsp или pcs; по умолчанию g
+}
+
+type CreateRecipeRequest struct {
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Servings    int                       `json:"servings"`
+	Ingredients []RecipeIngredientRequest `json:"ingredients"`
+}
+
+// UpdateRecipeRequest — переданный список Ingredients полностью заменяет состав
+type UpdateRecipeRequest struct {
+	Name        *string                    `json:"name"`
+	Description *string                    `json:"description"`
+	Servings    *int                       `json:"servings"`
+	Ingredients *[]RecipeIngredientRequest `json:"ingredients"`
+}
+
+// FoodImportResult — итог загрузки справочника продуктов из CSV
+type FoodImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+type ShoppingListItem struct {
+	FoodID   uint    `json:"food_id"`
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"` // g, kg, ml, l или pcs
+}
+
+type ShoppingListAisle struct {
+	Aisle string             `json:"aisle"`
+	Items []ShoppingListItem `json:"items"`
+}
+
+// ShoppingList — продукты для блюд плана питания за дни с FromDay по ToDay включительно
+type ShoppingList struct {
+	MealPlanID   uint                `json:"meal_plan_id"`
+	MealPlanName string              `json:"meal_plan_name"`
+	FromDay      int                 `json:"from_day"`
+	ToDay        int                 `json:"to_day"`
+	Aisles       []ShoppingListAisle `json:"aisles"`
+	// WithoutRecipe — блюда без рецепта, их состав неизвестен
+	WithoutRecipe []string `json:"without_recipe"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	GiftStatusPending  = "pending"
+	GiftStatusAccepted = "accepted"
+	GiftStatusDeclined = "declined"
+	GiftStatusExpired  = "expired"
+)
+
+// Gift — категория, оплаченная одним пользователем для другого. Пока получатель
+// не принял подарок, деньги лежат на счёте подарков, а доступ не выдан
+type Gift struct {
+	gorm.Model
+	SenderID          uint       `json:"sender_id" gorm:"index"`
+	RecipientID       uint       `json:"recipient_id" gorm:"index"`
+	CategoriesID      uint       `json:"categories_id" gorm:"index"`
+	Amount            int        `json:"amount"`
+	Message           string     `json:"message"`
+	Status            string     `json:"status" gorm:"index;default:pending"`
+	ExpiresAt         time.Time  `json:"expires_at" gorm:"index"`
+	RespondedAt       *time.Time `json:"responded_at"`
+	JournalEntryID    *uint      `json:"journal_entry_id"`
+	SettlementEntryID *uint      `json:"settlement_entry_id"`
+	UserPlanID        *uint      `json:"user_plan_id"`
+
+	Sender     *User       `json:"-" gorm:"foreignKey:SenderID"`
+	Recipient  *User       `json:"-" gorm:"foreignKey:RecipientID"`
+	Categories *Categories `json:"categories,omitempty" gorm:"foreignKey:CategoriesID"`
+}
+
+type CreateGiftRequest struct {
+	RecipientID uint   `json:"recipient_id"`
+	CategoryID  uint   `json:"category_id"`
+	Message     string `json:"message"`
+}
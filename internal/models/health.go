@@ -0,0 +1,64 @@
+package models
+
+// HealthProfileRequest — входные данные калькуляторов; каждому расчёту нужны не все поля
+type HealthProfileRequest struct {
+	Sex      string  `json:"sex"` // male или female
+	Age      int     `json:"age"`
+	WeightKg float64 `json:"weight_kg"`
+	HeightCm float64 `json:"height_cm"`
+	// mifflin_st_jeor (по умолчанию) или harris_benedict
+	Formula string `json:"formula"`
+	// sedentary, light, moderate, active или very_active
+	Activity string `json:"activity"`
+	// cut, maintain или bulk
+	Goal string `json:"goal"`
+}
+
+// NutritionTotals — калории и нутриенты в граммах
+type NutritionTotals struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Fat      float64 `json:"fat"`
+	Carbs    float64 `json:"carbs"`
+	Fibre    float64 `json:"fibre"`
+}
+
+// MacroSplit — доли белков, жиров и углеводов в калориях, в процентах
+type MacroSplit struct {
+	ProteinPercent float64 `json:"protein_percent"`
+	FatPercent     float64 `json:"fat_percent"`
+	CarbsPercent   float64 `json:"carbs_percent"`
+}
+
+type MealSlotNutrition struct {
+	MealSlot string          `json:"meal_slot"` // пустой, если приём пищи не указан
+	Items    int             `json:"items"`
+	Totals   NutritionTotals `json:"totals"`
+}
+
+type DayNutrition struct {
+	Day    int                 `json:"day"`
+	Totals NutritionTotals     `json:"totals"`
+	Macros MacroSplit          `json:"macros"`
+	Slots  []MealSlotNutrition `json:"slots"`
+}
+
+// MealPlanNutrition — пищевая ценность плана по дням и за весь план
+type MealPlanNutrition struct {
+	MealPlanID   uint            `json:"meal_plan_id"`
+	TotalDays    int             `json:"total_days"`
+	Totals       NutritionTotals `json:"totals"`
+	DailyAverage NutritionTotals `json:"daily_average"`
+	Macros       MacroSplit      `json:"macros"`
+	Days         []DayNutrition  `json:"days"`
+}
+
+// MealPlanComparison — среднесуточная пищевая ценность плана питания против потребностей пользователя
+type MealPlanComparison struct {
+	MealPlanID      uint            `json:"meal_plan_id"`
+	Days            int             `json:"days"`
+	DailyAverage    NutritionTotals `json:"daily_average"`
+	Targets         NutritionTotals `json:"targets"`
+	Difference      NutritionTotals `json:"difference"`
+	CaloriesPercent float64         `json:"calories_percent"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IdempotencyKey — сохранённый результат запроса с заголовком Idempotency-Key.
+// Ключ уникален в пределах пользователя
+type IdempotencyKey struct {
+	gorm.Model
+	UserID         uint   `gorm:"uniqueIndex:idx_idempotency_user_key"`
+	Key            string `gorm:"uniqueIndex:idx_idempotency_user_key;size:255"`
+	Fingerprint    string `gorm:"size:64"`
+	ResponseStatus int
+	ResponseBody   string
+	ContentType    string
+	CompletedAt    *time.Time
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AccountKindWallet  = "wallet"
+	AccountKindSystem  = "system"
+	AccountKindTrainer = "trainer"
+)
+
+const (
+	EntryKindPurchase       = "purchase"
+	EntryKindGift           = "gift"
+	EntryKindSubscription   = "subscription"
+	EntryKindAdjustment     = "adjustment"
+	EntryKindOpeningBalance = "opening_balance"
+	EntryKindTopUp          = "top_up"
+	EntryKindRefund         = "refund"
+	EntryKindPayout         = "payout"
+)
+
+// LedgerAccount — счёт леджера. У каждого пользователя есть кошелёк,
+// у платформы — системные счета (выручка, корректировки и т.д.),
+// у тренера — счёт заработка, который платформа должна ему выплатить
+type LedgerAccount struct {
+	gorm.Model
+	Code   string `json:"code" gorm:"uniqueIndex"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind" gorm:"index"`
+	UserID *uint  `json:"user_id" gorm:"index"`
+}
+
+// JournalEntry — неизменяемая проводка; сумма Postings всегда равна нулю
+type JournalEntry struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time `json:"created_at"`
+	Kind        string    `json:"kind" gorm:"index"`
+	Description string    `json:"description"`
+	Reference   string    `json:"reference" gorm:"index"`
+	CreatedByID *uint     `json:"created_by_id"`
+
+	Postings []Posting `json:"postings"`
+}
+
+// Posting — движение по одному счёту: положительная сумма зачисляет, отрицательная списывает
+type Posting struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time `json:"created_at"`
+	JournalEntryID uint      `json:"journal_entry_id" gorm:"index"`
+	AccountID      uint      `json:"account_id" gorm:"index"`
+	Amount         int       `json:"amount"`
+
+	Account *LedgerAccount `json:"-" gorm:"foreignKey:AccountID"`
+}
+
+// LedgerTransaction — строка истории операций по кошельку пользователя
+type LedgerTransaction struct {
+	EntryID      uint      `json:"entry_id"`
+	Kind         string    `json:"kind"`
+	Description  string    `json:"description"`
+	Reference    string    `json:"reference"`
+	Amount       int       `json:"amount"`
+	BalanceAfter int       `json:"balance_after"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AdjustmentRequest struct {
+	Amount int    `json:"amount"`
+	Reason string `json:"reason"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"slices"
+
+	"gorm.io/gorm"
+)
 
 type MealPlan struct {
 	gorm.Model
@@ -8,8 +12,22 @@ type MealPlan struct {
 	Description  string         `json:"description"`
 	CategoriesID *uint          `json:"categories_id"`
 	TotalDays    int            `json:"total_days"`
-	Meals        []MealPlanItem `json:"meals" gorm:"foreignKey:ExercisePlanID`
+	Meals        []MealPlanItem `json:"meals" gorm:"foreignKey:MealPlanId"`
 	Categories   *Categories    `json:"-"`
+
+	// ClientID — личный план клиента, см. ExercisePlan.ClientID
+	ClientID     *uint `json:"client_id,omitempty" gorm:"index"`
+	TrainerID    *uint `json:"trainer_id,omitempty" gorm:"index"`
+	SourcePlanID *uint `json:"source_plan_id,omitempty"`
+
+	// DietTags — теги, которые есть у всех блюд плана, Allergens — аллергены хотя бы одного блюда
+	DietTags  []string `json:"diet_tags" gorm:"-"`
+	Allergens []string `json:"allergens" gorm:"-"`
+	// Warnings — несовпадения с диетой и аллергенами пользователя, который запросил план
+	Warnings []string `json:"warnings,omitempty" gorm:"-"`
+	// Version — показанная опубликованная версия, 0 у черновика
+	Version       int `json:"version" gorm:"-"`
+	LatestVersion int `json:"latest_version" gorm:"-"`
 }
 
 type CreateMealPlanRequest struct {
@@ -25,3 +43,24 @@ type UpdateMealPlanRequest struct {
 	CategoriesID *uint   `json:"categories_id"`
 	TotalDays    *int    `json:"total_days"`
 }
+
+// MealPlanFilter — план должен подходить под все DietTags и не содержать ни одного из ExcludeAllergens
+type MealPlanFilter struct {
+	DietTags         []string
+	ExcludeAllergens []string
+}
+
+// Matches проверяет план по DietTags и Allergens, уже заполненным у плана
+func (f MealPlanFilter) Matches(plan *MealPlan) bool {
+	for _, tag := range f.DietTags {
+		if !slices.Contains(plan.DietTags, tag) {
+			return false
+		}
+	}
+	for _, allergen := range f.ExcludeAllergens {
+		if slices.Contains(plan.Allergens, allergen) {
+			return false
+		}
+	}
+	return true
+}
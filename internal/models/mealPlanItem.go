@@ -2,6 +2,25 @@ package models
 
 import "gorm.io/gorm"
 
+const (
+	MealSlotBreakfast = "breakfast"
+	MealSlotLunch     = "lunch"
+	MealSlotDinner    = "dinner"
+	MealSlotSnack     = "snack"
+)
+
+// MealSlots — приёмы пищи в порядке, в котором они идут в течение дня
+var MealSlots = []string{MealSlotBreakfast, MealSlotLunch, MealSlotDinner, MealSlotSnack}
+
+func IsValidMealSlot(slot string) bool {
+	for _, s := range MealSlots {
+		if s == slot {
+			return true
+		}
+	}
+	return false
+}
+
 type MealPlanItem struct {
 	gorm.Model
 	Name        string    `json:"name"`
@@ -9,24 +28,49 @@ type MealPlanItem struct {
 	Calories    float64   `json:"calories"`
 	Protein     float64   `json:"protein"`
 	Carbs       float64   `json:"carbs"`
+	Fat         float64   `json:"fat"`
+	Fibre       float64   `json:"fibre"`
+	Day         int       `json:"day" gorm:"default:1"` // день плана, начиная с 1
+	MealSlot    string    `json:"meal_slot"`
+	RecipeID    *uint     `json:"recipe_id" gorm:"index"` // калории и нутриенты считаются по рецепту
+	Servings    float64   `json:"servings" gorm:"default:1"`
+	DietTags    []string  `json:"diet_tags" gorm:"serializer:json"`
+	Allergens   []string  `json:"allergens" gorm:"serializer:json"`
 	MealPlanId  uint      `json:"meal_plan_id"`
 	MealPlan    *MealPlan `json:"-"`
+	Recipe      *Recipe   `json:"-"`
 }
 
 type CreateMealPlanItemRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Calories    float64 `json:"calories"`
-	Protein     float64 `json:"protein"`
-	Carbs       float64 `json:"carbs"`
-	MealPlanId  uint    `json:"meal_plan_id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Calories    float64  `json:"calories"`
+	Protein     float64  `json:"protein"`
+	Carbs       float64  `json:"carbs"`
+	Fat         float64  `json:"fat"`
+	Fibre       float64  `json:"fibre"`
+	Day         int      `json:"day"`
+	MealSlot    string   `json:"meal_slot"`
+	RecipeID    *uint    `json:"recipe_id"` // при заданном рецепте калории и нутриенты из запроса не используются
+	Servings    float64  `json:"servings"`
+	DietTags    []string `json:"diet_tags"` // vegetarian, vegan, halal, gluten_free, lactose_free
+	Allergens   []string `json:"allergens"`
+	MealPlanId  uint     `json:"meal_plan_id"`
 }
 
 type UpdateMealPlanItemRequest struct {
-	Name        *string  `json:"name"`
-	Description *string  `json:"description"`
-	Calories    *float64 `json:"calories"`
-	Protein     *float64 `json:"protein"`
-	Carbs       *float64 `json:"carbs"`
-	MealPlanId  *uint    `json:"meal_plan_id"`
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Calories    *float64  `json:"calories"`
+	Protein     *float64  `json:"protein"`
+	Carbs       *float64  `json:"carbs"`
+	Fat         *float64  `json:"fat"`
+	Fibre       *float64  `json:"fibre"`
+	Day         *int      `json:"day"`
+	MealSlot    *string   `json:"meal_slot"`
+	RecipeID    *uint     `json:"recipe_id"` // 0 отвязывает рецепт
+	Servings    *float64  `json:"servings"`
+	DietTags    *[]string `json:"diet_tags"`
+	Allergens   *[]string `json:"allergens"`
+	MealPlanId  *uint     `json:"meal_plan_id"`
 }
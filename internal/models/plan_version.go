@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// виды планов, у которых есть опубликованные версии
+const (
+	PlanKindExercise = "exercise"
+	PlanKindMeal     = "meal"
+)
+
+// PlanVersion — неизменяемая опубликованная версия плана. Строки ExercisePlan и MealPlan
+// служат черновиком: авторы правят их, а покупатели видят версию, закреплённую за покупкой
+type PlanVersion struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PlanKind    string    `json:"plan_kind" gorm:"uniqueIndex:idx_plan_version"`
+	PlanID      uint      `json:"plan_id" gorm:"uniqueIndex:idx_plan_version"`
+	Version     int       `json:"version" gorm:"uniqueIndex:idx_plan_version"`
+	Notes       string    `json:"notes"`
+	PublishedBy uint      `json:"published_by"`
+	PublishedAt time.Time `json:"published_at" gorm:"index"`
+	// Snapshot — план вместе с пунктами в JSON на момент публикации
+	Snapshot string `json:"-"`
+}
+
+type PublishPlanRequest struct {
+	Notes string `json:"notes"` // что изменилось в версии
+}
+
+// PinnedPlanVersion — версия плана, которую видит покупатель, и последняя опубликованная
+type PinnedPlanVersion struct {
+	PlanKind      string `json:"plan_kind"`
+	PlanID        uint   `json:"plan_id"`
+	Name          string `json:"name"`
+	Version       int    `json:"version"`
+	LatestVersion int    `json:"latest_version"`
+}
+
+// ProgrammeVersions — версии планов купленной программы
+type ProgrammeVersions struct {
+	CategoriesID     uint                `json:"categories_id"`
+	PinnedAt         time.Time           `json:"pinned_at"`
+	UpgradeAvailable bool                `json:"upgrade_available"`
+	Plans            []PinnedPlanVersion `json:"plans"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	ProgrammeSourcePurchase     = "purchase"
+	ProgrammeSourceGift         = "gift"
+	ProgrammeSourceSubscription = "subscription"
+)
+
+// OwnedProgramme — программа (категория), доступная пользователю
+type OwnedProgramme struct {
+	CategoriesID uint        `json:"categories_id"`
+	Source       string      `json:"source"`
+	PurchasedAt  time.Time   `json:"purchased_at"`
+	ExpiresAt    *time.Time  `json:"expires_at,omitempty"` // только для подписок
+	Active       bool        `json:"active"`
+	Categories   *Categories `json:"categories,omitempty"`
+}
+
+type SetActiveProgrammeRequest struct {
+	CategoriesID uint `json:"categories_id"`
+}
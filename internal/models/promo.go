@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PromoDiscountPercent = "percent"
+	PromoDiscountFixed   = "fixed"
+)
+
+const (
+	PromoTargetCategory     = "category"
+	PromoTargetSubscription = "subscription"
+)
+
+// PromoCode — промокод на скидку. Без Scopes действует на любые категории и подписки.
+// Нулевые MaxRedemptions и MaxPerUser означают отсутствие ограничения
+type PromoCode struct {
+	gorm.Model
+	Code            string     `json:"code" gorm:"uniqueIndex"`
+	DiscountType    string     `json:"discount_type"`
+	Value           int        `json:"value"`
+	ValidFrom       *time.Time `json:"valid_from"`
+	ValidUntil      *time.Time `json:"valid_until"`
+	MaxRedemptions  int        `json:"max_redemptions"`
+	MaxPerUser      int        `json:"max_per_user"`
+	RedemptionCount int        `json:"redemption_count"`
+	Active          bool       `json:"active" gorm:"default:true"`
+
+	Scopes []PromoCodeScope `json:"scopes" gorm:"foreignKey:PromoCodeID"`
+}
+
+// PromoCodeScope ограничивает промокод конкретной категорией или подпиской
+type PromoCodeScope struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	PromoCodeID uint   `json:"promo_code_id" gorm:"index"`
+	TargetType  string `json:"target_type"`
+	TargetID    uint   `json:"target_id"`
+}
+
+// PromoRedemption — факт применения промокода при покупке
+type PromoRedemption struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time `json:"created_at"`
+	PromoCodeID    uint      `json:"promo_code_id" gorm:"index"`
+	UserID         uint      `json:"user_id" gorm:"index"`
+	TargetType     string    `json:"target_type"`
+	TargetID       uint      `json:"target_id"`
+	OriginalPrice  int       `json:"original_price"`
+	Discount       int       `json:"discount"`
+	FinalPrice     int       `json:"final_price"`
+	JournalEntryID *uint     `json:"journal_entry_id"`
+}
+
+type CreatePromoCodeRequest struct {
+	Code            string     `json:"code"`
+	DiscountType    string     `json:"discount_type"`
+	Value           int        `json:"value"`
+	ValidFrom       *time.Time `json:"valid_from"`
+	ValidUntil      *time.Time `json:"valid_until"`
+	MaxRedemptions  int        `json:"max_redemptions"`
+	MaxPerUser      int        `json:"max_per_user"`
+	CategoryIDs     []uint     `json:"category_ids"`
+	SubscriptionIDs []uint     `json:"subscription_ids"`
+}
+
+type UpdatePromoCodeRequest struct {
+	Active         *bool      `json:"active"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	MaxRedemptions *int       `json:"max_redemptions"`
+	MaxPerUser     *int       `json:"max_per_user"`
+}
+
+// PurchaseRequest — необязательное тело запроса на покупку
+type PurchaseRequest struct {
+	PromoCode string `json:"promo_code"`
+}
+
+type PromoQuoteRequest struct {
+	Code       string `json:"code"`
+	TargetType string `json:"target_type"`
+	TargetID   uint   `json:"target_id"`
+}
+
+type PromoQuote struct {
+	Code          string `json:"code"`
+	OriginalPrice int    `json:"original_price"`
+	Discount      int    `json:"discount"`
+	FinalPrice    int    `json:"final_price"`
+}
@@ -0,0 +1,36 @@
+package models
+
+// RecommendationRequest — анкета для подбора программы; незаполненные поля не учитываются
+type RecommendationRequest struct {
+	// cut, maintain или bulk; если не указана, определяется по BMI
+	Goal string `json:"goal"`
+	// beginner, intermediate или advanced
+	Experience string `json:"experience"`
+	// инвентарь из EquipmentTypes; bodyweight доступен всегда
+	Equipment   []string `json:"equipment"`
+	DaysPerWeek int      `json:"days_per_week"`
+	// если диета и аллергены не указаны, берутся из профиля пользователя
+	DietTags  []string `json:"diet_tags"`
+	Allergens []string `json:"allergens"`
+	// вес и рост для BMI; без них используется последний замер пользователя
+	WeightKg *float64 `json:"weight_kg"`
+	HeightCm *float64 `json:"height_cm"`
+}
+
+type ProgrammeRecommendation struct {
+	CategoriesID uint   `json:"categories_id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Price        int    `json:"price"`
+	// Score — насколько программа подходит, от 0 до 100
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// RecommendationResult — программы в порядке убывания Score
+type RecommendationResult struct {
+	Goal            string                    `json:"goal"`
+	Bmi             *float64                  `json:"bmi"`
+	BmiClass        string                    `json:"bmi_class,omitempty"`
+	Recommendations []ProgrammeRecommendation `json:"recommendations"`
+}
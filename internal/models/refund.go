@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RefundStatusPending  = "pending"
+	RefundStatusApproved = "approved"
+	RefundStatusRejected = "rejected"
+)
+
+// RefundRequest — заявка на возврат покупки категории
+type RefundRequest struct {
+	gorm.Model
+	UserID         uint       `json:"user_id" gorm:"index"`
+	UserPlanID     uint       `json:"user_plan_id" gorm:"index"`
+	CategoriesID   uint       `json:"categories_id" gorm:"index"`
+	Amount         int        `json:"amount"`
+	Reason         string     `json:"reason"`
+	Status         string     `json:"status" gorm:"index;default:pending"`
+	DecisionReason string     `json:"decision_reason"`
+	DecidedByID    *uint      `json:"decided_by_id"`
+	DecidedAt      *time.Time `json:"decided_at"`
+	JournalEntryID *uint      `json:"journal_entry_id"`
+
+	User       *User       `json:"-" gorm:"foreignKey:UserID"`
+	Categories *Categories `json:"-" gorm:"foreignKey:CategoriesID"`
+}
+
+type CreateRefundRequest struct {
+	CategoryID uint   `json:"category_id"`
+	Reason     string `json:"reason"`
+}
+
+type RefundDecisionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RefundFilter — условия выборки заявок; nil и пустые поля не ограничивают выборку
+type RefundFilter struct {
+	UserID     *uint
+	CategoryID *uint
+	Status     string
+}
@@ -24,7 +24,6 @@ type GetReview struct {
 
 type CreateReviewRequest struct {
 	CategoriesID uint   `json:"categories_id"`
-	UserID       uint   `json:"user_id"`
 	Rating       int    `json:"rating"`
 	Content      string `json:"content"`
 }
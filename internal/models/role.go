@@ -0,0 +1,20 @@
+package models
+
+const (
+	RoleClient  = "client"
+	RoleTrainer = "trainer"
+	RoleAdmin   = "admin"
+)
+
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleClient, RoleTrainer, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
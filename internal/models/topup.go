@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TopUpStatusPending   = "pending"
+	TopUpStatusSucceeded = "succeeded"
+	TopUpStatusFailed    = "failed"
+)
+
+// TopUp — пополнение кошелька через платёжного провайдера.
+// Пара (Provider, ProviderPaymentID) уникальна, поэтому один платёж
+// провайдера не может зачислиться дважды
+type TopUp struct {
+	gorm.Model
+	UserID            uint       `json:"user_id" gorm:"index"`
+	Amount            int        `json:"amount"`
+	Provider          string     `json:"provider" gorm:"uniqueIndex:idx_top_ups_provider_payment"`
+	ProviderPaymentID string     `json:"provider_payment_id" gorm:"uniqueIndex:idx_top_ups_provider_payment"`
+	Status            string     `json:"status" gorm:"index;default:pending"`
+	ConfirmationURL   string     `json:"confirmation_url"`
+	JournalEntryID    *uint      `json:"journal_entry_id"`
+	ConfirmedAt       *time.Time `json:"confirmed_at"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+type TopUpRequest struct {
+	Amount int `json:"amount"`
+}
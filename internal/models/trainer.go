@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrainerProfile — публичный профиль тренера. Тренер владеет категориями (Categories.TrainerID)
+// и получает долю от каждой их продажи за вычетом комиссии платформы
+type TrainerProfile struct {
+	gorm.Model
+	UserID      uint     `json:"user_id" gorm:"uniqueIndex"`
+	DisplayName string   `json:"display_name"`
+	Bio         string   `json:"bio"`
+	Specialties []string `json:"specialties" gorm:"serializer:json"`
+	PhotoURL    string   `json:"photo_url"`
+	// CommissionPercent — индивидуальная комиссия платформы; пусто — общая для всех тренеров
+	CommissionPercent *int `json:"commission_percent"`
+
+	User       *User        `json:"-" gorm:"foreignKey:UserID"`
+	Categories []Categories `json:"categories,omitempty" gorm:"-"`
+}
+
+type CreateTrainerProfileRequest struct {
+	DisplayName string   `json:"display_name"`
+	Bio         string   `json:"bio"`
+	Specialties []string `json:"specialties"`
+	PhotoURL    string   `json:"photo_url"`
+}
+
+type UpdateTrainerProfileRequest struct {
+	DisplayName *string   `json:"display_name"`
+	Bio         *string   `json:"bio"`
+	Specialties *[]string `json:"specialties"`
+	PhotoURL    *string   `json:"photo_url"`
+}
+
+// SetCommissionRequest — нулевой указатель возвращает тренера на общую комиссию
+type SetCommissionRequest struct {
+	CommissionPercent *int `json:"commission_percent"`
+}
+
+// TrainerPayout — выплата тренеру заработанных денег за пределами платформы
+type TrainerPayout struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time `json:"created_at"`
+	TrainerID      uint      `json:"trainer_id" gorm:"index"`
+	Amount         int       `json:"amount"`
+	Note           string    `json:"note"`
+	CreatedByID    uint      `json:"created_by_id"`
+	JournalEntryID uint      `json:"journal_entry_id"`
+}
+
+type CreatePayoutRequest struct {
+	Amount int    `json:"amount"`
+	Note   string `json:"note"` // например, номер платёжного поручения
+}
+
+// TrainerCategoryStats — продажи одной категории тренера
+type TrainerCategoryStats struct {
+	CategoriesID      uint   `json:"categories_id"`
+	Name              string `json:"name"`
+	Price             int    `json:"price"`
+	Purchases         int    `json:"purchases"`
+	Gifts             int    `json:"gifts"`
+	ActiveSubscribers int    `json:"active_subscribers"`
+}
+
+// TrainerDashboard — продажи тренера и расчёты с ним. Earned — доля тренера за вычетом
+// возвратов, Owed — сколько платформа должна выплатить сейчас
+type TrainerDashboard struct {
+	TrainerID         uint                   `json:"trainer_id"`
+	CommissionPercent int                    `json:"commission_percent"`
+	Categories        []TrainerCategoryStats `json:"categories"`
+	Purchases         int                    `json:"purchases"`
+	Gifts             int                    `json:"gifts"`
+	ActiveSubscribers int                    `json:"active_subscribers"`
+	Earned            int                    `json:"earned"`
+	PaidOut           int                    `json:"paid_out"`
+	Owed              int                    `json:"owed"`
+	RecentPayouts     []TrainerPayout        `json:"recent_payouts"`
+}
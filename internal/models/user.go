@@ -4,23 +4,31 @@ import "gorm.io/gorm"
 
 type User struct {
 	gorm.Model
-	Name         string      `json:"name"`
-	Balance      int         `json:"balance"`
-	Email        string      `json:"email"`
-	CategoriesID uint        `json:"categories_id"`
-	Categories   *Categories `json:"-" gorm:"foreignKey:CategoriesID"`
+	Name         string `json:"name"`
+	Balance      int    `json:"balance" gorm:"-"` // вычисляется по проводкам леджера
+	Email        string `json:"email"`
+	PasswordHash string `json:"-"`
+	Role         string `json:"role" gorm:"default:client"`
+
+	// активная программа — одна из купленных, подаренных или доступных по подписке
+	ActiveCategoriesID *uint       `json:"active_categories_id"`
+	ActiveCategories   *Categories `json:"-" gorm:"foreignKey:ActiveCategoriesID"`
+
+	// диеты и аллергены пользователя; планы питания с ними сверяются
+	DietTags  []string `json:"diet_tags" gorm:"serializer:json"`
+	Allergens []string `json:"allergens" gorm:"serializer:json"`
 
 	UserSubscriptions []UserSubscription `json:"userSubscriptions"`
 	UserPlans         []UserPlan         `json:"userPlans"`
 }
 
 type CreateUserRequest struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
 }
 
 type UpdateUserRequest struct {
-	Name    *string `json:"name"`
-	Balance *int    `json:"balance"`
-	Email   *string `json:"email"`
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
 }
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkoutSession — тренировка, которую пользователь выполняет по тренировочному плану
+type WorkoutSession struct {
+	gorm.Model
+	UserID         uint       `json:"user_id" gorm:"index"`
+	ExercisePlanID uint       `json:"exercise_plan_id" gorm:"index"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+	Notes          string     `json:"notes"`
+
+	Sets         []WorkoutSet  `json:"sets,omitempty" gorm:"foreignKey:WorkoutSessionID"`
+	ExercisePlan *ExercisePlan `json:"-" gorm:"foreignKey:ExercisePlanID"`
+}
+
+// WorkoutSet — фактически выполненный подход по упражнению из плана
+type WorkoutSet struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt          time.Time `json:"created_at"`
+	WorkoutSessionID   uint      `json:"workout_session_id" gorm:"index"`
+	ExercisePlanItemID uint      `json:"exercise_plan_item_id" gorm:"index"`
+	SetNumber          int       `json:"set_number"`
+	Reps               int       `json:"reps"`
+	WeightKg           float64   `json:"weight_kg"`
+	DurationSeconds    int       `json:"duration_seconds"`
+
+	WorkoutSession   *WorkoutSession   `json:"-" gorm:"foreignKey:WorkoutSessionID"`
+	ExercisePlanItem *ExercisePlanItem `json:"-" gorm:"foreignKey:ExercisePlanItemID"`
+}
+
+// WorkoutComment — комментарий к тренировке от её владельца или его тренера
+type WorkoutComment struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt        time.Time `json:"created_at"`
+	WorkoutSessionID uint      `json:"workout_session_id" gorm:"index"`
+	AuthorID         uint      `json:"author_id"`
+	Body             string    `json:"body"`
+}
+
+type CreateWorkoutCommentRequest struct {
+	Body string `json:"body"`
+}
+
+type StartWorkoutRequest struct {
+	ExercisePlanID uint   `json:"exercise_plan_id"`
+	Notes          string `json:"notes"`
+}
+
+type LogWorkoutSetRequest struct {
+	ExercisePlanItemID uint    `json:"exercise_plan_item_id"`
+	Reps               int     `json:"reps"`
+	WeightKg           float64 `json:"weight_kg"`
+	DurationSeconds    int     `json:"duration_seconds"`
+}
+
+type FinishWorkoutRequest struct {
+	Notes *string `json:"notes"`
+}
+
+// WorkoutSetFilter — условия выборки подходов; nil-поля не ограничивают выборку
+type WorkoutSetFilter struct {
+	UserID             uint
+	ExercisePlanID     *uint
+	ExercisePlanItemID *uint
+	From               *time.Time
+	To                 *time.Time
+}
+
+// ExerciseProgressPoint — итоги одного упражнения за одну тренировку
+type ExerciseProgressPoint struct {
+	WorkoutSessionID uint      `json:"workout_session_id"`
+	Date             time.Time `json:"date"`
+	Sets             int       `json:"sets"`
+	Reps             int       `json:"reps"`
+	Volume           float64   `json:"volume"`
+	MaxWeightKg      float64   `json:"max_weight_kg"`
+	DurationSeconds  int       `json:"duration_seconds"`
+}
+
+// PersonalRecords — лучшие результаты пользователя по упражнению
+type PersonalRecords struct {
+	MaxWeightKg        float64 `json:"max_weight_kg"`
+	MaxReps            int     `json:"max_reps"`
+	MaxSessionVolume   float64 `json:"max_session_volume"`
+	EstimatedOneRepMax float64 `json:"estimated_one_rep_max"`
+}
+
+type ExerciseProgress struct {
+	ExercisePlanItemID uint                    `json:"exercise_plan_item_id"`
+	Name               string                  `json:"name"`
+	TotalVolume        float64                 `json:"total_volume"`
+	Records            PersonalRecords         `json:"records"`
+	History            []ExerciseProgressPoint `json:"history"`
+}
+
+// WorkoutAdherence — доля запланированных на неделю подходов, которые пользователь выполнил.
+// PlanWeek — неделя плана, с которой сравнивается календарная неделя
+type WorkoutAdherence struct {
+	ExercisePlanID uint      `json:"exercise_plan_id"`
+	PlanWeek       int       `json:"plan_week"`
+	WeekStart      time.Time `json:"week_start"`
+	WeekEnd        time.Time `json:"week_end"`
+	PlannedSets    int       `json:"planned_sets"`
+	CompletedSets  int       `json:"completed_sets"`
+	Sessions       int       `json:"sessions"`
+	Percent        float64   `json:"percent"`
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BodyRepository interface {
+	CreateMeasurement(measurement *models.BodyMeasurement) error
+	GetMeasurement(id uint) (*models.BodyMeasurement, error)
+	// ListMeasurements возвращает замеры по возрастанию даты; nil-границы не ограничивают выборку
+	ListMeasurements(userID uint, from, to *time.Time) ([]models.BodyMeasurement, error)
+	LatestMeasurement(userID uint) (*models.BodyMeasurement, error)
+	DeleteMeasurement(id uint) error
+	GetGoal(userID uint) (*models.BodyGoal, error)
+	SaveGoal(goal *models.BodyGoal) error
+	DeleteGoal(userID uint) error
+}
+
+type gormBodyRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewBodyRepository(db *gorm.DB, log *slog.Logger) BodyRepository {
+	return &gormBodyRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormBodyRepository) CreateMeasurement(measurement *models.BodyMeasurement) error {
+	if measurement == nil {
+		r.log.Error("error in Create function body_repository.go")
+		return errors.New("body measurement is nil")
+	}
+
+	if err := r.db.Create(measurement).Error; err != nil {
+		r.log.Error("failed to create body measurement", "user_id", measurement.UserID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormBodyRepository) GetMeasurement(id uint) (*models.BodyMeasurement, error) {
+	var measurement models.BodyMeasurement
+
+	if err := r.db.First(&measurement, id).Error; err != nil {
+		r.log.Error("failed to fetch body measurement", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &measurement, nil
+}
+
+func (r *gormBodyRepository) ListMeasurements(userID uint, from, to *time.Time) ([]models.BodyMeasurement, error) {
+	var measurements []models.BodyMeasurement
+
+	query := r.db.Where("user_id = ?", userID)
+	if from != nil {
+		query = query.Where("measured_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("measured_at < ?", *to)
+	}
+
+	if err := query.Order("measured_at ASC, id ASC").Find(&measurements).Error; err != nil {
+		r.log.Error("failed to list body measurements", "user_id", userID, "err", err)
+		return nil, err
+	}
+
+	return measurements, nil
+}
+
+func (r *gormBodyRepository) LatestMeasurement(userID uint) (*models.BodyMeasurement, error) {
+	var measurement models.BodyMeasurement
+
+	err := r.db.Where("user_id = ?", userID).Order("measured_at DESC, id DESC").First(&measurement).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			r.log.Error("failed to fetch latest body measurement", "user_id", userID, "err", err)
+		}
+		return nil, err
+	}
+
+	return &measurement, nil
+}
+
+func (r *gormBodyRepository) DeleteMeasurement(id uint) error {
+	if err := r.db.Delete(&models.BodyMeasurement{}, id).Error; err != nil {
+		r.log.Error("failed to delete body measurement", "id", id, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormBodyRepository) GetGoal(userID uint) (*models.BodyGoal, error) {
+	var goal models.BodyGoal
+
+	if err := r.db.Where("user_id = ?", userID).First(&goal).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			r.log.Error("failed to fetch body goal", "user_id", userID, "err", err)
+		}
+		return nil, err
+	}
+
+	return &goal, nil
+}
+
+func (r *gormBodyRepository) SaveGoal(goal *models.BodyGoal) error {
+	if goal == nil {
+		r.log.Error("error in Save function body_repository.go")
+		return errors.New("body goal is nil")
+	}
+
+	if err := r.db.Save(goal).Error; err != nil {
+		r.log.Error("failed to save body goal", "user_id", goal.UserID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteGoal удаляет цель безвозвратно, чтобы уникальный индекс по user_id не мешал задать новую
+func (r *gormBodyRepository) DeleteGoal(userID uint) error {
+	if err := r.db.Unscoped().Where("user_id = ?", userID).Delete(&models.BodyGoal{}).Error; err != nil {
+		r.log.Error("failed to delete body goal", "user_id", userID, "err", err)
+		return err
+	}
+
+	return nil
+}
@@ -13,6 +13,10 @@ type CategoryRepo interface {
 	 List() ([]models.Categories, error)
 	 GetByID(id uint) (*models.Categories,error)
 	 GetWithPlans(id uint) (*models.Categories, error)
+	// ListWithPlans возвращает все категории с планами, упражнениями из библиотеки и блюдами
+	ListWithPlans() ([]models.Categories, error)
+	// TrainerExists проверяет, что у пользователя есть профиль тренера
+	TrainerExists(userID uint) (bool, error)
 	 Update(category *models.Categories) error
 	 Delete(id uint) error
 }
@@ -53,7 +57,7 @@ func (c *categoryRepo) List() ([]models.Categories, error){
 
 func (c *categoryRepo) GetByID(id uint) (*models.Categories,error) {
 	var category models.Categories
-	if err := c.db.Preload("ExercisePlans.Exercises").Preload("MealPlans.Meals").First(&category,id).Error; err != nil {
+	if err := c.db.Preload("ExercisePlans", catalogPlans).Preload("ExercisePlans.Exercises").Preload("MealPlans", catalogPlans).Preload("MealPlans.Meals").First(&category,id).Error; err != nil {
 		c.log.Error("error in GetByID function category_repository.go")
 		return nil, err
 	}
@@ -65,7 +69,7 @@ func (c *categoryRepo) GetByID(id uint) (*models.Categories,error) {
 func (c *categoryRepo) GetWithPlans(id uint) (*models.Categories, error) {
     var category models.Categories
 
-    err := c.db.Preload("ExercisePlans.Exercises").Preload("MealPlans.Meals").First(&category, id).Error
+    err := c.db.Preload("ExercisePlans", catalogPlans).Preload("ExercisePlans.Exercises").Preload("MealPlans", catalogPlans).Preload("MealPlans.Meals").First(&category, id).Error
 
     if err != nil {
         c.log.Error("error in GetWithPlans function category_repository.go", "err", err)
@@ -76,6 +80,29 @@ func (c *categoryRepo) GetWithPlans(id uint) (*models.Categories, error) {
 }
 
 
+func (c *categoryRepo) ListWithPlans() ([]models.Categories, error) {
+	var list []models.Categories
+
+	err := c.db.Preload("ExercisePlans", catalogPlans).Preload("ExercisePlans.Exercises.Exercise").Preload("MealPlans", catalogPlans).Preload("MealPlans.Meals").Order("id").Find(&list).Error
+	if err != nil {
+		c.log.Error("error in ListWithPlans function category_repository.go", "err", err)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (c *categoryRepo) TrainerExists(userID uint) (bool, error) {
+	var count int64
+
+	if err := c.db.Model(&models.TrainerProfile{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		c.log.Error("error in TrainerExists function category_repository.go", "err", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
 func (c *categoryRepo) Update(category *models.Categories) error {
 	if category == nil {
 		c.log.Error("error in Update function category_repository.go")
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// catalogPlans отсекает личные планы клиентов от каталога и программ категорий
+const catalogPlans = "client_id IS NULL"
+
+type CoachingRepository interface {
+	Create(link *models.TrainerClient) error
+	Get(id uint) (*models.TrainerClient, error)
+	Update(link *models.TrainerClient) error
+	// Current возвращает ожидающую или активную связь тренера с клиентом;
+	// gorm.ErrRecordNotFound, если такой нет
+	Current(trainerID, clientID uint) (*models.TrainerClient, error)
+	// ListByTrainer возвращает связи тренера; пустой status — все связи
+	ListByTrainer(trainerID uint, status string) ([]models.TrainerClient, error)
+	ListByClient(clientID uint) ([]models.TrainerClient, error)
+
+	// CreateExercisePlan и CreateMealPlan сохраняют личный план вместе с пунктами
+	CreateExercisePlan(plan *models.ExercisePlan) error
+	CreateMealPlan(plan *models.MealPlan) error
+	AssignedExercisePlans(trainerID, clientID uint) ([]models.ExercisePlan, error)
+	AssignedMealPlans(trainerID, clientID uint) ([]models.MealPlan, error)
+
+	CreateComment(comment *models.WorkoutComment) error
+	ListComments(sessionID uint) ([]models.WorkoutComment, error)
+}
+
+type gormCoachingRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewCoachingRepository(db *gorm.DB, log *slog.Logger) CoachingRepository {
+	return &gormCoachingRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormCoachingRepository) Create(link *models.TrainerClient) error {
+	if link == nil {
+		r.log.Error("error in Create function coaching_repository.go")
+		return errors.New("trainer client link is nil")
+	}
+
+	if err := r.db.Create(link).Error; err != nil {
+		r.log.Error("failed to create trainer client link", "trainer_id", link.TrainerID, "client_id", link.ClientID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormCoachingRepository) Get(id uint) (*models.TrainerClient, error) {
+	var link models.TrainerClient
+
+	if err := r.db.First(&link, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (r *gormCoachingRepository) Update(link *models.TrainerClient) error {
+	if link == nil {
+		r.log.Error("error in Update function coaching_repository.go")
+		return errors.New("trainer client link is nil")
+	}
+
+	if err := r.db.Save(link).Error; err != nil {
+		r.log.Error("failed to update trainer client link", "id", link.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormCoachingRepository) Current(trainerID, clientID uint) (*models.TrainerClient, error) {
+	var link models.TrainerClient
+
+	err := r.db.Where("trainer_id = ? AND client_id = ? AND status IN ?", trainerID, clientID,
+		[]string{models.CoachingStatusPending, models.CoachingStatusActive}).
+		First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (r *gormCoachingRepository) ListByTrainer(trainerID uint, status string) ([]models.TrainerClient, error) {
+	var links []models.TrainerClient
+
+	query := r.db.Where("trainer_id = ?", trainerID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at DESC, id DESC").Find(&links).Error; err != nil {
+		r.log.Error("failed to list trainer clients", "trainer_id", trainerID, "err", err)
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (r *gormCoachingRepository) ListByClient(clientID uint) ([]models.TrainerClient, error) {
+	var links []models.TrainerClient
+
+	if err := r.db.Where("client_id = ?", clientID).Order("created_at DESC, id DESC").Find(&links).Error; err != nil {
+		r.log.Error("failed to list client trainers", "client_id", clientID, "err", err)
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (r *gormCoachingRepository) CreateExercisePlan(plan *models.ExercisePlan) error {
+	if plan == nil {
+		r.log.Error("error in CreateExercisePlan function coaching_repository.go")
+		return errors.New("exercise plan is nil")
+	}
+
+	if err := r.db.Omit("Categories").Create(plan).Error; err != nil {
+		r.log.Error("failed to create client exercise plan", "client_id", plan.ClientID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormCoachingRepository) CreateMealPlan(plan *models.MealPlan) error {
+	if plan == nil {
+		r.log.Error("error in CreateMealPlan function coaching_repository.go")
+		return errors.New("meal plan is nil")
+	}
+
+	if err := r.db.Omit("Categories").Create(plan).Error; err != nil {
+		r.log.Error("failed to create client meal plan", "client_id", plan.ClientID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormCoachingRepository) AssignedExercisePlans(trainerID, clientID uint) ([]models.ExercisePlan, error) {
+	var plans []models.ExercisePlan
+
+	err := r.db.Preload("Exercises").
+		Where("trainer_id = ? AND client_id = ?", trainerID, clientID).
+		Order("id").
+		Find(&plans).Error
+	if err != nil {
+		r.log.Error("failed to list client exercise plans", "trainer_id", trainerID, "client_id", clientID, "err", err)
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+func (r *gormCoachingRepository) AssignedMealPlans(trainerID, clientID uint) ([]models.MealPlan, error) {
+	var plans []models.MealPlan
+
+	err := r.db.Preload("Meals").
+		Where("trainer_id = ? AND client_id = ?", trainerID, clientID).
+		Order("id").
+		Find(&plans).Error
+	if err != nil {
+		r.log.Error("failed to list client meal plans", "trainer_id", trainerID, "client_id", clientID, "err", err)
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+func (r *gormCoachingRepository) CreateComment(comment *models.WorkoutComment) error {
+	if comment == nil {
+		r.log.Error("error in CreateComment function coaching_repository.go")
+		return errors.New("workout comment is nil")
+	}
+
+	if err := r.db.Create(comment).Error; err != nil {
+		r.log.Error("failed to create workout comment", "session_id", comment.WorkoutSessionID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormCoachingRepository) ListComments(sessionID uint) ([]models.WorkoutComment, error) {
+	var comments []models.WorkoutComment
+
+	if err := r.db.Where("workout_session_id = ?", sessionID).Order("created_at, id").Find(&comments).Error; err != nil {
+		r.log.Error("failed to list workout comments", "session_id", sessionID, "err", err)
+		return nil, err
+	}
+
+	return comments, nil
+}
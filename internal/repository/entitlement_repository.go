@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"healthy_body/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type EntitlementRepository interface {
+	// CategoryIDs возвращает категории, купленные пользователем или
+	// доступные ему по активной на момент now подписке
+	CategoryIDs(userID uint, now time.Time) ([]uint, error)
+	HasCategory(userID, categoryID uint, now time.Time) (bool, error)
+}
+
+type gormEntitlementRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewEntitlementRepository(db *gorm.DB, log *slog.Logger) EntitlementRepository {
+	return &gormEntitlementRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormEntitlementRepository) purchased(userID uint) *gorm.DB {
+	return r.db.Model(&models.UserPlan{}).
+		Select("categories_id").
+		Where("user_id = ?", userID)
+}
+
+func (r *gormEntitlementRepository) subscribed(userID uint, now time.Time) *gorm.DB {
+	return r.db.Model(&models.UserSubscription{}).
+		Select("subscriptions.categories_id").
+		Joins("JOIN subscriptions ON subscriptions.id = user_subscriptions.subscription_id").
+		Where("user_subscriptions.user_id = ? AND user_subscriptions.status = ? AND user_subscriptions.end_date > ?",
+			userID, models.SubscriptionStatusActive, now)
+}
+
+func (r *gormEntitlementRepository) CategoryIDs(userID uint, now time.Time) ([]uint, error) {
+	var purchased, subscribed []uint
+
+	if err := r.purchased(userID).Pluck("categories_id", &purchased).Error; err != nil {
+		r.log.Error("failed to fetch purchased categories", "user_id", userID, "err", err)
+		return nil, err
+	}
+
+	if err := r.subscribed(userID, now).Pluck("subscriptions.categories_id", &subscribed).Error; err != nil {
+		r.log.Error("failed to fetch subscribed categories", "user_id", userID, "err", err)
+		return nil, err
+	}
+
+	return append(purchased, subscribed...), nil
+}
+
+func (r *gormEntitlementRepository) HasCategory(userID, categoryID uint, now time.Time) (bool, error) {
+	var count int64
+
+	if err := r.purchased(userID).Where("categories_id = ?", categoryID).Count(&count).Error; err != nil {
+		r.log.Error("failed to check purchased category", "user_id", userID, "err", err)
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := r.subscribed(userID, now).Where("subscriptions.categories_id = ?", categoryID).Count(&count).Error; err != nil {
+		r.log.Error("failed to check subscribed category", "user_id", userID, "err", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type ExerciseLibraryRepository interface {
+	WithTx(tx *gorm.DB) ExerciseLibraryRepository
+	Create(exercise *models.Exercise) error
+	GetByID(id uint) (*models.Exercise, error)
+	GetByName(name string) (*models.Exercise, error)
+	// List ищет упражнения; группы мышц и инвентарь хранятся JSON-массивами
+	List(filter models.ExerciseFilter) ([]models.Exercise, error)
+	Update(exercise *models.Exercise) error
+	Delete(id uint) error
+	CountPlanItems(exerciseID uint) (int64, error)
+	// LinkPlanItemsByName привязывает к упражнению пункты планов с тем же названием,
+	// которые ещё не ссылаются на библиотеку
+	LinkPlanItemsByName(name string, exerciseID uint) (int64, error)
+	// RefreshPlanItems переписывает название и инвентарь в привязанных к упражнению пунктах планов
+	RefreshPlanItems(exercise *models.Exercise, equipment string) error
+}
+
+type gormExerciseLibraryRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewExerciseLibraryRepository(db *gorm.DB, log *slog.Logger) ExerciseLibraryRepository {
+	return &gormExerciseLibraryRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormExerciseLibraryRepository) WithTx(tx *gorm.DB) ExerciseLibraryRepository {
+	return &gormExerciseLibraryRepository{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+func (r *gormExerciseLibraryRepository) Create(exercise *models.Exercise) error {
+	if exercise == nil {
+		r.log.Error("error in Create function exercise_library_repository.go")
+		return errors.New("exercise is nil")
+	}
+
+	if err := r.db.Create(exercise).Error; err != nil {
+		r.log.Error("failed to create exercise", "name", exercise.Name, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormExerciseLibraryRepository) GetByID(id uint) (*models.Exercise, error) {
+	var exercise models.Exercise
+
+	if err := r.db.First(&exercise, id).Error; err != nil {
+		r.log.Error("failed to fetch exercise", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &exercise, nil
+}
+
+func (r *gormExerciseLibraryRepository) GetByName(name string) (*models.Exercise, error) {
+	var exercise models.Exercise
+
+	if err := r.db.Where("LOWER(name) = LOWER(?)", name).First(&exercise).Error; err != nil {
+		return nil, err
+	}
+
+	return &exercise, nil
+}
+
+func (r *gormExerciseLibraryRepository) List(filter models.ExerciseFilter) ([]models.Exercise, error) {
+	var list []models.Exercise
+
+	query := r.db.Order("name")
+	if filter.Query != "" {
+		query = query.Where("name ILIKE ?", "%"+filter.Query+"%")
+	}
+	if filter.MuscleGroup != "" {
+		query = query.Where("muscle_groups LIKE ?", jsonElementPattern(filter.MuscleGroup))
+	}
+	if filter.Equipment != "" {
+		query = query.Where("equipment LIKE ?", jsonElementPattern(filter.Equipment))
+	}
+	if filter.Difficulty != "" {
+		query = query.Where("difficulty = ?", filter.Difficulty)
+	}
+
+	if err := query.Find(&list).Error; err != nil {
+		r.log.Error("failed to list exercises", "err", err)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *gormExerciseLibraryRepository) Update(exercise *models.Exercise) error {
+	if exercise == nil {
+		r.log.Error("error in Update function exercise_library_repository.go")
+		return errors.New("exercise is nil")
+	}
+
+	if err := r.db.Save(exercise).Error; err != nil {
+		r.log.Error("failed to update exercise", "id", exercise.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// Delete удаляет упражнение окончательно, чтобы название можно было занять снова
+func (r *gormExerciseLibraryRepository) Delete(id uint) error {
+	if err := r.db.Unscoped().Delete(&models.Exercise{}, id).Error; err != nil {
+		r.log.Error("failed to delete exercise", "id", id, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormExerciseLibraryRepository) CountPlanItems(exerciseID uint) (int64, error) {
+	var count int64
+
+	if err := r.db.Model(&models.ExercisePlanItem{}).Where("exercise_id = ?", exerciseID).Count(&count).Error; err != nil {
+		r.log.Error("failed to count plan items by exercise", "exercise_id", exerciseID, "err", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *gormExerciseLibraryRepository) LinkPlanItemsByName(name string, exerciseID uint) (int64, error) {
+	result := r.db.Model(&models.ExercisePlanItem{}).
+		Where("exercise_id IS NULL AND LOWER(name) = LOWER(?)", name).
+		Update("exercise_id", exerciseID)
+	if result.Error != nil {
+		r.log.Error("failed to link plan items", "exercise_id", exerciseID, "err", result.Error)
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (r *gormExerciseLibraryRepository) RefreshPlanItems(exercise *models.Exercise, equipment string) error {
+	err := r.db.Model(&models.ExercisePlanItem{}).
+		Where("exercise_id = ?", exercise.ID).
+		Updates(map[string]any{"name": exercise.Name, "equipment_needed": equipment}).Error
+	if err != nil {
+		r.log.Error("failed to refresh plan items", "exercise_id", exercise.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// jsonElementPattern — шаблон LIKE для строки внутри JSON-массива, например ["chest","triceps"]
+func jsonElementPattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return `%"` + replacer.Replace(value) + `"%`
+}
@@ -47,7 +47,7 @@ func (r *exercisePlanRepo) CreateExercisePlan(exercise *models.ExercisePlan) err
 func (r *exercisePlanRepo) GetByIDExercisePlan(id uint) (*models.ExercisePlan, error) {
 	var exercise models.ExercisePlan
 
-	if err := r.db.Preload("Exercises").Preload("Categories").First(&exercise, id).Error; err != nil {
+	if err := r.db.Preload("Exercises.Exercise").Preload("Categories").First(&exercise, id).Error; err != nil {
 		r.log.Error("error in GetByID function exercise_plan_repository.go")
 		return nil, err
 	}
@@ -99,12 +99,12 @@ func (r *exercisePlanRepo) CreateExercisePlanItem(item *models.ExercisePlanItem)
 		return errors.New("error create in db")
 	}
 
-	return r.db.Create(item).Error
+	return r.db.Omit("Exercise").Create(item).Error
 }
 
 func (r *exercisePlanRepo) GetAllExercisePlanItem() ([]models.ExercisePlanItem, error) {
 	var exercises []models.ExercisePlanItem
-	if err := r.db.Find(&exercises).Error; err != nil {
+	if err := r.db.Preload("Exercise").Find(&exercises).Error; err != nil {
 		r.log.Error("error in GetAll function exercise_plan_item_repository.go")
 		return nil, err
 	}
@@ -114,7 +114,7 @@ func (r *exercisePlanRepo) GetAllExercisePlanItem() ([]models.ExercisePlanItem,
 func (r *exercisePlanRepo) GetByIDExercisePlanItem(id uint) (*models.ExercisePlanItem, error) {
 	var exercise models.ExercisePlanItem
 
-	if err := r.db.First(&exercise, id).Error; err != nil {
+	if err := r.db.Preload("Exercise").First(&exercise, id).Error; err != nil {
 		r.log.Error("error in GetByID function exercise_plan_repository.go")
 		return nil, err
 	}
@@ -128,7 +128,7 @@ func (r *exercisePlanRepo) UpdateExercisePlanItem(exercise *models.ExercisePlanI
 		return errors.New("error update in db")
 	}
 
-	return r.db.Save(exercise).Error
+	return r.db.Omit("Exercise").Save(exercise).Error
 }
 
 func (r *exercisePlanRepo) DeleteExercisePlanItem(id uint) error {
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type FoodRepository interface {
+	WithTx(tx *gorm.DB) FoodRepository
+	CreateFood(food *models.Food) error
+	GetFood(id uint) (*models.Food, error)
+	GetFoodByName(name string) (*models.Food, error)
+	GetFoods(ids []uint) ([]models.Food, error)
+	// ListFoods ищет продукты по части названия без учёта регистра
+	ListFoods(query string) ([]models.Food, error)
+	UpdateFood(food *models.Food) error
+	DeleteFood(id uint) error
+	CountFoodUsage(foodID uint) (int64, error)
+
+	CreateRecipe(recipe *models.Recipe) error
+	GetRecipe(id uint) (*models.Recipe, error)
+	ListRecipes() ([]models.Recipe, error)
+	UpdateRecipe(recipe *models.Recipe) error
+	// ReplaceIngredients удаляет прежний состав рецепта и сохраняет новый
+	ReplaceIngredients(recipeID uint, ingredients []models.RecipeIngredient) error
+	DeleteRecipe(id uint) error
+	// RecipeIDsByFood возвращает рецепты, в которые входит продукт
+	RecipeIDsByFood(foodID uint) ([]uint, error)
+}
+
+type gormFoodRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewFoodRepository(db *gorm.DB, log *slog.Logger) FoodRepository {
+	return &gormFoodRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormFoodRepository) WithTx(tx *gorm.DB) FoodRepository {
+	return &gormFoodRepository{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+func (r *gormFoodRepository) CreateFood(food *models.Food) error {
+	if food == nil {
+		r.log.Error("error in CreateFood function food_repository.go")
+		return errors.New("food is nil")
+	}
+
+	if err := r.db.Create(food).Error; err != nil {
+		r.log.Error("failed to create food", "name", food.Name, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormFoodRepository) GetFood(id uint) (*models.Food, error) {
+	var food models.Food
+
+	if err := r.db.First(&food, id).Error; err != nil {
+		r.log.Error("failed to fetch food", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &food, nil
+}
+
+func (r *gormFoodRepository) GetFoodByName(name string) (*models.Food, error) {
+	var food models.Food
+
+	if err := r.db.Where("LOWER(name) = LOWER(?)", name).First(&food).Error; err != nil {
+		return nil, err
+	}
+
+	return &food, nil
+}
+
+func (r *gormFoodRepository) GetFoods(ids []uint) ([]models.Food, error) {
+	var foods []models.Food
+
+	if err := r.db.Where("id IN ?", ids).Find(&foods).Error; err != nil {
+		r.log.Error("failed to fetch foods", "err", err)
+		return nil, err
+	}
+
+	return foods, nil
+}
+
+func (r *gormFoodRepository) ListFoods(query string) ([]models.Food, error) {
+	var foods []models.Food
+
+	db := r.db.Order("name")
+	if query != "" {
+		db = db.Where("name ILIKE ?", "%"+query+"%")
+	}
+
+	if err := db.Find(&foods).Error; err != nil {
+		r.log.Error("failed to list foods", "err", err)
+		return nil, err
+	}
+
+	return foods, nil
+}
+
+func (r *gormFoodRepository) UpdateFood(food *models.Food) error {
+	if food == nil {
+		r.log.Error("error in UpdateFood function food_repository.go")
+		return errors.New("food is nil")
+	}
+
+	if err := r.db.Save(food).Error; err != nil {
+		r.log.Error("failed to update food", "id", food.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteFood удаляет продукт окончательно, чтобы название можно было занять снова
+func (r *gormFoodRepository) DeleteFood(id uint) error {
+	if err := r.db.Unscoped().Delete(&models.Food{}, id).Error; err != nil {
+		r.log.Error("failed to delete food", "id", id, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormFoodRepository) CountFoodUsage(foodID uint) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.RecipeIngredient{}).
+		Joins("JOIN recipes ON recipes.id = recipe_ingredients.recipe_id AND recipes.deleted_at IS NULL").
+		Where("recipe_ingredients.food_id = ?", foodID).
+		Count(&count).Error
+	if err != nil {
+		r.log.Error("failed to count food usage", "food_id", foodID, "err", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *gormFoodRepository) CreateRecipe(recipe *models.Recipe) error {
+	if recipe == nil {
+		r.log.Error("error in CreateRecipe function food_repository.go")
+		return errors.New("recipe is nil")
+	}
+
+	if err := r.db.Omit("Ingredients.Food").Create(recipe).Error; err != nil {
+		r.log.Error("failed to create recipe", "name", recipe.Name, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormFoodRepository) GetRecipe(id uint) (*models.Recipe, error) {
+	var recipe models.Recipe
+
+	if err := r.db.Preload("Ingredients.Food").First(&recipe, id).Error; err != nil {
+		r.log.Error("failed to fetch recipe", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &recipe, nil
+}
+
+func (r *gormFoodRepository) ListRecipes() ([]models.Recipe, error) {
+	var recipes []models.Recipe
+
+	if err := r.db.Preload("Ingredients.Food").Order("name").Find(&recipes).Error; err != nil {
+		r.log.Error("failed to list recipes", "err", err)
+		return nil, err
+	}
+
+	return recipes, nil
+}
+
+func (r *gormFoodRepository) UpdateRecipe(recipe *models.Recipe) error {
+	if recipe == nil {
+		r.log.Error("error in UpdateRecipe function food_repository.go")
+		return errors.New("recipe is nil")
+	}
+
+	if err := r.db.Omit("Ingredients").Save(recipe).Error; err != nil {
+		r.log.Error("failed to update recipe", "id", recipe.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormFoodRepository) ReplaceIngredients(recipeID uint, ingredients []models.RecipeIngredient) error {
+	if err := r.db.Where("recipe_id = ?", recipeID).Delete(&models.RecipeIngredient{}).Error; err != nil {
+		r.log.Error("failed to delete recipe ingredients", "recipe_id", recipeID, "err", err)
+		return err
+	}
+	if len(ingredients) == 0 {
+		return nil
+	}
+
+	for i := range ingredients {
+		ingredients[i].ID = 0
+		ingredients[i].RecipeID = recipeID
+	}
+
+	if err := r.db.Omit("Food").Create(&ingredients).Error; err != nil {
+		r.log.Error("failed to create recipe ingredients", "recipe_id", recipeID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormFoodRepository) DeleteRecipe(id uint) error {
+	if err := r.db.Delete(&models.Recipe{}, id).Error; err != nil {
+		r.log.Error("failed to delete recipe", "id", id, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormFoodRepository) RecipeIDsByFood(foodID uint) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.Model(&models.RecipeIngredient{}).
+		Distinct().
+		Where("food_id = ?", foodID).
+		Pluck("recipe_id", &ids).Error
+	if err != nil {
+		r.log.Error("failed to fetch recipes by food", "food_id", foodID, "err", err)
+		return nil, err
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GiftRepository interface {
+	WithTx(tx *gorm.DB) GiftRepository
+	Create(gift *models.Gift) error
+	GetByID(id uint) (*models.Gift, error)
+	Lock(id uint) (*models.Gift, error)
+	Update(gift *models.Gift) error
+	ListSent(senderID uint, status string) ([]models.Gift, error)
+	ListReceived(recipientID uint, status string) ([]models.Gift, error)
+	// ListExpiredIDs возвращает непринятые подарки, срок которых истёк к моменту now
+	ListExpiredIDs(now time.Time) ([]uint, error)
+}
+
+type gormGiftRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewGiftRepository(db *gorm.DB, log *slog.Logger) GiftRepository {
+	return &gormGiftRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormGiftRepository) WithTx(tx *gorm.DB) GiftRepository {
+	return &gormGiftRepository{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+func (r *gormGiftRepository) Create(gift *models.Gift) error {
+	if gift == nil {
+		r.log.Error("error in Create function gift_repository.go")
+		return errors.New("gift is nil")
+	}
+
+	if err := r.db.Create(gift).Error; err != nil {
+		r.log.Error("failed to create gift", "sender_id", gift.SenderID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormGiftRepository) GetByID(id uint) (*models.Gift, error) {
+	var gift models.Gift
+
+	if err := r.db.Preload("Categories").First(&gift, id).Error; err != nil {
+		r.log.Error("failed to fetch gift", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &gift, nil
+}
+
+func (r *gormGiftRepository) Lock(id uint) (*models.Gift, error) {
+	var gift models.Gift
+
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&gift, id).Error; err != nil {
+		r.log.Error("failed to lock gift", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &gift, nil
+}
+
+func (r *gormGiftRepository) Update(gift *models.Gift) error {
+	if gift == nil {
+		r.log.Error("error in Update function gift_repository.go")
+		return errors.New("gift is nil")
+	}
+
+	if err := r.db.Save(gift).Error; err != nil {
+		r.log.Error("failed to update gift", "id", gift.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormGiftRepository) ListSent(senderID uint, status string) ([]models.Gift, error) {
+	return r.list("sender_id", senderID, status)
+}
+
+func (r *gormGiftRepository) ListReceived(recipientID uint, status string) ([]models.Gift, error) {
+	return r.list("recipient_id", recipientID, status)
+}
+
+func (r *gormGiftRepository) list(column string, userID uint, status string) ([]models.Gift, error) {
+	var gifts []models.Gift
+
+	query := r.db.Preload("Categories").Where(column+" = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("created_at DESC").Find(&gifts).Error; err != nil {
+		r.log.Error("failed to list gifts", column, userID, "err", err)
+		return nil, err
+	}
+
+	return gifts, nil
+}
+
+func (r *gormGiftRepository) ListExpiredIDs(now time.Time) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.Model(&models.Gift{}).
+		Where("status = ? AND expires_at <= ?", models.GiftStatusPending, now).
+		Order("expires_at ASC").
+		Pluck("id", &ids).Error
+	if err != nil {
+		r.log.Error("failed to list expired gifts", "err", err)
+		return nil, err
+	}
+
+	return ids, nil
+}
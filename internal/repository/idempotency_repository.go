@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	// Reserve создаёт запись ключа; created == false, если ключ уже существует
+	Reserve(record *models.IdempotencyKey) (created bool, err error)
+	Get(userID uint, key string) (*models.IdempotencyKey, error)
+	Complete(id uint, status int, contentType, body string) error
+	Delete(id uint) error
+}
+
+type gormIdempotencyRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewIdempotencyRepository(db *gorm.DB, log *slog.Logger) IdempotencyRepository {
+	return &gormIdempotencyRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormIdempotencyRepository) Reserve(record *models.IdempotencyKey) (bool, error) {
+	if record == nil {
+		r.log.Error("error in Reserve function idempotency_repository.go")
+		return false, errors.New("idempotency key is nil")
+	}
+
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		r.log.Error("failed to reserve idempotency key", "user_id", record.UserID, "err", result.Error)
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *gormIdempotencyRepository) Get(userID uint, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+
+	if err := r.db.Where("user_id = ? AND key = ?", userID, key).First(&record).Error; err != nil {
+		r.log.Error("failed to fetch idempotency key", "user_id", userID, "err", err)
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *gormIdempotencyRepository) Complete(id uint, status int, contentType, body string) error {
+	err := r.db.Model(&models.IdempotencyKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"response_status": status,
+			"content_type":    contentType,
+			"response_body":   body,
+			"completed_at":    time.Now(),
+		}).Error
+	if err != nil {
+		r.log.Error("failed to complete idempotency key", "id", id, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// Delete удаляет ключ физически, чтобы его можно было использовать повторно
+func (r *gormIdempotencyRepository) Delete(id uint) error {
+	if err := r.db.Unscoped().Delete(&models.IdempotencyKey{}, id).Error; err != nil {
+		r.log.Error("failed to delete idempotency key", "id", id, "err", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerRepository interface {
+	WithTx(tx *gorm.DB) LedgerRepository
+	GetOrCreateAccount(code, name, kind string, userID *uint) (*models.LedgerAccount, error)
+	GetAccount(id uint) (*models.LedgerAccount, error)
+	LockAccount(id uint) error
+	CreateEntry(entry *models.JournalEntry) error
+	GetEntry(id uint) (*models.JournalEntry, error)
+	AccountBalance(accountID uint) (int, error)
+	UserBalances(userIDs []uint) (map[uint]int, error)
+	ListAccountTransactions(accountID uint) ([]models.LedgerTransaction, error)
+}
+
+type gormLedgerRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewLedgerRepository(db *gorm.DB, log *slog.Logger) LedgerRepository {
+	return &gormLedgerRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// WithTx возвращает репозиторий, работающий внутри переданной транзакции
+func (r *gormLedgerRepository) WithTx(tx *gorm.DB) LedgerRepository {
+	return &gormLedgerRepository{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+func (r *gormLedgerRepository) GetOrCreateAccount(code, name, kind string, userID *uint) (*models.LedgerAccount, error) {
+	account := models.LedgerAccount{
+		Code:   code,
+		Name:   name,
+		Kind:   kind,
+		UserID: userID,
+	}
+
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		r.log.Error("failed to create ledger account", "code", code, "err", err)
+		return nil, err
+	}
+
+	if account.ID != 0 {
+		return &account, nil
+	}
+
+	var existing models.LedgerAccount
+	if err := r.db.Where("code = ?", code).First(&existing).Error; err != nil {
+		r.log.Error("failed to fetch ledger account", "code", code, "err", err)
+		return nil, err
+	}
+
+	return &existing, nil
+}
+
+func (r *gormLedgerRepository) GetAccount(id uint) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+
+	if err := r.db.First(&account, id).Error; err != nil {
+		r.log.Error("failed to fetch ledger account", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// LockAccount блокирует строку счёта до конца транзакции,
+// чтобы параллельные списания не увели баланс в минус
+func (r *gormLedgerRepository) LockAccount(id uint) error {
+	var account models.LedgerAccount
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, id).Error; err != nil {
+		r.log.Error("failed to lock ledger account", "id", id, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormLedgerRepository) CreateEntry(entry *models.JournalEntry) error {
+	if entry == nil || len(entry.Postings) == 0 {
+		r.log.Error("error in CreateEntry function ledger_repository.go")
+		return errors.New("journal entry without postings")
+	}
+
+	if err := r.db.Create(entry).Error; err != nil {
+		r.log.Error("failed to create journal entry", "kind", entry.Kind, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormLedgerRepository) GetEntry(id uint) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+
+	if err := r.db.Preload("Postings").First(&entry, id).Error; err != nil {
+		r.log.Error("failed to fetch journal entry", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (r *gormLedgerRepository) AccountBalance(accountID uint) (int, error) {
+	var balance int
+	err := r.db.Model(&models.Posting{}).
+		Where("account_id = ?", accountID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&balance).Error
+	if err != nil {
+		r.log.Error("failed to calculate account balance", "account_id", accountID, "err", err)
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+func (r *gormLedgerRepository) UserBalances(userIDs []uint) (map[uint]int, error) {
+	var rows []struct {
+		UserID  uint
+		Balance int
+	}
+
+	err := r.db.Model(&models.Posting{}).
+		Select("ledger_accounts.user_id AS user_id, COALESCE(SUM(postings.amount), 0) AS balance").
+		Joins("JOIN ledger_accounts ON ledger_accounts.id = postings.account_id").
+		Where("ledger_accounts.kind = ? AND ledger_accounts.user_id IN ?", models.AccountKindWallet, userIDs).
+		Group("ledger_accounts.user_id").
+		Scan(&rows).Error
+	if err != nil {
+		r.log.Error("failed to calculate user balances", "err", err)
+		return nil, err
+	}
+
+	balances := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		balances[row.UserID] = row.Balance
+	}
+
+	return balances, nil
+}
+
+func (r *gormLedgerRepository) ListAccountTransactions(accountID uint) ([]models.LedgerTransaction, error) {
+	var transactions []models.LedgerTransaction
+
+	err := r.db.Model(&models.Posting{}).
+		Select("journal_entries.id AS entry_id, journal_entries.kind, journal_entries.description, "+
+			"journal_entries.reference, postings.amount, journal_entries.created_at").
+		Joins("JOIN journal_entries ON journal_entries.id = postings.journal_entry_id").
+		Where("postings.account_id = ?", accountID).
+		Order("journal_entries.created_at ASC, journal_entries.id ASC").
+		Scan(&transactions).Error
+	if err != nil {
+		r.log.Error("failed to fetch account transactions", "account_id", accountID, "err", err)
+		return nil, err
+	}
+
+	return transactions, nil
+}
@@ -9,11 +9,16 @@ import (
 )
 
 type MealPlanItemRepository interface {
+	WithTx(tx *gorm.DB) MealPlanItemRepository
 	Create(mealPlanItem *models.MealPlanItem) error
 	List() ([]models.MealPlanItem, error)
 	Update(mealPlan *models.MealPlanItem) error
 	GetMealPlanItemByID(id uint) (*models.MealPlanItem, error)
 	Delete(id uint) error
+	CountByRecipe(recipeID uint) (int64, error)
+	// RefreshRecipeNutrition пересчитывает пищевую ценность блюд рецепта
+	// по значениям на одну порцию с учётом количества порций каждого блюда
+	RefreshRecipeNutrition(recipeID uint, perServing models.NutritionTotals) error
 }
 
 type gormMealPlanItemRepository struct {
@@ -28,6 +33,13 @@ func NewMealPlanItemRepository(db *gorm.DB, logger *slog.Logger) MealPlanItemRep
 	}
 }
 
+func (r *gormMealPlanItemRepository) WithTx(tx *gorm.DB) MealPlanItemRepository {
+	return &gormMealPlanItemRepository{
+		db:     tx,
+		logger: r.logger,
+	}
+}
+
 func (r *gormMealPlanItemRepository) Create(mealPlanItem *models.MealPlanItem) error {
 	if mealPlanItem == nil {
 		r.logger.Error("failed to create meal plan item")
@@ -61,7 +73,7 @@ func (r *gormMealPlanItemRepository) Update(mealPlanItem *models.MealPlanItem) e
 	}
 
 	err := r.db.Model(&models.MealPlanItem{}).Where("id = ?", mealPlanItem.ID).
-		Select("Name", "Calories", "Protein", "Carbs", "MealPlanId").Updates(mealPlanItem).Error
+		Select("Name", "Description", "Calories", "Protein", "Carbs", "Fat", "Fibre", "Day", "MealSlot", "RecipeID", "Servings", "DietTags", "Allergens", "MealPlanId").Updates(mealPlanItem).Error
 
 	if err != nil {
 		r.logger.Error("failed to update meal plan", "id", mealPlanItem.ID, "err", err)
@@ -90,3 +102,29 @@ func (r *gormMealPlanItemRepository) Delete(id uint) error {
 	r.logger.Info("meal plan item deleted", "id", id)
 	return nil
 }
+
+func (r *gormMealPlanItemRepository) CountByRecipe(recipeID uint) (int64, error) {
+	var count int64
+
+	if err := r.db.Model(&models.MealPlanItem{}).Where("recipe_id = ?", recipeID).Count(&count).Error; err != nil {
+		r.logger.Error("failed to count meal plan items by recipe", "recipe_id", recipeID, "err", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *gormMealPlanItemRepository) RefreshRecipeNutrition(recipeID uint, perServing models.NutritionTotals) error {
+	err := r.db.Model(&models.MealPlanItem{}).Where("recipe_id = ?", recipeID).Updates(map[string]interface{}{
+		"calories": gorm.Expr("ROUND(CAST(servings * ? AS numeric), 2)", perServing.Calories),
+		"protein":  gorm.Expr("ROUND(CAST(servings * ? AS numeric), 2)", perServing.Protein),
+		"fat":      gorm.Expr("ROUND(CAST(servings * ? AS numeric), 2)", perServing.Fat),
+		"carbs":    gorm.Expr("ROUND(CAST(servings * ? AS numeric), 2)", perServing.Carbs),
+		"fibre":    gorm.Expr("ROUND(CAST(servings * ? AS numeric), 2)", perServing.Fibre),
+	}).Error
+	if err != nil {
+		r.logger.Error("failed to refresh meal plan items nutrition", "recipe_id", recipeID, "err", err)
+		return err
+	}
+	r.logger.Info("meal plan items nutrition refreshed", "recipe_id", recipeID)
+	return nil
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PlanVersionRepository interface {
+	WithTx(tx *gorm.DB) PlanVersionRepository
+	Create(version *models.PlanVersion) error
+	// Latest возвращает последнюю версию плана; gorm.ErrRecordNotFound, если план не публиковался
+	Latest(kind string, planID uint) (*models.PlanVersion, error)
+	Get(kind string, planID uint, version int) (*models.PlanVersion, error)
+	// List возвращает версии плана без снимков, от новых к старым
+	List(kind string, planID uint) ([]models.PlanVersion, error)
+	// PublishedBefore возвращает последнюю версию, опубликованную не позже at, а если таких нет —
+	// первую: план, добавленный в программу после покупки, покупатель видит с первой версии
+	PublishedBefore(kind string, planID uint, at time.Time) (*models.PlanVersion, error)
+	// LockPlan блокирует версии плана до конца транзакции, чтобы номера не совпали
+	LockPlan(kind string, planID uint) error
+	// UnversionedPlanIDs возвращает каталожные планы без единой версии; личные планы клиентов не версионируются
+	UnversionedPlanIDs(kind string) ([]uint, error)
+
+	GetUserPlan(userID, categoryID uint) (*models.UserPlan, error)
+	PinUserPlan(userPlanID uint, at time.Time) error
+	CategoryExercisePlans(categoryID uint) ([]models.ExercisePlan, error)
+	CategoryMealPlans(categoryID uint) ([]models.MealPlan, error)
+}
+
+type gormPlanVersionRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewPlanVersionRepository(db *gorm.DB, log *slog.Logger) PlanVersionRepository {
+	return &gormPlanVersionRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormPlanVersionRepository) WithTx(tx *gorm.DB) PlanVersionRepository {
+	return &gormPlanVersionRepository{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+func (r *gormPlanVersionRepository) Create(version *models.PlanVersion) error {
+	if version == nil {
+		r.log.Error("error in Create function plan_version_repository.go")
+		return errors.New("plan version is nil")
+	}
+
+	if err := r.db.Create(version).Error; err != nil {
+		r.log.Error("failed to create plan version", "kind", version.PlanKind, "plan_id", version.PlanID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormPlanVersionRepository) Latest(kind string, planID uint) (*models.PlanVersion, error) {
+	var version models.PlanVersion
+
+	err := r.db.Where("plan_kind = ? AND plan_id = ?", kind, planID).Order("version DESC").First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+func (r *gormPlanVersionRepository) Get(kind string, planID uint, number int) (*models.PlanVersion, error) {
+	var version models.PlanVersion
+
+	err := r.db.Where("plan_kind = ? AND plan_id = ? AND version = ?", kind, planID, number).First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+func (r *gormPlanVersionRepository) List(kind string, planID uint) ([]models.PlanVersion, error) {
+	var versions []models.PlanVersion
+
+	err := r.db.Omit("Snapshot").
+		Where("plan_kind = ? AND plan_id = ?", kind, planID).
+		Order("version DESC").
+		Find(&versions).Error
+	if err != nil {
+		r.log.Error("failed to list plan versions", "kind", kind, "plan_id", planID, "err", err)
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+func (r *gormPlanVersionRepository) PublishedBefore(kind string, planID uint, at time.Time) (*models.PlanVersion, error) {
+	var version models.PlanVersion
+
+	err := r.db.Where("plan_kind = ? AND plan_id = ? AND published_at <= ?", kind, planID, at).
+		Order("version DESC").
+		First(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = r.db.Where("plan_kind = ? AND plan_id = ?", kind, planID).Order("version").First(&version).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+func (r *gormPlanVersionRepository) LockPlan(kind string, planID uint) error {
+	var ids []uint
+
+	err := r.db.Model(&models.PlanVersion{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("plan_kind = ? AND plan_id = ?", kind, planID).
+		Pluck("id", &ids).Error
+	if err != nil {
+		r.log.Error("failed to lock plan versions", "kind", kind, "plan_id", planID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormPlanVersionRepository) UnversionedPlanIDs(kind string) ([]uint, error) {
+	var ids []uint
+
+	table := "exercise_plans"
+	if kind == models.PlanKindMeal {
+		table = "meal_plans"
+	}
+
+	err := r.db.Table(table).
+		Where("deleted_at IS NULL").
+		Where(catalogPlans).
+		Where("NOT EXISTS (SELECT 1 FROM plan_versions WHERE plan_versions.plan_kind = ? AND plan_versions.plan_id = "+table+".id)", kind).
+		Order("id").
+		Pluck("id", &ids).Error
+	if err != nil {
+		r.log.Error("failed to fetch unversioned plans", "kind", kind, "err", err)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (r *gormPlanVersionRepository) GetUserPlan(userID, categoryID uint) (*models.UserPlan, error) {
+	var userPlan models.UserPlan
+
+	err := r.db.Where("user_id = ? AND categories_id = ?", userID, categoryID).Order("id").First(&userPlan).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &userPlan, nil
+}
+
+func (r *gormPlanVersionRepository) PinUserPlan(userPlanID uint, at time.Time) error {
+	err := r.db.Model(&models.UserPlan{}).Where("id = ?", userPlanID).Update("version_pinned_at", at).Error
+	if err != nil {
+		r.log.Error("failed to pin user plan versions", "user_plan_id", userPlanID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormPlanVersionRepository) CategoryExercisePlans(categoryID uint) ([]models.ExercisePlan, error) {
+	var plans []models.ExercisePlan
+
+	if err := r.db.Where("categories_id = ?", categoryID).Where(catalogPlans).Order("id").Find(&plans).Error; err != nil {
+		r.log.Error("failed to fetch category exercise plans", "categories_id", categoryID, "err", err)
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+func (r *gormPlanVersionRepository) CategoryMealPlans(categoryID uint) ([]models.MealPlan, error) {
+	var plans []models.MealPlan
+
+	if err := r.db.Where("categories_id = ?", categoryID).Where(catalogPlans).Order("id").Find(&plans).Error; err != nil {
+		r.log.Error("failed to fetch category meal plans", "categories_id", categoryID, "err", err)
+		return nil, err
+	}
+
+	return plans, nil
+}
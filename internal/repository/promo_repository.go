@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PromoRepository interface {
+	WithTx(tx *gorm.DB) PromoRepository
+	Create(promo *models.PromoCode) error
+	GetByID(id uint) (*models.PromoCode, error)
+	GetByCode(code string, lock bool) (*models.PromoCode, error)
+	List() ([]models.PromoCode, error)
+	Update(promo *models.PromoCode) error
+	CountUserRedemptions(promoID, userID uint) (int64, error)
+	CreateRedemption(redemption *models.PromoRedemption) error
+	GetRedemptionByEntry(entryID uint) (*models.PromoRedemption, error)
+	DecrementRedemptions(promoID uint) error
+	ListRedemptions(promoID uint) ([]models.PromoRedemption, error)
+}
+
+type gormPromoRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewPromoRepository(db *gorm.DB, log *slog.Logger) PromoRepository {
+	return &gormPromoRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormPromoRepository) WithTx(tx *gorm.DB) PromoRepository {
+	return &gormPromoRepository{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+func (r *gormPromoRepository) Create(promo *models.PromoCode) error {
+	if promo == nil {
+		r.log.Error("error in Create function promo_repository.go")
+		return errors.New("promo code is nil")
+	}
+
+	if err := r.db.Create(promo).Error; err != nil {
+		r.log.Error("failed to create promo code", "code", promo.Code, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormPromoRepository) GetByID(id uint) (*models.PromoCode, error) {
+	var promo models.PromoCode
+
+	if err := r.db.Preload("Scopes").First(&promo, id).Error; err != nil {
+		r.log.Error("failed to fetch promo code", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &promo, nil
+}
+
+// GetByCode ищет промокод; при lock строка блокируется до конца транзакции,
+// чтобы параллельные покупки не превысили лимит применений
+func (r *gormPromoRepository) GetByCode(code string, lock bool) (*models.PromoCode, error) {
+	var promo models.PromoCode
+
+	query := r.db
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	if err := query.Where("code = ?", code).First(&promo).Error; err != nil {
+		r.log.Warn("promo code not found", "err", err)
+		return nil, err
+	}
+
+	if err := r.db.Where("promo_code_id = ?", promo.ID).Find(&promo.Scopes).Error; err != nil {
+		r.log.Error("failed to fetch promo code scopes", "id", promo.ID, "err", err)
+		return nil, err
+	}
+
+	return &promo, nil
+}
+
+func (r *gormPromoRepository) List() ([]models.PromoCode, error) {
+	var list []models.PromoCode
+
+	if err := r.db.Preload("Scopes").Order("created_at DESC").Find(&list).Error; err != nil {
+		r.log.Error("failed to list promo codes", "err", err)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *gormPromoRepository) Update(promo *models.PromoCode) error {
+	if promo == nil {
+		r.log.Error("error in Update function promo_repository.go")
+		return errors.New("promo code is nil")
+	}
+
+	if err := r.db.Omit("Scopes").Save(promo).Error; err != nil {
+		r.log.Error("failed to update promo code", "id", promo.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormPromoRepository) CountUserRedemptions(promoID, userID uint) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.PromoRedemption{}).
+		Where("promo_code_id = ? AND user_id = ?", promoID, userID).
+		Count(&count).Error
+	if err != nil {
+		r.log.Error("failed to count promo redemptions", "promo_code_id", promoID, "err", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *gormPromoRepository) CreateRedemption(redemption *models.PromoRedemption) error {
+	if err := r.db.Create(redemption).Error; err != nil {
+		r.log.Error("failed to create promo redemption", "promo_code_id", redemption.PromoCodeID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormPromoRepository) GetRedemptionByEntry(entryID uint) (*models.PromoRedemption, error) {
+	var redemption models.PromoRedemption
+
+	if err := r.db.Where("journal_entry_id = ?", entryID).First(&redemption).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			r.log.Error("failed to fetch promo redemption", "journal_entry_id", entryID, "err", err)
+		}
+		return nil, err
+	}
+
+	return &redemption, nil
+}
+
+// DecrementRedemptions уменьшает счётчик применений одним запросом,
+// чтобы не потерять параллельное применение того же промокода
+func (r *gormPromoRepository) DecrementRedemptions(promoID uint) error {
+	err := r.db.Model(&models.PromoCode{}).
+		Where("id = ? AND redemption_count > 0", promoID).
+		Update("redemption_count", gorm.Expr("redemption_count - 1")).Error
+	if err != nil {
+		r.log.Error("failed to decrement promo redemptions", "promo_code_id", promoID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormPromoRepository) ListRedemptions(promoID uint) ([]models.PromoRedemption, error) {
+	var list []models.PromoRedemption
+
+	if err := r.db.Where("promo_code_id = ?", promoID).Order("created_at DESC").Find(&list).Error; err != nil {
+		r.log.Error("failed to list promo redemptions", "promo_code_id", promoID, "err", err)
+		return nil, err
+	}
+
+	return list, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository interface {
+	Create(token *models.RefreshToken) error
+	GetByTokenID(tokenID string) (*models.RefreshToken, error)
+	// Revoke возвращает false, если токен уже был отозван
+	Revoke(tokenID string) (bool, error)
+	RevokeAllForUser(userID uint) error
+}
+
+type gormRefreshTokenRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewRefreshTokenRepository(db *gorm.DB, log *slog.Logger) RefreshTokenRepository {
+	return &gormRefreshTokenRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	if token == nil {
+		r.log.Error("error in Create function refresh_token_repository.go")
+		return errors.New("refresh token is nil")
+	}
+
+	if err := r.db.Create(token).Error; err != nil {
+		r.log.Error("failed to create refresh token", "user_id", token.UserID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormRefreshTokenRepository) GetByTokenID(tokenID string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+
+	if err := r.db.Where("token_id = ?", tokenID).First(&token).Error; err != nil {
+		r.log.Warn("refresh token not found", "err", err)
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *gormRefreshTokenRepository) Revoke(tokenID string) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("token_id = ? AND revoked_at IS NULL", tokenID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		r.log.Error("failed to revoke refresh token", "err", result.Error)
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *gormRefreshTokenRepository) RevokeAllForUser(userID uint) error {
+	err := r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		r.log.Error("failed to revoke user refresh tokens", "user_id", userID, "err", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefundRepository interface {
+	WithTx(tx *gorm.DB) RefundRepository
+	Create(refund *models.RefundRequest) error
+	GetByID(id uint) (*models.RefundRequest, error)
+	Lock(id uint) (*models.RefundRequest, error)
+	Update(refund *models.RefundRequest) error
+	List(filter models.RefundFilter) ([]models.RefundRequest, error)
+	// HasOpen проверяет, есть ли по покупке заявка на рассмотрении или одобренная
+	HasOpen(userPlanID uint) (bool, error)
+}
+
+type gormRefundRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewRefundRepository(db *gorm.DB, log *slog.Logger) RefundRepository {
+	return &gormRefundRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormRefundRepository) WithTx(tx *gorm.DB) RefundRepository {
+	return &gormRefundRepository{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+func (r *gormRefundRepository) Create(refund *models.RefundRequest) error {
+	if refund == nil {
+		r.log.Error("error in Create function refund_repository.go")
+		return errors.New("refund request is nil")
+	}
+
+	if err := r.db.Create(refund).Error; err != nil {
+		r.log.Error("failed to create refund request", "user_id", refund.UserID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormRefundRepository) GetByID(id uint) (*models.RefundRequest, error) {
+	var refund models.RefundRequest
+
+	if err := r.db.First(&refund, id).Error; err != nil {
+		r.log.Error("failed to fetch refund request", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+func (r *gormRefundRepository) Lock(id uint) (*models.RefundRequest, error) {
+	var refund models.RefundRequest
+
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&refund, id).Error; err != nil {
+		r.log.Error("failed to lock refund request", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+func (r *gormRefundRepository) Update(refund *models.RefundRequest) error {
+	if refund == nil {
+		r.log.Error("error in Update function refund_repository.go")
+		return errors.New("refund request is nil")
+	}
+
+	if err := r.db.Save(refund).Error; err != nil {
+		r.log.Error("failed to update refund request", "id", refund.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormRefundRepository) List(filter models.RefundFilter) ([]models.RefundRequest, error) {
+	var refunds []models.RefundRequest
+
+	query := r.db.Model(&models.RefundRequest{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.CategoryID != nil {
+		query = query.Where("categories_id = ?", *filter.CategoryID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Order("created_at DESC").Find(&refunds).Error; err != nil {
+		r.log.Error("failed to list refund requests", "err", err)
+		return nil, err
+	}
+
+	return refunds, nil
+}
+
+func (r *gormRefundRepository) HasOpen(userPlanID uint) (bool, error) {
+	var count int64
+
+	err := r.db.Model(&models.RefundRequest{}).
+		Where("user_plan_id = ? AND status IN ?", userPlanID, []string{models.RefundStatusPending, models.RefundStatusApproved}).
+		Count(&count).Error
+	if err != nil {
+		r.log.Error("failed to check open refund requests", "user_plan_id", userPlanID, "err", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TopUpRepository interface {
+	WithTx(tx *gorm.DB) TopUpRepository
+	Create(topUp *models.TopUp) error
+	GetByID(id uint) (*models.TopUp, error)
+	GetByProviderPayment(provider, paymentID string) (*models.TopUp, error)
+	LockByProviderPayment(provider, paymentID string) (*models.TopUp, error)
+	Update(topUp *models.TopUp) error
+	ListByUser(userID uint) ([]models.TopUp, error)
+}
+
+type gormTopUpRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewTopUpRepository(db *gorm.DB, log *slog.Logger) TopUpRepository {
+	return &gormTopUpRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormTopUpRepository) WithTx(tx *gorm.DB) TopUpRepository {
+	return &gormTopUpRepository{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+func (r *gormTopUpRepository) Create(topUp *models.TopUp) error {
+	if topUp == nil {
+		r.log.Error("error in Create function topup_repository.go")
+		return errors.New("top up is nil")
+	}
+
+	if err := r.db.Create(topUp).Error; err != nil {
+		r.log.Error("failed to create top up", "user_id", topUp.UserID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormTopUpRepository) GetByID(id uint) (*models.TopUp, error) {
+	var topUp models.TopUp
+
+	if err := r.db.First(&topUp, id).Error; err != nil {
+		r.log.Error("failed to fetch top up", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &topUp, nil
+}
+
+func (r *gormTopUpRepository) GetByProviderPayment(provider, paymentID string) (*models.TopUp, error) {
+	var topUp models.TopUp
+
+	err := r.db.Where("provider = ? AND provider_payment_id = ?", provider, paymentID).First(&topUp).Error
+	if err != nil {
+		r.log.Error("failed to fetch top up", "provider", provider, "payment_id", paymentID, "err", err)
+		return nil, err
+	}
+
+	return &topUp, nil
+}
+
+// LockByProviderPayment находит пополнение по ID платежа у провайдера
+// и блокирует строку до конца транзакции
+func (r *gormTopUpRepository) LockByProviderPayment(provider, paymentID string) (*models.TopUp, error) {
+	var topUp models.TopUp
+
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("provider = ? AND provider_payment_id = ?", provider, paymentID).
+		First(&topUp).Error
+	if err != nil {
+		r.log.Error("failed to lock top up", "provider", provider, "payment_id", paymentID, "err", err)
+		return nil, err
+	}
+
+	return &topUp, nil
+}
+
+func (r *gormTopUpRepository) Update(topUp *models.TopUp) error {
+	if topUp == nil {
+		r.log.Error("error in Update function topup_repository.go")
+		return errors.New("top up is nil")
+	}
+
+	if err := r.db.Save(topUp).Error; err != nil {
+		r.log.Error("failed to update top up", "id", topUp.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormTopUpRepository) ListByUser(userID uint) ([]models.TopUp, error) {
+	var topUps []models.TopUp
+
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&topUps).Error; err != nil {
+		r.log.Error("failed to list top ups", "user_id", userID, "err", err)
+		return nil, err
+	}
+
+	return topUps, nil
+}
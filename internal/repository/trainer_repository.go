@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TrainerRepository interface {
+	WithTx(tx *gorm.DB) TrainerRepository
+	Create(profile *models.TrainerProfile) error
+	GetByUserID(userID uint) (*models.TrainerProfile, error)
+	List() ([]models.TrainerProfile, error)
+	Update(profile *models.TrainerProfile) error
+	// CategoryTrainer возвращает профиль владельца категории; gorm.ErrRecordNotFound, если владельца нет
+	CategoryTrainer(categoryID uint) (*models.TrainerProfile, error)
+	Categories(trainerID uint) ([]models.Categories, error)
+
+	// Sales возвращает число действующих покупок и подарков по категориям тренера
+	Sales(trainerID uint) (map[uint]map[string]int, error)
+	// ActiveSubscribers возвращает число подписчиков по категориям и общее число разных подписчиков
+	ActiveSubscribers(trainerID uint, at time.Time) (map[uint]int, int, error)
+
+	CreatePayout(payout *models.TrainerPayout) error
+	ListPayouts(trainerID uint, limit int) ([]models.TrainerPayout, error)
+	PaidOut(trainerID uint) (int, error)
+}
+
+type gormTrainerRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewTrainerRepository(db *gorm.DB, log *slog.Logger) TrainerRepository {
+	return &gormTrainerRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormTrainerRepository) WithTx(tx *gorm.DB) TrainerRepository {
+	return &gormTrainerRepository{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+func (r *gormTrainerRepository) Create(profile *models.TrainerProfile) error {
+	if profile == nil {
+		r.log.Error("error in Create function trainer_repository.go")
+		return errors.New("trainer profile is nil")
+	}
+
+	if err := r.db.Create(profile).Error; err != nil {
+		r.log.Error("failed to create trainer profile", "user_id", profile.UserID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormTrainerRepository) GetByUserID(userID uint) (*models.TrainerProfile, error) {
+	var profile models.TrainerProfile
+
+	if err := r.db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+func (r *gormTrainerRepository) List() ([]models.TrainerProfile, error) {
+	var profiles []models.TrainerProfile
+
+	if err := r.db.Order("id").Find(&profiles).Error; err != nil {
+		r.log.Error("failed to list trainer profiles", "err", err)
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+func (r *gormTrainerRepository) Update(profile *models.TrainerProfile) error {
+	if profile == nil {
+		r.log.Error("error in Update function trainer_repository.go")
+		return errors.New("trainer profile is nil")
+	}
+
+	if err := r.db.Omit("User").Save(profile).Error; err != nil {
+		r.log.Error("failed to update trainer profile", "user_id", profile.UserID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormTrainerRepository) CategoryTrainer(categoryID uint) (*models.TrainerProfile, error) {
+	var profile models.TrainerProfile
+
+	err := r.db.Joins("JOIN categories ON categories.trainer_id = trainer_profiles.user_id").
+		Where("categories.id = ? AND categories.deleted_at IS NULL", categoryID).
+		First(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+func (r *gormTrainerRepository) Categories(trainerID uint) ([]models.Categories, error) {
+	var categories []models.Categories
+
+	if err := r.db.Where("trainer_id = ?", trainerID).Order("id").Find(&categories).Error; err != nil {
+		r.log.Error("failed to fetch trainer categories", "trainer_id", trainerID, "err", err)
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r *gormTrainerRepository) Sales(trainerID uint) (map[uint]map[string]int, error) {
+	var rows []struct {
+		CategoriesID uint
+		Source       string
+		Count        int
+	}
+
+	err := r.db.Model(&models.UserPlan{}).
+		Select("user_plans.categories_id, user_plans.source, COUNT(*) AS count").
+		Joins("JOIN categories ON categories.id = user_plans.categories_id").
+		Where("categories.trainer_id = ?", trainerID).
+		Group("user_plans.categories_id, user_plans.source").
+		Scan(&rows).Error
+	if err != nil {
+		r.log.Error("failed to count trainer sales", "trainer_id", trainerID, "err", err)
+		return nil, err
+	}
+
+	sales := make(map[uint]map[string]int)
+	for _, row := range rows {
+		if sales[row.CategoriesID] == nil {
+			sales[row.CategoriesID] = make(map[string]int)
+		}
+		sales[row.CategoriesID][row.Source] = row.Count
+	}
+
+	return sales, nil
+}
+
+func (r *gormTrainerRepository) ActiveSubscribers(trainerID uint, at time.Time) (map[uint]int, int, error) {
+	active := func() *gorm.DB {
+		return r.db.Model(&models.UserSubscription{}).
+			Joins("JOIN subscriptions ON subscriptions.id = user_subscriptions.subscription_id").
+			Joins("JOIN categories ON categories.id = subscriptions.categories_id").
+			Where("categories.trainer_id = ?", trainerID).
+			Where("user_subscriptions.status = ? AND user_subscriptions.end_date > ?", models.SubscriptionStatusActive, at)
+	}
+
+	var rows []struct {
+		CategoriesID uint
+		Count        int
+	}
+	err := active().
+		Select("subscriptions.categories_id, COUNT(DISTINCT user_subscriptions.user_id) AS count").
+		Group("subscriptions.categories_id").
+		Scan(&rows).Error
+	if err != nil {
+		r.log.Error("failed to count trainer subscribers", "trainer_id", trainerID, "err", err)
+		return nil, 0, err
+	}
+
+	var total int
+	if err := active().Select("COUNT(DISTINCT user_subscriptions.user_id)").Scan(&total).Error; err != nil {
+		r.log.Error("failed to count trainer subscribers", "trainer_id", trainerID, "err", err)
+		return nil, 0, err
+	}
+
+	subscribers := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		subscribers[row.CategoriesID] = row.Count
+	}
+
+	return subscribers, total, nil
+}
+
+func (r *gormTrainerRepository) CreatePayout(payout *models.TrainerPayout) error {
+	if payout == nil {
+		r.log.Error("error in CreatePayout function trainer_repository.go")
+		return errors.New("trainer payout is nil")
+	}
+
+	if err := r.db.Create(payout).Error; err != nil {
+		r.log.Error("failed to create trainer payout", "trainer_id", payout.TrainerID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormTrainerRepository) ListPayouts(trainerID uint, limit int) ([]models.TrainerPayout, error) {
+	var payouts []models.TrainerPayout
+
+	query := r.db.Where("trainer_id = ?", trainerID).Order("created_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&payouts).Error; err != nil {
+		r.log.Error("failed to list trainer payouts", "trainer_id", trainerID, "err", err)
+		return nil, err
+	}
+
+	return payouts, nil
+}
+
+func (r *gormTrainerRepository) PaidOut(trainerID uint) (int, error) {
+	var total int
+
+	err := r.db.Model(&models.TrainerPayout{}).
+		Where("trainer_id = ?", trainerID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error
+	if err != nil {
+		r.log.Error("failed to sum trainer payouts", "trainer_id", trainerID, "err", err)
+		return 0, err
+	}
+
+	return total, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"healthy_body/internal/models"
 	"log/slog"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,8 +14,14 @@ type UserRepository interface {
 	Create(req *models.User) error
 	GetAllUser() ([]models.User, error)
 	GetUserByID(id uint) (*models.User, error)
+	GetUserByEmail(email string) (*models.User, error)
 	GeUserCategory(id uint) (*models.User, error)
 	GetUserSub(id uint) (*models.User, error)
+	// GetUserPlans возвращает купленные и подаренные категории; withContent
+	// дополнительно загружает планы тренировок и питания
+	GetUserPlans(userID uint, withContent bool) ([]models.UserPlan, error)
+	// GetActiveSubscriptions возвращает подписки, действующие на момент now
+	GetActiveSubscriptions(userID uint, now time.Time, withContent bool) ([]models.UserSubscription, error)
 	Update(user *models.User) error
 	Delete(id uint) error
 }
@@ -83,14 +90,28 @@ func (r *gormUserRepository) GetUserByID(id uint) (*models.User, error) {
 	return &user, nil
 }
 
+func (r *gormUserRepository) GetUserByEmail(email string) (*models.User, error) {
+	var user models.User
+
+	if err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			r.log.Error("Ошибка при получении пользователя по email",
+				"error", err.Error())
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 func (r *gormUserRepository) GeUserCategory(id uint) (*models.User, error) {
 	var user models.User
 if err := r.db.
     Preload("UserPlans").
     Preload("UserPlans.Categories").
-    Preload("UserPlans.Categories.ExercisePlans").
+    Preload("UserPlans.Categories.ExercisePlans", catalogPlans).
     Preload("UserPlans.Categories.ExercisePlans.Exercises").
-	Preload("UserPlans.Categories.MealPlans").
+	Preload("UserPlans.Categories.MealPlans", catalogPlans).
 	Preload("UserPlans.Categories.MealPlans.Meals").
     First(&user, id).Error; err != nil {
     r.log.Error("Ошибка при получении пользователя по ID",
@@ -122,6 +143,50 @@ func (r *gormUserRepository) GetUserSub(id uint) (*models.User, error) {
 	return &user, nil
 }
 
+func (r *gormUserRepository) GetUserPlans(userID uint, withContent bool) ([]models.UserPlan, error) {
+	var plans []models.UserPlan
+
+	query := r.db.Preload("Categories")
+	if withContent {
+		query = query.
+			Preload("Categories.ExercisePlans", catalogPlans).
+			Preload("Categories.ExercisePlans.Exercises").
+			Preload("Categories.MealPlans", catalogPlans).
+			Preload("Categories.MealPlans.Meals")
+	}
+
+	if err := query.Where("user_id = ?", userID).Order("created_at DESC").Find(&plans).Error; err != nil {
+		r.log.Error("failed to list user plans", "user_id", userID, "err", err)
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+func (r *gormUserRepository) GetActiveSubscriptions(userID uint, now time.Time, withContent bool) ([]models.UserSubscription, error) {
+	var subs []models.UserSubscription
+
+	query := r.db.Preload("Subscription.Categories")
+	if withContent {
+		query = query.
+			Preload("Subscription.Categories.ExercisePlans", catalogPlans).
+			Preload("Subscription.Categories.ExercisePlans.Exercises").
+			Preload("Subscription.Categories.MealPlans", catalogPlans).
+			Preload("Subscription.Categories.MealPlans.Meals")
+	}
+
+	err := query.
+		Where("user_id = ? AND status = ? AND end_date > ?", userID, models.SubscriptionStatusActive, now).
+		Order("start_date DESC").
+		Find(&subs).Error
+	if err != nil {
+		r.log.Error("failed to list active subscriptions", "user_id", userID, "err", err)
+		return nil, err
+	}
+
+	return subs, nil
+}
+
 func (r *gormUserRepository) Update(req *models.User) error {
 
 	if req == nil {
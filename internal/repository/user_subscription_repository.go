@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserSubscriptionRepository interface {
+	WithTx(tx *gorm.DB) UserSubscriptionRepository
+	Create(userSub *models.UserSubscription) error
+	GetByID(id uint) (*models.UserSubscription, error)
+	Lock(id uint) (*models.UserSubscription, error)
+	Update(userSub *models.UserSubscription) error
+	// ListDueIDs возвращает активные подписки, у которых закончился оплаченный период
+	ListDueIDs(now time.Time) ([]uint, error)
+	CreateEvent(event *models.SubscriptionEvent) error
+	ListEvents(userSubID uint) ([]models.SubscriptionEvent, error)
+}
+
+type gormUserSubscriptionRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewUserSubscriptionRepository(db *gorm.DB, log *slog.Logger) UserSubscriptionRepository {
+	return &gormUserSubscriptionRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormUserSubscriptionRepository) WithTx(tx *gorm.DB) UserSubscriptionRepository {
+	return &gormUserSubscriptionRepository{
+		db:  tx,
+		log: r.log,
+	}
+}
+
+func (r *gormUserSubscriptionRepository) Create(userSub *models.UserSubscription) error {
+	if userSub == nil {
+		r.log.Error("error in Create function user_subscription_repository.go")
+		return errors.New("user subscription is nil")
+	}
+
+	if err := r.db.Create(userSub).Error; err != nil {
+		r.log.Error("failed to create user subscription", "user_id", userSub.UserID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormUserSubscriptionRepository) GetByID(id uint) (*models.UserSubscription, error) {
+	var userSub models.UserSubscription
+
+	if err := r.db.First(&userSub, id).Error; err != nil {
+		r.log.Error("failed to fetch user subscription", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &userSub, nil
+}
+
+func (r *gormUserSubscriptionRepository) Lock(id uint) (*models.UserSubscription, error) {
+	var userSub models.UserSubscription
+
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&userSub, id).Error; err != nil {
+		r.log.Error("failed to lock user subscription", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &userSub, nil
+}
+
+func (r *gormUserSubscriptionRepository) Update(userSub *models.UserSubscription) error {
+	if userSub == nil {
+		r.log.Error("error in Update function user_subscription_repository.go")
+		return errors.New("user subscription is nil")
+	}
+
+	if err := r.db.Omit("Events").Save(userSub).Error; err != nil {
+		r.log.Error("failed to update user subscription", "id", userSub.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormUserSubscriptionRepository) ListDueIDs(now time.Time) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.Model(&models.UserSubscription{}).
+		Where("status = ? AND end_date <= ?", models.SubscriptionStatusActive, now).
+		Order("end_date ASC").
+		Pluck("id", &ids).Error
+	if err != nil {
+		r.log.Error("failed to list due subscriptions", "err", err)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (r *gormUserSubscriptionRepository) CreateEvent(event *models.SubscriptionEvent) error {
+	if err := r.db.Create(event).Error; err != nil {
+		r.log.Error("failed to create subscription event", "user_subscription_id", event.UserSubscriptionID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormUserSubscriptionRepository) ListEvents(userSubID uint) ([]models.SubscriptionEvent, error) {
+	var events []models.SubscriptionEvent
+
+	if err := r.db.Where("user_subscription_id = ?", userSubID).Order("created_at ASC, id ASC").Find(&events).Error; err != nil {
+		r.log.Error("failed to list subscription events", "user_subscription_id", userSubID, "err", err)
+		return nil, err
+	}
+
+	return events, nil
+}
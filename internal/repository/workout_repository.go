@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type WorkoutRepository interface {
+	CreateSession(session *models.WorkoutSession) error
+	GetSession(id uint) (*models.WorkoutSession, error)
+	UpdateSession(session *models.WorkoutSession) error
+	ListSessions(userID uint, planID *uint) ([]models.WorkoutSession, error)
+	// GetOpenSession возвращает незавершённую тренировку пользователя
+	GetOpenSession(userID uint) (*models.WorkoutSession, error)
+	CreateSet(set *models.WorkoutSet) error
+	CountSets(sessionID, itemID uint) (int64, error)
+	// ListSets возвращает подходы вместе с тренировкой, к которой они относятся
+	ListSets(filter models.WorkoutSetFilter) ([]models.WorkoutSet, error)
+}
+
+type gormWorkoutRepository struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewWorkoutRepository(db *gorm.DB, log *slog.Logger) WorkoutRepository {
+	return &gormWorkoutRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *gormWorkoutRepository) CreateSession(session *models.WorkoutSession) error {
+	if session == nil {
+		r.log.Error("error in Create function workout_repository.go")
+		return errors.New("workout session is nil")
+	}
+
+	if err := r.db.Create(session).Error; err != nil {
+		r.log.Error("failed to create workout session", "user_id", session.UserID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormWorkoutRepository) GetSession(id uint) (*models.WorkoutSession, error) {
+	var session models.WorkoutSession
+
+	err := r.db.Preload("Sets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("exercise_plan_item_id, set_number")
+	}).First(&session, id).Error
+	if err != nil {
+		r.log.Error("failed to fetch workout session", "id", id, "err", err)
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *gormWorkoutRepository) UpdateSession(session *models.WorkoutSession) error {
+	if session == nil {
+		r.log.Error("error in Update function workout_repository.go")
+		return errors.New("workout session is nil")
+	}
+
+	if err := r.db.Omit("Sets").Save(session).Error; err != nil {
+		r.log.Error("failed to update workout session", "id", session.ID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormWorkoutRepository) ListSessions(userID uint, planID *uint) ([]models.WorkoutSession, error) {
+	var sessions []models.WorkoutSession
+
+	query := r.db.Where("user_id = ?", userID)
+	if planID != nil {
+		query = query.Where("exercise_plan_id = ?", *planID)
+	}
+
+	if err := query.Order("started_at DESC").Find(&sessions).Error; err != nil {
+		r.log.Error("failed to list workout sessions", "user_id", userID, "err", err)
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *gormWorkoutRepository) GetOpenSession(userID uint) (*models.WorkoutSession, error) {
+	var session models.WorkoutSession
+
+	if err := r.db.Where("user_id = ? AND finished_at IS NULL", userID).First(&session).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			r.log.Error("failed to fetch open workout session", "user_id", userID, "err", err)
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *gormWorkoutRepository) CreateSet(set *models.WorkoutSet) error {
+	if set == nil {
+		r.log.Error("error in Create function workout_repository.go")
+		return errors.New("workout set is nil")
+	}
+
+	if err := r.db.Create(set).Error; err != nil {
+		r.log.Error("failed to create workout set", "session_id", set.WorkoutSessionID, "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormWorkoutRepository) CountSets(sessionID, itemID uint) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.WorkoutSet{}).
+		Where("workout_session_id = ? AND exercise_plan_item_id = ?", sessionID, itemID).
+		Count(&count).Error
+	if err != nil {
+		r.log.Error("failed to count workout sets", "session_id", sessionID, "err", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *gormWorkoutRepository) ListSets(filter models.WorkoutSetFilter) ([]models.WorkoutSet, error) {
+	var sets []models.WorkoutSet
+
+	query := r.db.
+		Select("workout_sets.*").
+		Joins("JOIN workout_sessions ON workout_sessions.id = workout_sets.workout_session_id AND workout_sessions.deleted_at IS NULL").
+		Where("workout_sessions.user_id = ?", filter.UserID).
+		Preload("WorkoutSession")
+	if filter.ExercisePlanID != nil {
+		query = query.Where("workout_sessions.exercise_plan_id = ?", *filter.ExercisePlanID)
+	}
+	if filter.ExercisePlanItemID != nil {
+		query = query.Where("workout_sets.exercise_plan_item_id = ?", *filter.ExercisePlanItemID)
+	}
+	if filter.From != nil {
+		query = query.Where("workout_sets.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("workout_sets.created_at < ?", *filter.To)
+	}
+
+	if err := query.Order("workout_sets.created_at ASC").Find(&sets).Error; err != nil {
+		r.log.Error("failed to list workout sets", "user_id", filter.UserID, "err", err)
+		return nil, err
+	}
+
+	return sets, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import "healthy_body/internal/models"
+
+type Permission string
+
+const (
+	// создание и удаление категорий и подписок
+	PermManageCatalog Permission = "catalog:manage"
+	// редактирование текстовых полей категорий и подписок
+	PermEditCatalog Permission = "catalog:edit"
+	// установка и изменение цен
+	PermEditPricing Permission = "pricing:edit"
+	// создание и редактирование тренировочных планов и планов питания
+	PermAuthorPlans Permission = "plans:author"
+	// просмотр полного контента планов без покупки
+	PermViewAllPlans Permission = "plans:view_all"
+	// просмотр и управление чужими профилями и ролями
+	PermManageUsers Permission = "users:manage"
+	// ручная корректировка баланса пользователя
+	PermAdjustBalances Permission = "balances:adjust"
+	// рассмотрение заявок на возврат
+	PermManageRefunds Permission = "refunds:manage"
+	// создание и управление промокодами
+	PermManagePromos Permission = "promos:manage"
+)
+
+type AccessPolicy interface {
+	Can(role string, perm Permission) bool
+}
+
+type rolePolicy struct {
+	grants map[string]map[Permission]bool
+}
+
+func NewAccessPolicy() AccessPolicy {
+	return &rolePolicy{
+		grants: map[string]map[Permission]bool{
+			models.RoleClient: {},
+			models.RoleTrainer: {
+				PermEditCatalog:  true,
+				PermAuthorPlans:  true,
+				PermViewAllPlans: true,
+			},
+			models.RoleAdmin: {
+				PermManageCatalog:  true,
+				PermEditCatalog:    true,
+				PermEditPricing:    true,
+				PermAuthorPlans:    true,
+				PermViewAllPlans:   true,
+				PermManageUsers:    true,
+				PermAdjustBalances: true,
+				PermManageRefunds:  true,
+				PermManagePromos:   true,
+			},
+		},
+	}
+}
+
+func (p *rolePolicy) Can(role string, perm Permission) bool {
+	return p.grants[role][perm]
+}
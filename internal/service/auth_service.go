@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidCredentials = errors.New("неверный email или пароль")
+
+type AuthService interface {
+	Register(req models.CreateUserRequest) (*models.User, *models.TokenPair, error)
+	Login(req models.LoginRequest) (*models.TokenPair, error)
+	Refresh(refreshToken string) (*models.TokenPair, error)
+	Logout(refreshToken string) error
+	Authenticate(accessToken string) (*models.User, error)
+}
+
+type authService struct {
+	users      UserService
+	userRepo   repository.UserRepository
+	tokens     TokenManager
+	refresh    repository.RefreshTokenRepository
+	adminEmail string
+	log        *slog.Logger
+}
+
+// adminEmail — email, который при регистрации сразу получает роль администратора;
+// нужен, чтобы в пустой базе появился первый администратор
+func NewAuthService(
+	users UserService,
+	userRepo repository.UserRepository,
+	tokens TokenManager,
+	refresh repository.RefreshTokenRepository,
+	adminEmail string,
+	log *slog.Logger,
+) AuthService {
+	return &authService{
+		users:      users,
+		userRepo:   userRepo,
+		tokens:     tokens,
+		refresh:    refresh,
+		adminEmail: adminEmail,
+		log:        log,
+	}
+}
+
+func (s *authService) Register(req models.CreateUserRequest) (*models.User, *models.TokenPair, error) {
+	user, err := s.users.CreateUser(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.adminEmail != "" && strings.EqualFold(user.Email, s.adminEmail) {
+		if user, err = s.users.SetRole(user.ID, models.RoleAdmin); err != nil {
+			return nil, nil, err
+		}
+		s.log.Info("Первый администратор зарегистрирован", "id", user.ID)
+	}
+
+	pair, err := s.issueTokens(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.log.Info("Пользователь зарегистрирован", "id", user.ID)
+	return user, pair, nil
+}
+
+func (s *authService) Login(req models.LoginRequest) (*models.TokenPair, error) {
+	user, err := s.userRepo.GetUserByEmail(req.Email)
+	if err != nil {
+		s.log.Warn("Попытка входа с неизвестным email")
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.PasswordHash == "" {
+		s.log.Warn("У пользователя не задан пароль", "id", user.ID)
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		s.log.Warn("Неверный пароль", "id", user.ID)
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.issueTokens(user.ID)
+}
+
+func (s *authService) Refresh(refreshToken string) (*models.TokenPair, error) {
+	claims, err := s.tokens.Parse(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.refresh.GetByTokenID(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, s.reuse(stored.UserID)
+	}
+
+	// параллельный запрос с тем же токеном мог отозвать его после чтения выше;
+	// новую пару выдаёт только тот, чей отзыв действительно сработал
+	revoked, err := s.refresh.Revoke(claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при отзыве refresh-токена: %w", err)
+	}
+	if !revoked {
+		return nil, s.reuse(stored.UserID)
+	}
+
+	return s.issueTokens(stored.UserID)
+}
+
+// reuse отзывает все сессии пользователя при повторном использовании refresh-токена
+func (s *authService) reuse(userID uint) error {
+	s.log.Warn("Повторное использование refresh-токена", "user_id", userID)
+	if err := s.refresh.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	return ErrInvalidToken
+}
+
+func (s *authService) Logout(refreshToken string) error {
+	claims, err := s.tokens.Parse(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.refresh.Revoke(claims.ID); err != nil {
+		return fmt.Errorf("ошибка при выходе: %w", err)
+	}
+
+	return nil
+}
+
+func (s *authService) Authenticate(accessToken string) (*models.User, error) {
+	claims, err := s.tokens.Parse(accessToken, TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return user, nil
+}
+
+func (s *authService) issueTokens(userID uint) (*models.TokenPair, error) {
+	access, err := s.tokens.NewAccessToken(userID)
+	if err != nil {
+		s.log.Error("Ошибка при создании access-токена", "error", err.Error())
+		return nil, fmt.Errorf("ошибка при создании токена: %w", err)
+	}
+
+	refresh, claims, err := s.tokens.NewRefreshToken(userID)
+	if err != nil {
+		s.log.Error("Ошибка при создании refresh-токена", "error", err.Error())
+		return nil, fmt.Errorf("ошибка при создании токена: %w", err)
+	}
+
+	if err := s.refresh.Create(&models.RefreshToken{
+		UserID:    userID,
+		TokenID:   claims.ID,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении токена: %w", err)
+	}
+
+	return &models.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.tokens.AccessTTL().Seconds()),
+	}, nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeRefreshTokenRepository хранит токены в памяти; stale имитирует чтение,
+// сделанное до того, как параллельный запрос отозвал токен
+type fakeRefreshTokenRepository struct {
+	repository.RefreshTokenRepository
+	tokens     map[string]*models.RefreshToken
+	stale      bool
+	revokedAll []uint
+}
+
+func (r *fakeRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	r.tokens[token.TokenID] = token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) GetByTokenID(tokenID string) (*models.RefreshToken, error) {
+	token, ok := r.tokens[tokenID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *token
+	if r.stale {
+		copied.RevokedAt = nil
+	}
+	return &copied, nil
+}
+
+func (r *fakeRefreshTokenRepository) Revoke(tokenID string) (bool, error) {
+	token, ok := r.tokens[tokenID]
+	if !ok || token.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.RevokedAt = &now
+	return true, nil
+}
+
+func (r *fakeRefreshTokenRepository) RevokeAllForUser(userID uint) error {
+	r.revokedAll = append(r.revokedAll, userID)
+	return nil
+}
+
+func TestRefreshRejectsTokenRevokedConcurrently(t *testing.T) {
+	const userID = uint(7)
+	tokens := NewTokenManager("secret", time.Minute, time.Hour)
+
+	refreshToken, claims, err := tokens.NewRefreshToken(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		stale   bool
+		wantErr error
+	}{
+		{name: "token already revoked", wantErr: ErrInvalidToken},
+		{name: "revoked after the read", stale: true, wantErr: ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRefreshTokenRepository{tokens: map[string]*models.RefreshToken{
+				claims.ID: {UserID: userID, TokenID: claims.ID, ExpiresAt: time.Now().Add(time.Hour)},
+			}}
+			auth := &authService{tokens: tokens, refresh: repo, log: testLogger()}
+
+			// первый запрос получает новую пару и отзывает токен
+			if _, err := auth.Refresh(refreshToken); err != nil {
+				t.Fatalf("first Refresh() error = %v", err)
+			}
+			issued := len(repo.tokens)
+
+			repo.stale = tt.stale
+			pair, err := auth.Refresh(refreshToken)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("second Refresh() error = %v, want %v", err, tt.wantErr)
+			}
+			if pair != nil || len(repo.tokens) != issued {
+				t.Errorf("повторный запрос выдал новую пару токенов")
+			}
+			if len(repo.revokedAll) != 1 || repo.revokedAll[0] != userID {
+				t.Errorf("RevokeAllForUser = %v, ожидался отзыв сессий пользователя %d", repo.revokedAll, userID)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/calculator"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultTrendWindowDays = 7
+	maxTrendWindowDays     = 90
+	// weeklyChangePeriod — за какой период оценивается темп изменения веса
+	weeklyChangePeriod = 28 * 24 * time.Hour
+)
+
+var (
+	ErrMeasurementNotFound = errors.New("замер не найден")
+	ErrBodyGoalNotFound    = errors.New("целевой вес не задан")
+)
+
+type BodyMetricsService interface {
+	AddMeasurement(userID uint, req models.CreateBodyMeasurementRequest) (*models.BodyMeasurement, error)
+	ListMeasurements(userID uint, from, to *time.Time) ([]models.BodyMeasurement, error)
+	DeleteMeasurement(userID, id uint) error
+	// Trend возвращает вес со скользящим средним за window дней, темп изменения и прогресс к цели
+	Trend(userID uint, window int) (*models.BodyTrend, error)
+	SetGoal(userID uint, req models.SetBodyGoalRequest) (*models.BodyGoalProgress, error)
+	GetGoal(userID uint) (*models.BodyGoalProgress, error)
+	DeleteGoal(userID uint) error
+}
+
+type bodyMetricsService struct {
+	repo repository.BodyRepository
+	log  *slog.Logger
+}
+
+func NewBodyMetricsService(repo repository.BodyRepository, log *slog.Logger) BodyMetricsService {
+	return &bodyMetricsService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (s *bodyMetricsService) AddMeasurement(userID uint, req models.CreateBodyMeasurementRequest) (*models.BodyMeasurement, error) {
+	if req.WeightKg <= 0 || req.WeightKg > 500 {
+		return nil, errors.New("вес должен быть от 0 до 500 кг")
+	}
+	if req.WaistCm != nil && (*req.WaistCm <= 0 || *req.WaistCm > 300) {
+		return nil, errors.New("обхват талии должен быть от 0 до 300 см")
+	}
+	if req.BodyFatPercent != nil && (*req.BodyFatPercent <= 0 || *req.BodyFatPercent >= 100) {
+		return nil, errors.New("процент жира должен быть от 0 до 100")
+	}
+
+	measuredAt := time.Now()
+	if req.MeasuredAt != nil {
+		if req.MeasuredAt.After(measuredAt) {
+			return nil, errors.New("дата замера не может быть в будущем")
+		}
+		measuredAt = *req.MeasuredAt
+	}
+
+	var height float64
+	if req.HeightCm != nil {
+		height = *req.HeightCm
+	} else {
+		latest, err := s.repo.LatestMeasurement(userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("укажите рост для первого замера")
+			}
+			return nil, err
+		}
+		height = latest.HeightCm
+	}
+	if height < 50 || height > 280 {
+		return nil, errors.New("рост должен быть от 50 до 280 см")
+	}
+
+	category, bmi := calculator.BmiCalc(req.WeightKg, height)
+
+	measurement := &models.BodyMeasurement{
+		UserID:         userID,
+		MeasuredAt:     measuredAt,
+		WeightKg:       req.WeightKg,
+		HeightCm:       height,
+		WaistCm:        req.WaistCm,
+		BodyFatPercent: req.BodyFatPercent,
+		Bmi:            bmi,
+		BmiCategory:    category,
+	}
+
+	if err := s.repo.CreateMeasurement(measurement); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении замера: %w", err)
+	}
+
+	s.log.Info("Замер сохранён",
+		"id", measurement.ID,
+		"user_id", userID,
+		"bmi", bmi)
+
+	return measurement, nil
+}
+
+func (s *bodyMetricsService) ListMeasurements(userID uint, from, to *time.Time) ([]models.BodyMeasurement, error) {
+	return s.repo.ListMeasurements(userID, from, to)
+}
+
+func (s *bodyMetricsService) DeleteMeasurement(userID, id uint) error {
+	measurement, err := s.repo.GetMeasurement(id)
+	if err != nil || measurement.UserID != userID {
+		return ErrMeasurementNotFound
+	}
+
+	return s.repo.DeleteMeasurement(id)
+}
+
+func (s *bodyMetricsService) Trend(userID uint, window int) (*models.BodyTrend, error) {
+	if window <= 0 {
+		window = DefaultTrendWindowDays
+	}
+	if window > maxTrendWindowDays {
+		return nil, fmt.Errorf("окно усреднения не должно превышать %d дней", maxTrendWindowDays)
+	}
+
+	measurements, err := s.repo.ListMeasurements(userID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	trend := &models.BodyTrend{
+		WindowDays: window,
+		Points:     make([]models.BodyTrendPoint, 0, len(measurements)),
+	}
+
+	points := make([]calculator.WeightPoint, 0, len(measurements))
+	for _, measurement := range measurements {
+		points = append(points, calculator.WeightPoint{Date: measurement.MeasuredAt, WeightKg: measurement.WeightKg})
+	}
+
+	averages := calculator.MovingAverage(points, window)
+	for i, measurement := range measurements {
+		trend.Points = append(trend.Points, models.BodyTrendPoint{
+			MeasuredAt:    measurement.MeasuredAt,
+			WeightKg:      measurement.WeightKg,
+			MovingAverage: averages[i],
+			Bmi:           measurement.Bmi,
+		})
+	}
+
+	if len(points) == 0 {
+		return trend, nil
+	}
+
+	last := points[len(points)-1]
+	current := averages[len(averages)-1]
+	trend.CurrentKg = &current
+
+	// темп считаем только по последним неделям, чтобы старые замеры не сглаживали его
+	recent := points
+	for len(recent) > 0 && last.Date.Sub(recent[0].Date) > weeklyChangePeriod {
+		recent = recent[1:]
+	}
+	if change, ok := calculator.WeeklyChange(recent); ok {
+		trend.WeeklyChangeKg = &change
+	}
+
+	goal, err := s.repo.GetGoal(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return trend, nil
+		}
+		return nil, err
+	}
+
+	trend.Goal = goalProgress(goal, last.Date, current, trend.WeeklyChangeKg)
+	return trend, nil
+}
+
+func (s *bodyMetricsService) SetGoal(userID uint, req models.SetBodyGoalRequest) (*models.BodyGoalProgress, error) {
+	if req.TargetWeightKg <= 0 || req.TargetWeightKg > 500 {
+		return nil, errors.New("целевой вес должен быть от 0 до 500 кг")
+	}
+
+	latest, err := s.repo.LatestMeasurement(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("добавьте хотя бы один замер перед тем, как задать цель")
+		}
+		return nil, err
+	}
+
+	goal, err := s.repo.GetGoal(userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		goal = &models.BodyGoal{UserID: userID}
+	}
+
+	goal.StartWeightKg = latest.WeightKg
+	goal.TargetWeightKg = req.TargetWeightKg
+
+	if err := s.repo.SaveGoal(goal); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении цели: %w", err)
+	}
+
+	s.log.Info("Целевой вес задан",
+		"user_id", userID,
+		"target", goal.TargetWeightKg)
+
+	return s.GetGoal(userID)
+}
+
+func (s *bodyMetricsService) GetGoal(userID uint) (*models.BodyGoalProgress, error) {
+	trend, err := s.Trend(userID, DefaultTrendWindowDays)
+	if err != nil {
+		return nil, err
+	}
+	if trend.Goal == nil {
+		return nil, ErrBodyGoalNotFound
+	}
+
+	return trend.Goal, nil
+}
+
+func (s *bodyMetricsService) DeleteGoal(userID uint) error {
+	return s.repo.DeleteGoal(userID)
+}
+
+func goalProgress(goal *models.BodyGoal, lastDate time.Time, currentKg float64, weeklyChangeKg *float64) *models.BodyGoalProgress {
+	progress := &models.BodyGoalProgress{
+		TargetWeightKg: goal.TargetWeightKg,
+		StartWeightKg:  goal.StartWeightKg,
+		DistanceKg:     calculator.Round(math.Abs(goal.TargetWeightKg - currentKg)),
+	}
+
+	if total := goal.StartWeightKg - goal.TargetWeightKg; total != 0 {
+		percent := (goal.StartWeightKg - currentKg) / total * 100
+		progress.Percent = calculator.Round(math.Min(math.Max(percent, 0), 100))
+	} else {
+		progress.Percent = 100
+	}
+
+	if weeklyChangeKg != nil {
+		if date, ok := calculator.EstimateGoalDate(lastDate, currentKg, goal.TargetWeightKg, *weeklyChangeKg); ok {
+			progress.EstimatedDate = &date
+		}
+	}
+
+	return progress
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"healthy_body/internal/calculator"
 	"healthy_body/internal/models"
 	"healthy_body/internal/repository"
 	"log/slog"
@@ -42,10 +43,20 @@ func (c *categoryServices) CreateCategory(req models.CreateCategoryRequest) (*mo
 		return  nil , errors.New("empty description by your category")
 	}
 
+	if req.Goal != "" && !calculator.IsValidGoal(calculator.Goal(req.Goal)) {
+		return nil, calculator.ErrInvalidGoal
+	}
+
+	if err := c.validateTrainer(req.TrainerID); err != nil {
+		return nil, err
+	}
+
 	 category := &models.Categories{
 		Name: req.Name,
 		Description: req.Description,
 		Price: req.Price,
+		Goal: req.Goal,
+		TrainerID: req.TrainerID,
 	 }
 
 	  if err:= c.category.Create(category); err != nil {
@@ -95,6 +106,16 @@ func (c *categoryServices) UpdateCategory(id uint, req models.UpdateCategoryRequ
 		return &models.Categories{} , err
 	}
 
+	if req.Goal != nil && *req.Goal != "" && !calculator.IsValidGoal(calculator.Goal(*req.Goal)) {
+		return nil, calculator.ErrInvalidGoal
+	}
+
+	if req.TrainerID != nil && *req.TrainerID != 0 {
+		if err := c.validateTrainer(req.TrainerID); err != nil {
+			return nil, err
+		}
+	}
+
 	c.Up(category, req)
 
 	if err := c.category.Update(category); err != nil {
@@ -125,4 +146,30 @@ func(c *categoryServices) Up(cat *models.Categories, req models.UpdateCategoryRe
 	if req.Price != nil {
 		cat.Price = *req.Price
 	}
+	if req.Goal != nil {
+		cat.Goal = *req.Goal
+	}
+	if req.TrainerID != nil {
+		cat.TrainerID = req.TrainerID
+		if *req.TrainerID == 0 {
+			cat.TrainerID = nil
+		}
+	}
+}
+
+// validateTrainer проверяет, что владелец категории — тренер с профилем
+func (c *categoryServices) validateTrainer(trainerID *uint) error {
+	if trainerID == nil {
+		return nil
+	}
+
+	exists, err := c.category.TrainerExists(*trainerID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("trainer profile not found")
+	}
+
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,436 @@
+package service
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCoachingNotFound     = errors.New("связь с тренером не найдена")
+	ErrCoachingExists       = errors.New("заявка этому тренеру уже отправлена или связь уже активна")
+	ErrCoachingNotActive    = errors.New("связь с тренером не активна")
+	ErrWorkoutCommentDenied = errors.New("комментировать тренировку могут только её владелец и его тренер")
+)
+
+// максимальная длина комментария к тренировке в символах
+const maxWorkoutComment = 2000
+
+type CoachingService interface {
+	// Request отправляет тренеру заявку клиента
+	Request(clientID, trainerID uint, req models.CoachingRequest) (*models.TrainerClient, error)
+	Accept(trainerID, linkID uint) (*models.TrainerClient, error)
+	Decline(trainerID, linkID uint) (*models.TrainerClient, error)
+	// End завершает связь по инициативе любой из сторон; клиент так же отзывает заявку.
+	// Личные планы после завершения остаются у клиента
+	End(userID, linkID uint) (*models.TrainerClient, error)
+	// Clients возвращает связи тренера; пустой status — все связи
+	Clients(trainerID uint, status string) ([]models.TrainerClient, error)
+	Trainers(clientID uint) ([]models.TrainerClient, error)
+
+	// AssignExercisePlan копирует план вместе с пунктами в личный план клиента активной связи.
+	// Тренер копирует только планы своих категорий в последней опубликованной версии и свои личные планы
+	AssignExercisePlan(trainerID, linkID uint, req models.AssignPlanRequest) (*models.ExercisePlan, error)
+	AssignMealPlan(trainerID, linkID uint, req models.AssignPlanRequest) (*models.MealPlan, error)
+	// AssignedPlans возвращает личные планы, составленные в рамках связи; видны тренеру и клиенту
+	AssignedPlans(userID, linkID uint) (*models.AssignedPlans, error)
+	// ClientWorkouts возвращает тренировки клиента его тренеру, пока связь активна
+	ClientWorkouts(trainerID, linkID uint) ([]models.WorkoutSession, error)
+
+	Comment(userID, sessionID uint, req models.CreateWorkoutCommentRequest) (*models.WorkoutComment, error)
+	Comments(userID, sessionID uint) ([]models.WorkoutComment, error)
+}
+
+type coachingService struct {
+	repo          repository.CoachingRepository
+	trainers      repository.TrainerRepository
+	exercisePlans repository.ExercisePlanRepo
+	mealPlans     repository.MealPlanRepository
+	workouts      repository.WorkoutRepository
+	versions      PlanVersionService
+	log           *slog.Logger
+}
+
+func NewCoachingService(
+	repo repository.CoachingRepository,
+	trainers repository.TrainerRepository,
+	exercisePlans repository.ExercisePlanRepo,
+	mealPlans repository.MealPlanRepository,
+	// workouts нужны тренеру, чтобы видеть и комментировать тренировки клиентов
+	workouts repository.WorkoutRepository,
+	// versions отдаёт опубликованную версию копируемого плана вместо черновика
+	versions PlanVersionService,
+	log *slog.Logger,
+) CoachingService {
+	return &coachingService{
+		repo:          repo,
+		trainers:      trainers,
+		exercisePlans: exercisePlans,
+		mealPlans:     mealPlans,
+		workouts:      workouts,
+		versions:      versions,
+		log:           log,
+	}
+}
+
+func (s *coachingService) Request(clientID, trainerID uint, req models.CoachingRequest) (*models.TrainerClient, error) {
+	if clientID == trainerID {
+		return nil, errors.New("нельзя стать клиентом самому себе")
+	}
+
+	if _, err := s.trainers.GetByUserID(trainerID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTrainerNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.Current(trainerID, clientID); err == nil {
+		return nil, ErrCoachingExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	link := &models.TrainerClient{
+		TrainerID: trainerID,
+		ClientID:  clientID,
+		Status:    models.CoachingStatusPending,
+		Message:   strings.TrimSpace(req.Message),
+	}
+	if err := s.repo.Create(link); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Клиент отправил заявку тренеру", "id", link.ID, "trainer_id", trainerID, "client_id", clientID)
+	return link, nil
+}
+
+func (s *coachingService) Accept(trainerID, linkID uint) (*models.TrainerClient, error) {
+	return s.respond(trainerID, linkID, models.CoachingStatusActive)
+}
+
+func (s *coachingService) Decline(trainerID, linkID uint) (*models.TrainerClient, error) {
+	return s.respond(trainerID, linkID, models.CoachingStatusDeclined)
+}
+
+func (s *coachingService) respond(trainerID, linkID uint, status string) (*models.TrainerClient, error) {
+	link, err := s.trainerLink(trainerID, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link.Status != models.CoachingStatusPending {
+		return nil, errors.New("заявка уже рассмотрена")
+	}
+
+	now := time.Now()
+	link.Status = status
+	link.RespondedAt = &now
+	if err := s.repo.Update(link); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Тренер рассмотрел заявку клиента", "id", link.ID, "trainer_id", trainerID, "status", status)
+	return link, nil
+}
+
+func (s *coachingService) End(userID, linkID uint) (*models.TrainerClient, error) {
+	link, err := s.link(linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link.TrainerID != userID && link.ClientID != userID {
+		return nil, ErrCoachingNotFound
+	}
+	if link.Status != models.CoachingStatusPending && link.Status != models.CoachingStatusActive {
+		return nil, ErrCoachingNotActive
+	}
+
+	now := time.Now()
+	link.Status = models.CoachingStatusEnded
+	link.EndedAt = &now
+	if err := s.repo.Update(link); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Связь тренера и клиента завершена", "id", link.ID, "user_id", userID)
+	return link, nil
+}
+
+func (s *coachingService) Clients(trainerID uint, status string) ([]models.TrainerClient, error) {
+	switch status {
+	case "", models.CoachingStatusPending, models.CoachingStatusActive, models.CoachingStatusDeclined, models.CoachingStatusEnded:
+	default:
+		return nil, errors.New("некорректный статус связи")
+	}
+
+	return s.repo.ListByTrainer(trainerID, status)
+}
+
+func (s *coachingService) Trainers(clientID uint) ([]models.TrainerClient, error) {
+	return s.repo.ListByClient(clientID)
+}
+
+func (s *coachingService) AssignExercisePlan(trainerID, linkID uint, req models.AssignPlanRequest) (*models.ExercisePlan, error) {
+	link, err := s.activeLink(trainerID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := s.exercisePlans.GetByIDExercisePlan(req.PlanID)
+	if err != nil {
+		return nil, ErrPlanNotFound
+	}
+	if err := s.canCopy(trainerID, &draft.CategoriesID, draft.ClientID, draft.TrainerID); err != nil {
+		return nil, err
+	}
+	source, err := s.versions.ResolveExercisePlan(nil, draft)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.ExercisePlan{
+		Name:          copyText(req.Name, source.Name),
+		Description:   copyText(req.Description, source.Description),
+		DurationWeeks: source.DurationWeeks,
+		CategoriesID:  source.CategoriesID,
+		ClientID:      &link.ClientID,
+		TrainerID:     &trainerID,
+		SourcePlanID:  &draft.ID,
+		Exercises:     make([]models.ExercisePlanItem, 0, len(source.Exercises)),
+	}
+	for _, item := range source.Exercises {
+		item.ID = 0
+		item.ExercisePlanID = 0
+		item.ExercisePlan = nil
+		item.Exercise = nil
+		plan.Exercises = append(plan.Exercises, item)
+	}
+
+	if err := s.repo.CreateExercisePlan(plan); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Тренер составил клиенту личный тренировочный план",
+		"id", plan.ID,
+		"source_plan_id", draft.ID,
+		"source_version", source.Version,
+		"trainer_id", trainerID,
+		"client_id", link.ClientID)
+
+	return plan, nil
+}
+
+func (s *coachingService) AssignMealPlan(trainerID, linkID uint, req models.AssignPlanRequest) (*models.MealPlan, error) {
+	link, err := s.activeLink(trainerID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := s.mealPlans.GetMealPlanByID(req.PlanID)
+	if err != nil {
+		return nil, ErrPlanNotFound
+	}
+	if err := s.canCopy(trainerID, draft.CategoriesID, draft.ClientID, draft.TrainerID); err != nil {
+		return nil, err
+	}
+	source, err := s.versions.ResolveMealPlan(nil, draft)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.MealPlan{
+		Name:         copyText(req.Name, source.Name),
+		Description:  copyText(req.Description, source.Description),
+		CategoriesID: source.CategoriesID,
+		TotalDays:    source.TotalDays,
+		ClientID:     &link.ClientID,
+		TrainerID:    &trainerID,
+		SourcePlanID: &draft.ID,
+		Meals:        make([]models.MealPlanItem, 0, len(source.Meals)),
+	}
+	for _, meal := range source.Meals {
+		meal.Model = gorm.Model{}
+		meal.MealPlanId = 0
+		meal.MealPlan = nil
+		meal.Recipe = nil
+		plan.Meals = append(plan.Meals, meal)
+	}
+
+	if err := s.repo.CreateMealPlan(plan); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Тренер составил клиенту личный план питания",
+		"id", plan.ID,
+		"source_plan_id", draft.ID,
+		"source_version", source.Version,
+		"trainer_id", trainerID,
+		"client_id", link.ClientID)
+
+	fillDietInfo(plan)
+	return plan, nil
+}
+
+func (s *coachingService) AssignedPlans(userID, linkID uint) (*models.AssignedPlans, error) {
+	link, err := s.link(linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link.TrainerID != userID && link.ClientID != userID {
+		return nil, ErrCoachingNotFound
+	}
+
+	exercisePlans, err := s.repo.AssignedExercisePlans(link.TrainerID, link.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	mealPlans, err := s.repo.AssignedMealPlans(link.TrainerID, link.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mealPlans {
+		fillDietInfo(&mealPlans[i])
+	}
+
+	return &models.AssignedPlans{ExercisePlans: exercisePlans, MealPlans: mealPlans}, nil
+}
+
+func (s *coachingService) ClientWorkouts(trainerID, linkID uint) ([]models.WorkoutSession, error) {
+	link, err := s.activeLink(trainerID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.workouts.ListSessions(link.ClientID, nil)
+}
+
+func (s *coachingService) Comment(userID, sessionID uint, req models.CreateWorkoutCommentRequest) (*models.WorkoutComment, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, errors.New("комментарий не может быть пустым")
+	}
+	if utf8.RuneCountInString(body) > maxWorkoutComment {
+		return nil, errors.New("комментарий слишком длинный")
+	}
+
+	session, err := s.commentable(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &models.WorkoutComment{
+		WorkoutSessionID: session.ID,
+		AuthorID:         userID,
+		Body:             body,
+	}
+	if err := s.repo.CreateComment(comment); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Добавлен комментарий к тренировке", "id", comment.ID, "session_id", session.ID, "author_id", userID)
+	return comment, nil
+}
+
+func (s *coachingService) Comments(userID, sessionID uint) ([]models.WorkoutComment, error) {
+	session, err := s.commentable(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListComments(session.ID)
+}
+
+// commentable возвращает тренировку, если userID — её владелец или тренер владельца с активной связью
+func (s *coachingService) commentable(userID, sessionID uint) (*models.WorkoutSession, error) {
+	session, err := s.workouts.GetSession(sessionID)
+	if err != nil {
+		return nil, ErrWorkoutNotFound
+	}
+	if session.UserID == userID {
+		return session, nil
+	}
+
+	link, err := s.repo.Current(userID, session.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && link.Status != models.CoachingStatusActive) {
+		return nil, ErrWorkoutCommentDenied
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *coachingService) link(linkID uint) (*models.TrainerClient, error) {
+	link, err := s.repo.Get(linkID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCoachingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// trainerLink возвращает связь тренера; чужие связи выглядят как несуществующие
+func (s *coachingService) trainerLink(trainerID, linkID uint) (*models.TrainerClient, error) {
+	link, err := s.link(linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link.TrainerID != trainerID {
+		return nil, ErrCoachingNotFound
+	}
+
+	return link, nil
+}
+
+func (s *coachingService) activeLink(trainerID, linkID uint) (*models.TrainerClient, error) {
+	link, err := s.trainerLink(trainerID, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link.Status != models.CoachingStatusActive {
+		return nil, ErrCoachingNotActive
+	}
+
+	return link, nil
+}
+
+// canCopy разрешает тренеру копировать только планы категорий, которыми он владеет,
+// а из личных планов — только составленные им самим. Чужие планы выглядят как несуществующие,
+// чтобы не раскрывать черновики и платный контент других тренеров
+func (s *coachingService) canCopy(trainerID uint, categoryID, clientID, planTrainerID *uint) error {
+	if clientID != nil && (planTrainerID == nil || *planTrainerID != trainerID) {
+		return ErrPlanNotFound
+	}
+	if categoryID == nil {
+		return ErrPlanNotFound
+	}
+
+	owner, err := s.trainers.CategoryTrainer(*categoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPlanNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if owner.UserID != trainerID {
+		return ErrPlanNotFound
+	}
+
+	return nil
+}
+
+func copyText(value, fallback string) string {
+	if value = strings.TrimSpace(value); value != "" {
+		return value
+	}
+	return fallback
+}
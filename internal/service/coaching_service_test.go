@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeCoachingRepository знает одну связь и запоминает созданные личные планы
+type fakeCoachingRepository struct {
+	repository.CoachingRepository
+	link    *models.TrainerClient
+	created []*models.ExercisePlan
+}
+
+func (r *fakeCoachingRepository) Get(id uint) (*models.TrainerClient, error) {
+	if r.link == nil || r.link.ID != id {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.link, nil
+}
+
+func (r *fakeCoachingRepository) CreateExercisePlan(plan *models.ExercisePlan) error {
+	plan.ID = uint(100 + len(r.created))
+	r.created = append(r.created, plan)
+	return nil
+}
+
+// fakeExercisePlanRepository отдаёт черновики планов по ID
+type fakeExercisePlanRepository struct {
+	repository.ExercisePlanRepo
+	plans map[uint]*models.ExercisePlan
+}
+
+func (r *fakeExercisePlanRepository) GetByIDExercisePlan(id uint) (*models.ExercisePlan, error) {
+	plan, ok := r.plans[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *plan
+	return &copied, nil
+}
+
+func TestAssignExercisePlanCopiesOwnPublishedPlans(t *testing.T) {
+	const (
+		trainerID = uint(10)
+		otherID   = uint(20)
+		clientID  = uint(30)
+		linkID    = uint(1)
+	)
+	client := clientID
+	trainer := trainerID
+	other := otherID
+
+	draftItem := models.ExercisePlanItem{ID: 1, Name: "Черновик", Sets: 5}
+	publishedItem := models.ExercisePlanItem{ID: 1, Name: "Присед", Sets: 3}
+	newPlan := func(id, categoryID uint, clientID, planTrainerID *uint, items ...models.ExercisePlanItem) *models.ExercisePlan {
+		plan := &models.ExercisePlan{CategoriesID: categoryID, ClientID: clientID, TrainerID: planTrainerID, Exercises: items}
+		plan.ID = id
+		return plan
+	}
+	plans := map[uint]*models.ExercisePlan{
+		// опубликованный план своей категории с неопубликованными правками
+		1: newPlan(1, 1, nil, nil, draftItem),
+		// неопубликованный план своей категории
+		2: newPlan(2, 1, nil, nil),
+		// платный план чужой категории
+		3: newPlan(3, 2, nil, nil, publishedItem),
+		// план категории без тренера
+		4: newPlan(4, 3, nil, nil),
+		// свой личный план другого клиента
+		5: newPlan(5, 1, &client, &trainer, draftItem),
+		// личный план другого тренера
+		6: newPlan(6, 1, &client, &other),
+	}
+
+	published := newPlan(1, 1, nil, nil, publishedItem)
+	january := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	versions := &planVersionService{
+		repo: &fakePlanVersionRepository{versions: map[string][]models.PlanVersion{
+			models.PlanKindExercise: {
+				snapshotVersion(t, models.PlanKindExercise, 1, 1, january, published),
+				snapshotVersion(t, models.PlanKindExercise, 3, 1, january, plans[3]),
+			},
+		}},
+		policy: NewAccessPolicy(),
+		log:    testLogger(),
+	}
+
+	tests := []struct {
+		name      string
+		planID    uint
+		wantErr   error
+		wantItems string
+	}{
+		{name: "own category copies published version", planID: 1, wantItems: "Присед"},
+		{name: "own category, not published", planID: 2, wantErr: ErrPlanNotPublished},
+		{name: "another trainer's category", planID: 3, wantErr: ErrPlanNotFound},
+		{name: "category without trainer", planID: 4, wantErr: ErrPlanNotFound},
+		{name: "own private plan copies draft", planID: 5, wantItems: "Черновик"},
+		{name: "another trainer's private plan", planID: 6, wantErr: ErrPlanNotFound},
+		{name: "unknown plan", planID: 7, wantErr: ErrPlanNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCoachingRepository{link: &models.TrainerClient{TrainerID: trainerID, ClientID: clientID, Status: models.CoachingStatusActive}}
+			repo.link.ID = linkID
+			coaching := &coachingService{
+				repo: repo,
+				trainers: &fakeTrainerRepository{owners: map[uint]*models.TrainerProfile{
+					1: {UserID: trainerID},
+					2: {UserID: otherID},
+				}},
+				exercisePlans: &fakeExercisePlanRepository{plans: plans},
+				versions:      versions,
+				log:           testLogger(),
+			}
+
+			plan, err := coaching.AssignExercisePlan(trainerID, linkID, models.AssignPlanRequest{PlanID: tt.planID})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AssignExercisePlan() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.created) != 0 {
+					t.Errorf("создано %d планов, ожидалось 0", len(repo.created))
+				}
+				return
+			}
+
+			if len(plan.Exercises) != 1 || plan.Exercises[0].Name != tt.wantItems {
+				t.Fatalf("скопированы упражнения %+v, ожидалось %q", plan.Exercises, tt.wantItems)
+			}
+			if plan.Exercises[0].ID != 0 {
+				t.Errorf("у копии упражнения остался ID %d", plan.Exercises[0].ID)
+			}
+			if plan.SourcePlanID == nil || *plan.SourcePlanID != tt.planID {
+				t.Errorf("SourcePlanID = %v, ожидалось %d", plan.SourcePlanID, tt.planID)
+			}
+			if plan.ClientID == nil || *plan.ClientID != clientID {
+				t.Errorf("ClientID = %v, ожидалось %d", plan.ClientID, clientID)
+			}
+		})
+	}
+}
@@ -50,6 +50,149 @@ func (s *EmailNotificationService) SendPaymentSuccess(user *models.User, categor
 		),
 	)
 
+	if err := s.send(msg); err != nil {
+		return err
+	}
+
+	s.logger.Info("email уведомление отправлено",
+		"to", user.Email,
+		"category", category.Name,
+	)
+
+	return nil
+}
+
+func (s *EmailNotificationService) SendRefundDecision(user *models.User, category *models.Categories, refund *models.RefundRequest) error {
+	if user.Email == "" {
+		s.logger.Warn("у пользователя нет email", "user_id", user.ID)
+		return nil
+	}
+
+	subject := "Возврат одобрен"
+	body := fmt.Sprintf(
+		"Привет, %s!\n\nВозврат за категорию %s одобрен. На ваш баланс зачислено %d.\nДоступ к материалам категории закрыт.",
+		user.Name,
+		category.Name,
+		refund.Amount,
+	)
+	if refund.Status == models.RefundStatusRejected {
+		subject = "Возврат отклонён"
+		body = fmt.Sprintf(
+			"Привет, %s!\n\nЗаявка на возврат за категорию %s отклонена.\nПричина: %s",
+			user.Name,
+			category.Name,
+			refund.DecisionReason,
+		)
+	}
+
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", s.fromEmail)
+	msg.SetHeader("To", user.Email)
+	msg.SetHeader("Subject", subject)
+	msg.SetBody("text/plain", body)
+
+	if err := s.send(msg); err != nil {
+		return err
+	}
+
+	s.logger.Info("email уведомление о возврате отправлено",
+		"to", user.Email,
+		"refund_id", refund.ID,
+		"status", refund.Status,
+	)
+
+	return nil
+}
+
+func (s *EmailNotificationService) SendGiftReceived(recipient, sender *models.User, category *models.Categories, gift *models.Gift) error {
+	if recipient.Email == "" {
+		s.logger.Warn("у пользователя нет email", "user_id", recipient.ID)
+		return nil
+	}
+
+	body := fmt.Sprintf(
+		"Привет, %s!\n\n%s дарит вам категорию: %s.",
+		recipient.Name,
+		sender.Name,
+		category.Name,
+	)
+	if gift.Message != "" {
+		body += fmt.Sprintf("\nСообщение: %s", gift.Message)
+	}
+	body += fmt.Sprintf("\n\nПримите или отклоните подарок до %s.", gift.ExpiresAt.Format("02.01.2006"))
+
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", s.fromEmail)
+	msg.SetHeader("To", recipient.Email)
+	msg.SetHeader("Subject", "Вам подарок")
+	msg.SetBody("text/plain", body)
+
+	if err := s.send(msg); err != nil {
+		return err
+	}
+
+	s.logger.Info("email уведомление о подарке отправлено",
+		"to", recipient.Email,
+		"gift_id", gift.ID,
+	)
+
+	return nil
+}
+
+func (s *EmailNotificationService) SendGiftDecision(sender, recipient *models.User, category *models.Categories, gift *models.Gift) error {
+	if sender.Email == "" {
+		s.logger.Warn("у пользователя нет email", "user_id", sender.ID)
+		return nil
+	}
+
+	subject := "Подарок принят"
+	body := fmt.Sprintf(
+		"Привет, %s!\n\n%s принял(а) ваш подарок: %s.",
+		sender.Name,
+		recipient.Name,
+		category.Name,
+	)
+	switch gift.Status {
+	case models.GiftStatusDeclined:
+		subject = "Подарок отклонён"
+		body = fmt.Sprintf(
+			"Привет, %s!\n\n%s отклонил(а) ваш подарок: %s.\nНа ваш баланс возвращено %d.",
+			sender.Name,
+			recipient.Name,
+			category.Name,
+			gift.Amount,
+		)
+	case models.GiftStatusExpired:
+		subject = "Срок подарка истёк"
+		body = fmt.Sprintf(
+			"Привет, %s!\n\n%s не принял(а) ваш подарок %s вовремя.\nНа ваш баланс возвращено %d.",
+			sender.Name,
+			recipient.Name,
+			category.Name,
+			gift.Amount,
+		)
+	}
+
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", s.fromEmail)
+	msg.SetHeader("To", sender.Email)
+	msg.SetHeader("Subject", subject)
+	msg.SetBody("text/plain", body)
+
+	if err := s.send(msg); err != nil {
+		return err
+	}
+
+	s.logger.Info("email уведомление о подарке отправлено",
+		"to", sender.Email,
+		"gift_id", gift.ID,
+		"status", gift.Status,
+	)
+
+	return nil
+}
+
+func (s *EmailNotificationService) send(msg *gomail.Message) error {
 	dialer := gomail.NewDialer(s.smtpHost, s.smtpPort, s.fromEmail, s.fromPass)
 
 	dialer.SSL = false
@@ -59,10 +202,5 @@ func (s *EmailNotificationService) SendPaymentSuccess(user *models.User, categor
 		return err
 	}
 
-	s.logger.Info("email уведомление отправлено",
-		"to", user.Email,
-		"category", category.Name,
-	)
-
 	return nil
 }
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"time"
+)
+
+// Entitlement — набор категорий, контент которых доступен пользователю
+type Entitlement struct {
+	all        bool
+	categories map[uint]bool
+	// userID и allPrivate решают доступ к личным планам клиентов, покупки на них не влияют
+	userID     uint
+	allPrivate bool
+}
+
+func (e *Entitlement) CanViewCategory(categoryID uint) bool {
+	return e.all || e.categories[categoryID]
+}
+
+// CanViewExercisePlan — личный план виден только клиенту, его тренеру и тем, кто управляет пользователями
+func (e *Entitlement) CanViewExercisePlan(plan *models.ExercisePlan) bool {
+	if plan.ClientID != nil {
+		return e.allPrivate || e.Assigned(plan.ClientID, plan.TrainerID)
+	}
+	return e.CanViewCategory(plan.CategoriesID)
+}
+
+// CanViewMealPlan — план питания без категории доступен всем
+func (e *Entitlement) CanViewMealPlan(plan *models.MealPlan) bool {
+	if plan.ClientID != nil {
+		return e.allPrivate || e.Assigned(plan.ClientID, plan.TrainerID)
+	}
+	return plan.CategoriesID == nil || e.CanViewCategory(*plan.CategoriesID)
+}
+
+// Assigned сообщает, составлен ли личный план для пользователя или им самим как тренером
+func (e *Entitlement) Assigned(clientID, trainerID *uint) bool {
+	if e.userID == 0 {
+		return false
+	}
+	return (clientID != nil && *clientID == e.userID) || (trainerID != nil && *trainerID == e.userID)
+}
+
+type EntitlementService interface {
+	// CanViewCategory отвечает, может ли пользователь видеть полный контент категории.
+	// user == nil — анонимный запрос
+	CanViewCategory(user *models.User, categoryID uint) (bool, error)
+	// CanViewExercisePlan учитывает, что личные планы клиентов не продаются через категории
+	CanViewExercisePlan(user *models.User, plan *models.ExercisePlan) (bool, error)
+	// For возвращает все доступные пользователю категории — для фильтрации списков
+	For(user *models.User) (*Entitlement, error)
+}
+
+type entitlementService struct {
+	repo   repository.EntitlementRepository
+	policy AccessPolicy
+	log    *slog.Logger
+}
+
+func NewEntitlementService(repo repository.EntitlementRepository, policy AccessPolicy, log *slog.Logger) EntitlementService {
+	return &entitlementService{
+		repo:   repo,
+		policy: policy,
+		log:    log,
+	}
+}
+
+func (s *entitlementService) CanViewCategory(user *models.User, categoryID uint) (bool, error) {
+	if user == nil {
+		return false, nil
+	}
+
+	if s.policy.Can(user.Role, PermViewAllPlans) {
+		return true, nil
+	}
+
+	return s.repo.HasCategory(user.ID, categoryID, time.Now())
+}
+
+func (s *entitlementService) CanViewExercisePlan(user *models.User, plan *models.ExercisePlan) (bool, error) {
+	if plan.ClientID == nil {
+		return s.CanViewCategory(user, plan.CategoriesID)
+	}
+
+	return s.private(user).CanViewExercisePlan(plan), nil
+}
+
+func (s *entitlementService) For(user *models.User) (*Entitlement, error) {
+	entitlement := s.private(user)
+	if user == nil {
+		return entitlement, nil
+	}
+
+	if s.policy.Can(user.Role, PermViewAllPlans) {
+		entitlement.all = true
+		return entitlement, nil
+	}
+
+	ids, err := s.repo.CategoryIDs(user.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		entitlement.categories[id] = true
+	}
+
+	return entitlement, nil
+}
+
+// private заполняет только то, что нужно для доступа к личным планам
+func (s *entitlementService) private(user *models.User) *Entitlement {
+	entitlement := &Entitlement{categories: map[uint]bool{}}
+	if user != nil {
+		entitlement.userID = user.ID
+		entitlement.allPrivate = s.policy.Can(user.Role, PermManageUsers)
+	}
+	return entitlement
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExerciseNotFound = errors.New("упражнение не найдено")
+	ErrExerciseInUse    = errors.New("упражнение используется в тренировочных планах")
+)
+
+type ExerciseLibraryService interface {
+	// CreateExercise добавляет упражнение и привязывает к нему пункты планов с тем же названием
+	CreateExercise(req models.CreateExerciseRequest) (*models.Exercise, error)
+	GetExercise(id uint) (*models.Exercise, error)
+	SearchExercises(filter models.ExerciseFilter) ([]models.Exercise, error)
+	// UpdateExercise меняет упражнение и переносит название и инвентарь в привязанные пункты планов
+	UpdateExercise(id uint, req models.UpdateExerciseRequest) (*models.Exercise, error)
+	DeleteExercise(id uint) error
+}
+
+type exerciseLibraryService struct {
+	repo repository.ExerciseLibraryRepository
+	db   *gorm.DB
+	log  *slog.Logger
+}
+
+func NewExerciseLibraryService(repo repository.ExerciseLibraryRepository, db *gorm.DB, log *slog.Logger) ExerciseLibraryService {
+	return &exerciseLibraryService{
+		repo: repo,
+		db:   db,
+		log:  log,
+	}
+}
+
+func (s *exerciseLibraryService) CreateExercise(req models.CreateExerciseRequest) (*models.Exercise, error) {
+	exercise := &models.Exercise{
+		Name:         strings.TrimSpace(req.Name),
+		MuscleGroups: req.MuscleGroups,
+		Equipment:    req.Equipment,
+		Difficulty:   req.Difficulty,
+		Instructions: strings.TrimSpace(req.Instructions),
+		MediaURL:     strings.TrimSpace(req.MediaURL),
+	}
+	if err := validateExercise(exercise); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetByName(exercise.Name); err == nil {
+		return nil, fmt.Errorf("упражнение %q уже есть в библиотеке", exercise.Name)
+	}
+
+	var linked int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.Create(exercise); err != nil {
+			return fmt.Errorf("ошибка при создании упражнения: %w", err)
+		}
+
+		count, err := repo.LinkPlanItemsByName(exercise.Name, exercise.ID)
+		if err != nil {
+			return err
+		}
+		linked = count
+
+		return repo.RefreshPlanItems(exercise, exerciseEquipmentText(exercise))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Упражнение добавлено в библиотеку", "id", exercise.ID, "name", exercise.Name, "linked_items", linked)
+	return exercise, nil
+}
+
+func (s *exerciseLibraryService) GetExercise(id uint) (*models.Exercise, error) {
+	exercise, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrExerciseNotFound
+	}
+
+	return exercise, nil
+}
+
+func (s *exerciseLibraryService) SearchExercises(filter models.ExerciseFilter) ([]models.Exercise, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	filter.MuscleGroup = strings.ToLower(strings.TrimSpace(filter.MuscleGroup))
+	filter.Equipment = strings.ToLower(strings.TrimSpace(filter.Equipment))
+	filter.Difficulty = strings.ToLower(strings.TrimSpace(filter.Difficulty))
+
+	if filter.MuscleGroup != "" && !slices.Contains(models.MuscleGroups, filter.MuscleGroup) {
+		return nil, fmt.Errorf("неизвестная группа мышц %q", filter.MuscleGroup)
+	}
+	if filter.Equipment != "" && !slices.Contains(models.EquipmentTypes, filter.Equipment) {
+		return nil, fmt.Errorf("неизвестный инвентарь %q", filter.Equipment)
+	}
+	if filter.Difficulty != "" && !slices.Contains(models.Difficulties, filter.Difficulty) {
+		return nil, fmt.Errorf("неизвестный уровень сложности %q", filter.Difficulty)
+	}
+
+	return s.repo.List(filter)
+}
+
+func (s *exerciseLibraryService) UpdateExercise(id uint, req models.UpdateExerciseRequest) (*models.Exercise, error) {
+	exercise, err := s.GetExercise(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if existing, err := s.repo.GetByName(name); err == nil && existing.ID != exercise.ID {
+			return nil, fmt.Errorf("упражнение %q уже есть в библиотеке", name)
+		}
+		exercise.Name = name
+	}
+	if req.MuscleGroups != nil {
+		exercise.MuscleGroups = *req.MuscleGroups
+	}
+	if req.Equipment != nil {
+		exercise.Equipment = *req.Equipment
+	}
+	if req.Difficulty != nil {
+		exercise.Difficulty = *req.Difficulty
+	}
+	if req.Instructions != nil {
+		exercise.Instructions = strings.TrimSpace(*req.Instructions)
+	}
+	if req.MediaURL != nil {
+		exercise.MediaURL = strings.TrimSpace(*req.MediaURL)
+	}
+	if err := validateExercise(exercise); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.Update(exercise); err != nil {
+			return fmt.Errorf("ошибка при обновлении упражнения: %w", err)
+		}
+
+		return repo.RefreshPlanItems(exercise, exerciseEquipmentText(exercise))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return exercise, nil
+}
+
+func (s *exerciseLibraryService) DeleteExercise(id uint) error {
+	if _, err := s.GetExercise(id); err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountPlanItems(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrExerciseInUse
+	}
+
+	return s.repo.Delete(id)
+}
+
+func validateExercise(exercise *models.Exercise) error {
+	if exercise.Name == "" {
+		return errors.New("не указано название упражнения")
+	}
+
+	muscles, ok := models.NormalizeTags(exercise.MuscleGroups, models.MuscleGroups)
+	if !ok {
+		return fmt.Errorf("допустимые группы мышц: %s", strings.Join(models.MuscleGroups, ", "))
+	}
+	if len(muscles) == 0 {
+		return errors.New("укажите хотя бы одну группу мышц")
+	}
+	exercise.MuscleGroups = muscles
+
+	equipment, ok := models.NormalizeTags(exercise.Equipment, models.EquipmentTypes)
+	if !ok {
+		return fmt.Errorf("допустимый инвентарь: %s", strings.Join(models.EquipmentTypes, ", "))
+	}
+	if len(equipment) == 0 {
+		return errors.New("укажите инвентарь, для упражнений без него — bodyweight")
+	}
+	exercise.Equipment = equipment
+
+	exercise.Difficulty = strings.ToLower(strings.TrimSpace(exercise.Difficulty))
+	if exercise.Difficulty == "" {
+		exercise.Difficulty = models.DifficultyBeginner
+	}
+	if !slices.Contains(models.Difficulties, exercise.Difficulty) {
+		return fmt.Errorf("допустимые уровни сложности: %s", strings.Join(models.Difficulties, ", "))
+	}
+
+	if exercise.MediaURL != "" && !strings.HasPrefix(exercise.MediaURL, "http://") && !strings.HasPrefix(exercise.MediaURL, "https://") {
+		return errors.New("ссылка на медиа должна начинаться с http:// или https://")
+	}
+
+	return nil
+}
+
+// exerciseEquipmentText — инвентарь упражнения в виде строки для EquipmentNeeded пункта плана
+func exerciseEquipmentText(exercise *models.Exercise) string {
+	return strings.Join(exercise.Equipment, ", ")
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"cmp"
+	"healthy_body/internal/models"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// префиксы, по которым распознаются дни недели в старых планах, заполненных вручную
+var legacyWeekdayPrefixes = map[string][]string{
+	models.DayMonday:    {"mon", "пн", "пон"},
+	models.DayTuesday:   {"tue", "вт"},
+	models.DayWednesday: {"wed", "ср"},
+	models.DayThursday:  {"thu", "чт", "чет"},
+	models.DayFriday:    {"fri", "пт", "пят"},
+	models.DaySaturday:  {"sat", "сб", "суб"},
+	models.DaySunday:    {"sun", "вс", "вос"},
+}
+
+// MigrateLegacyExerciseSchedule переводит duration_minutes пунктов тренировочных планов
+// из текста в число и приводит day_of_week к значениям models.Weekdays.
+// Вызывается до AutoMigrate: сам gorm не умеет менять тип text на bigint
+func MigrateLegacyExerciseSchedule(db *gorm.DB, log *slog.Logger) error {
+	if !db.Migrator().HasTable(&models.ExercisePlanItem{}) {
+		return nil
+	}
+
+	columns, err := db.Migrator().ColumnTypes(&models.ExercisePlanItem{})
+	if err != nil {
+		return err
+	}
+	legacy := false
+	for _, column := range columns {
+		if column.Name() != "duration_minutes" {
+			continue
+		}
+		typeName := strings.ToLower(column.DatabaseTypeName())
+		legacy = typeName == "text" || strings.Contains(typeName, "char")
+	}
+	if !legacy {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// из строк вида "30 мин" берётся первое число, строки без чисел становятся нулём
+		if err := tx.Exec(`ALTER TABLE exercise_plan_items ALTER COLUMN duration_minutes TYPE bigint
+			USING COALESCE(substring(duration_minutes from '[0-9]+')::bigint, 0)`).Error; err != nil {
+			return err
+		}
+
+		var days []string
+		if err := tx.Model(&models.ExercisePlanItem{}).Distinct().Pluck("day_of_week", &days).Error; err != nil {
+			return err
+		}
+		for _, day := range days {
+			normalized := legacyWeekday(day)
+			if normalized == day {
+				continue
+			}
+			if err := tx.Model(&models.ExercisePlanItem{}).
+				Where("day_of_week = ?", day).
+				Update("day_of_week", normalized).Error; err != nil {
+				return err
+			}
+		}
+
+		log.Info("Расписание тренировочных планов переведено на номера недель и дни недели", "days", len(days))
+		return nil
+	})
+}
+
+// legacyWeekday распознаёт день недели по началу слова; нераспознанные значения
+// остаются как есть и попадают в Unscheduled расписания
+func legacyWeekday(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, day := range models.Weekdays {
+		for _, prefix := range legacyWeekdayPrefixes[day] {
+			if strings.HasPrefix(value, prefix) {
+				return day
+			}
+		}
+	}
+	return value
+}
+
+// buildSchedule раскладывает упражнения плана по неделям и дням с учётом прогрессии;
+// у каждой недели все семь дней, чтобы были видны дни отдыха
+func buildSchedule(plan *models.ExercisePlan) *models.ExercisePlanSchedule {
+	schedule := &models.ExercisePlanSchedule{
+		ExercisePlanID: plan.ID,
+		Name:           plan.Name,
+		DurationWeeks:  plan.DurationWeeks,
+		Weeks:          make([]models.ScheduleWeek, max(plan.DurationWeeks, 0)),
+		Unscheduled:    []models.ExercisePlanItem{},
+	}
+	for i := range schedule.Weeks {
+		schedule.Weeks[i] = prescribeWeek(plan, i+1)
+	}
+
+	for _, item := range sortedItems(plan) {
+		if item.Week < 1 || item.Week > plan.DurationWeeks || !models.IsValidWeekday(item.DayOfWeek) {
+			schedule.Unscheduled = append(schedule.Unscheduled, item)
+		}
+	}
+
+	return schedule
+}
+
+// prescribeWeek возвращает упражнения недели week с подходами, повторами и нагрузкой этой недели
+func prescribeWeek(plan *models.ExercisePlan, week int) models.ScheduleWeek {
+	result := models.ScheduleWeek{
+		ExercisePlanID: plan.ID,
+		Week:           week,
+		Days:           make([]models.ScheduleDay, len(models.Weekdays)),
+	}
+	for i, day := range models.Weekdays {
+		result.Days[i] = models.ScheduleDay{
+			DayOfWeek: day,
+			Rest:      true,
+			Exercises: []models.ExercisePrescription{},
+		}
+	}
+
+	for _, item := range sortedItems(plan) {
+		dayIndex := slices.Index(models.Weekdays, item.DayOfWeek)
+		if dayIndex < 0 || item.Week < 1 {
+			continue
+		}
+		prescription, ok := prescribe(item, week)
+		if !ok {
+			continue
+		}
+
+		day := &result.Days[dayIndex]
+		day.Exercises = append(day.Exercises, prescription)
+		day.Rest = false
+		day.DurationMinutes += item.DurationMinutes
+		result.DurationMinutes += item.DurationMinutes
+	}
+
+	return result
+}
+
+// prescribe рассчитывает пункт плана для недели week. Прогрессия растёт каждую неделю,
+// кроме разгрузочных; в разгрузочную неделю подходы и нагрузка снижаются от достигнутого уровня
+func prescribe(item models.ExercisePlanItem, week int) (models.ExercisePrescription, bool) {
+	rule := item.Progression
+	if week < item.Week || (week != item.Week && !rule.Active()) {
+		return models.ExercisePrescription{}, false
+	}
+
+	steps, deload := 0, false
+	for w := item.Week + 1; w <= week; w++ {
+		if rule.DeloadEvery > 0 && (w-item.Week+1)%rule.DeloadEvery == 0 {
+			deload = w == week
+			continue
+		}
+		steps++
+	}
+
+	reps := item.Reps + rule.RepsPerWeek*steps
+	if rule.MaxReps > 0 {
+		reps = min(reps, max(rule.MaxReps, item.Reps))
+	}
+	sets := item.Sets
+	load := 100 + rule.LoadPercentPerWeek*float64(steps)
+	if deload {
+		factor := 1 - rule.DeloadPercent/100
+		sets = max(1, int(math.Round(float64(sets)*factor)))
+		load *= factor
+	}
+
+	prescription := models.ExercisePrescription{
+		ExercisePlanItemID: item.ID,
+		Name:               item.Name,
+		Sets:               sets,
+		Reps:               reps,
+		LoadPercent:        math.Round(load*10) / 10,
+		DurationMinutes:    item.DurationMinutes,
+		RestSeconds:        item.RestSeconds,
+		Tempo:              item.Tempo,
+		EquipmentNeeded:    item.EquipmentNeeded,
+		Deload:             deload,
+		ExerciseID:         item.ExerciseID,
+		Exercise:           item.Exercise,
+	}
+	// вес округляется до 0,5 кг — наименьшего шага блинов в большинстве залов
+	if item.WeightKg != nil {
+		weight := math.Round(*item.WeightKg*load/100*2) / 2
+		prescription.WeightKg = &weight
+	}
+
+	return prescription, true
+}
+
+func sortedItems(plan *models.ExercisePlan) []models.ExercisePlanItem {
+	items := slices.Clone(plan.Exercises)
+	slices.SortFunc(items, func(a, b models.ExercisePlanItem) int { return cmp.Compare(a.ID, b.ID) })
+	return items
+}
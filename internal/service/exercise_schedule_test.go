@@ -0,0 +1,89 @@
+package service
+
+import (
+	"healthy_body/internal/models"
+	"testing"
+)
+
+func TestPrescribe(t *testing.T) {
+	weight := 100.0
+	rule := models.ProgressionRule{
+		RepsPerWeek:        1,
+		MaxReps:            10,
+		LoadPercentPerWeek: 2.5,
+		DeloadEvery:        4,
+		DeloadPercent:      50,
+	}
+	progressive := models.ExercisePlanItem{Week: 1, Sets: 4, Reps: 8, WeightKg: &weight, Progression: rule}
+	late := models.ExercisePlanItem{Week: 3, Sets: 4, Reps: 8, WeightKg: &weight, Progression: rule}
+	fixed := models.ExercisePlanItem{Week: 2, Sets: 3, Reps: 12, WeightKg: &weight}
+
+	tests := []struct {
+		name       string
+		item       models.ExercisePlanItem
+		week       int
+		wantOK     bool
+		wantSets   int
+		wantReps   int
+		wantLoad   float64
+		wantWeight float64
+		wantDeload bool
+	}{
+		{name: "first week", item: progressive, week: 1, wantOK: true, wantSets: 4, wantReps: 8, wantLoad: 100, wantWeight: 100},
+		{name: "progression", item: progressive, week: 2, wantOK: true, wantSets: 4, wantReps: 9, wantLoad: 102.5, wantWeight: 102.5},
+		{name: "reps capped", item: progressive, week: 3, wantOK: true, wantSets: 4, wantReps: 10, wantLoad: 105, wantWeight: 105},
+		{name: "deload week", item: progressive, week: 4, wantOK: true, wantSets: 2, wantReps: 10, wantLoad: 52.5, wantWeight: 52.5, wantDeload: true},
+		{name: "after deload", item: progressive, week: 5, wantOK: true, wantSets: 4, wantReps: 10, wantLoad: 107.5, wantWeight: 107.5},
+		{name: "before item starts", item: late, week: 2},
+		{name: "item start week", item: late, week: 3, wantOK: true, wantSets: 4, wantReps: 8, wantLoad: 100, wantWeight: 100},
+		{name: "deload counted from item start", item: late, week: 6, wantOK: true, wantSets: 2, wantReps: 10, wantLoad: 52.5, wantWeight: 52.5, wantDeload: true},
+		{name: "no rule before its week", item: fixed, week: 1},
+		{name: "no rule on its week", item: fixed, week: 2, wantOK: true, wantSets: 3, wantReps: 12, wantLoad: 100, wantWeight: 100},
+		{name: "no rule after its week", item: fixed, week: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := prescribe(tt.item, tt.week)
+			if ok != tt.wantOK {
+				t.Fatalf("prescribe() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+
+			if got.Sets != tt.wantSets || got.Reps != tt.wantReps || got.LoadPercent != tt.wantLoad || got.Deload != tt.wantDeload {
+				t.Errorf("prescribe() = %d×%d, нагрузка %v%%, разгрузка %v; want %d×%d, %v%%, %v",
+					got.Sets, got.Reps, got.LoadPercent, got.Deload, tt.wantSets, tt.wantReps, tt.wantLoad, tt.wantDeload)
+			}
+			if got.WeightKg == nil || *got.WeightKg != tt.wantWeight {
+				t.Errorf("вес %v, ожидалось %v", got.WeightKg, tt.wantWeight)
+			}
+		})
+	}
+}
+
+func TestPrescribeRoundsWeightAndKeepsOneSet(t *testing.T) {
+	weight := 62.0
+	item := models.ExercisePlanItem{
+		Week:        1,
+		Sets:        1,
+		Reps:        5,
+		WeightKg:    &weight,
+		Progression: models.ProgressionRule{LoadPercentPerWeek: 2.5, DeloadEvery: 3, DeloadPercent: 60},
+	}
+
+	got, ok := prescribe(item, 2)
+	if !ok {
+		t.Fatal("prescribe() вернул ok = false")
+	}
+	// 62 × 1,025 = 63,55 → до 0,5 кг
+	if *got.WeightKg != 63.5 {
+		t.Errorf("вес %v, ожидалось 63.5", *got.WeightKg)
+	}
+
+	got, _ = prescribe(item, 3)
+	if !got.Deload || got.Sets != 1 {
+		t.Errorf("разгрузка %v, подходов %d; ожидалась разгрузка с одним подходом", got.Deload, got.Sets)
+	}
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"healthy_body/internal/models"
 	"healthy_body/internal/repository"
 	"log/slog"
+	"strings"
 )
 
 type ExercisePlanServices interface {
@@ -14,6 +16,11 @@ type ExercisePlanServices interface {
 	GetListPlans() ([]models.ExercisePlan, error)
 	UpdatePlan(id uint, req models.UpdateExercesicePlanRequest) (*models.ExercisePlan, error)
 	DeletePlan(id uint) error
+	// Schedule возвращает упражнения плана по неделям и дням недели.
+	// План передаётся целиком, чтобы строить расписание и по черновику, и по опубликованной версии
+	Schedule(plan *models.ExercisePlan) *models.ExercisePlanSchedule
+	// WeekPrescription возвращает упражнения недели week с подходами, повторами и нагрузкой по правилам прогрессии
+	WeekPrescription(plan *models.ExercisePlan, week int) (*models.ScheduleWeek, error)
 
 	CreatePlanItem(req models.CreateExercisePlanItemRequest) (*models.ExercisePlanItem, error)
 	GetAllPlanItem() ([]models.ExercisePlanItem, error)
@@ -25,19 +32,27 @@ type ExercisePlanServices interface {
 type exercisePlanServices struct {
 	exerciseRepo repository.ExercisePlanRepo
 	category     CategoryServices
+	library      repository.ExerciseLibraryRepository
 	log          *slog.Logger
 }
 
-func NewExercisePlanServices(exerciseRepo repository.ExercisePlanRepo, log *slog.Logger, category CategoryServices) ExercisePlanServices {
+func NewExercisePlanServices(
+	exerciseRepo repository.ExercisePlanRepo,
+	log *slog.Logger,
+	category CategoryServices,
+	// library — библиотека упражнений, на которые ссылаются пункты планов
+	library repository.ExerciseLibraryRepository,
+) ExercisePlanServices {
 	return &exercisePlanServices{
 		exerciseRepo: exerciseRepo,
 		log:          log,
 		category: category,
+		library:      library,
 	}
 }
 
 func (e *exercisePlanServices) CreatePlan(req models.CreateExercesicePlanRequest) (*models.ExercisePlan, error) {
-	if req.DurationWeeks == 0 {
+	if req.DurationWeeks <= 0 {
 		e.log.Error("error CreatePlan function in exercise_service.go")
 		return nil, errors.New("empty weeks your plan")
 	}
@@ -100,6 +115,14 @@ func (e *exercisePlanServices) UpdatePlan(id uint, req models.UpdateExercesicePl
 	}
 
 	if req.DurationWeeks != nil {
+		if *req.DurationWeeks <= 0 {
+			return nil, errors.New("empty weeks your plan")
+		}
+		for _, item := range plan.Exercises {
+			if item.Week > *req.DurationWeeks {
+				return nil, fmt.Errorf("plan has exercises in week %d, remove them before shortening the plan", item.Week)
+			}
+		}
 		plan.DurationWeeks = *req.DurationWeeks
 	}
 
@@ -119,6 +142,19 @@ func (e *exercisePlanServices) UpdatePlan(id uint, req models.UpdateExercesicePl
 	return plan, nil
 }
 
+func (e *exercisePlanServices) Schedule(plan *models.ExercisePlan) *models.ExercisePlanSchedule {
+	return buildSchedule(plan)
+}
+
+func (e *exercisePlanServices) WeekPrescription(plan *models.ExercisePlan, week int) (*models.ScheduleWeek, error) {
+	if week < 1 || week > plan.DurationWeeks {
+		return nil, fmt.Errorf("week must be between 1 and %d", plan.DurationWeeks)
+	}
+
+	result := prescribeWeek(plan, week)
+	return &result, nil
+}
+
 func (e *exercisePlanServices) DeletePlan(id uint) error {
 	if err := e.exerciseRepo.DeleteExercisePlan(id); err != nil {
 		e.log.Error("error DeletePlan function in exercise_service.go")
@@ -130,12 +166,30 @@ func (e *exercisePlanServices) DeletePlan(id uint) error {
 
 
 func (e *exercisePlanServices) CreatePlanItem(req models.CreateExercisePlanItemRequest) (*models.ExercisePlanItem, error) {
-	if err := e.validate(req); err != nil {
+	var exercise *models.Exercise
+	if req.ExerciseID != nil {
+		found, err := e.library.GetByID(*req.ExerciseID)
+		if err != nil {
+			e.log.Error("error CreatePlanItem function in exercise_service.go")
+			return nil, ErrExerciseNotFound
+		}
+		exercise = found
+		req.Name = exercise.Name
+		req.EquipmentNeeded = exerciseEquipmentText(exercise)
+	}
+
+	plan, err := e.exerciseRepo.GetByIDExercisePlanForNotPreload(req.ExercisePlanID)
+	if err != nil {
 		e.log.Error("error CreatePlanItem function in exercise_service.go")
 		return nil, err
 	}
 
-	if _, err := e.exerciseRepo.GetByIDExercisePlanForNotPreload(req.ExercisePlanID); err != nil {
+	if req.Week == 0 {
+		req.Week = 1
+	}
+	req.DayOfWeek = strings.ToLower(strings.TrimSpace(req.DayOfWeek))
+
+	if err := e.validate(req, plan); err != nil {
 		e.log.Error("error CreatePlanItem function in exercise_service.go")
 		return nil, err
 	}
@@ -146,8 +200,14 @@ func (e *exercisePlanServices) CreatePlanItem(req models.CreateExercisePlanItemR
 		Reps:            req.Reps,
 		EquipmentNeeded: req.EquipmentNeeded,
 		DurationMinutes: req.DurationMinutes,
+		Week:            req.Week,
 		DayOfWeek:       req.DayOfWeek,
+		RestSeconds:     req.RestSeconds,
+		Tempo:           strings.ToUpper(req.Tempo),
+		ExerciseID:      req.ExerciseID,
 		ExercisePlanID:  req.ExercisePlanID,
+		WeightKg:        req.WeightKg,
+		Progression:     normalizeProgression(req.Progression),
 	}
 
 	if err := e.exerciseRepo.CreateExercisePlanItem(item); err != nil {
@@ -155,6 +215,7 @@ func (e *exercisePlanServices) CreatePlanItem(req models.CreateExercisePlanItemR
 		return nil, err
 	}
 
+	item.Exercise = exercise
 	return item, nil
 }
 
@@ -185,8 +246,44 @@ func (e *exercisePlanServices) UpdatePlanItem(id uint, req models.UpdateExercise
 		return nil, err
 	}
 
+	if req.ExerciseID != nil {
+		// exercise_id = 0 отвязывает пункт от библиотеки
+		item.ExerciseID, item.Exercise = nil, nil
+		if *req.ExerciseID != 0 {
+			exercise, err := e.library.GetByID(*req.ExerciseID)
+			if err != nil {
+				e.log.Error("error UpdatePlanItem function in exercise_service.go")
+				return nil, ErrExerciseNotFound
+			}
+			item.ExerciseID, item.Exercise = &exercise.ID, exercise
+		}
+	}
+
 	e.up(item, req)
 
+	if item.Exercise != nil {
+		item.Name = item.Exercise.Name
+		item.EquipmentNeeded = exerciseEquipmentText(item.Exercise)
+	}
+
+	plan, err := e.exerciseRepo.GetByIDExercisePlanForNotPreload(item.ExercisePlanID)
+	if err != nil {
+		e.log.Error("error UpdatePlanItem function in exercise_service.go")
+		return nil, err
+	}
+
+	if err := validateSchedule(item.Week, item.DayOfWeek, item.DurationMinutes, plan); err != nil {
+		return nil, err
+	}
+
+	if err := validatePrescription(item.RestSeconds, item.Tempo); err != nil {
+		return nil, err
+	}
+
+	if err := validateProgression(item.Reps, item.WeightKg, item.Progression); err != nil {
+		return nil, err
+	}
+
 	if err := e.exerciseRepo.UpdateExercisePlanItem(item); err != nil {
 		e.log.Error("error UpdatePlanItem function in exercise_service.go")
 		return nil, err
@@ -204,7 +301,7 @@ func (e *exercisePlanServices) DeletePlanItem(id uint) error {
 	return nil
 }
 
-func (r *exercisePlanServices) validate(req models.CreateExercisePlanItemRequest) error {
+func (r *exercisePlanServices) validate(req models.CreateExercisePlanItemRequest, plan *models.ExercisePlan) error {
 	if req.Name == "" {
 		return errors.New("name plan item is null")
 	}
@@ -216,21 +313,86 @@ func (r *exercisePlanServices) validate(req models.CreateExercisePlanItemRequest
 		return errors.New("reps plan item is null")
 	}
 
-	if req.DurationMinutes == "" {
-		return errors.New("durationMinutes plan item is null")
+	if req.EquipmentNeeded == "" {
+		return errors.New("equipmentNeeded plan item is null")
 	}
 
-	if req.DayOfWeek == "" {
-		return errors.New("dayOfWeek plan item is null")
+	if err := validateSchedule(req.Week, req.DayOfWeek, req.DurationMinutes, plan); err != nil {
+		return err
 	}
 
-	if req.EquipmentNeeded == "" {
-		return errors.New("equipmentNeeded plan item is null")
+	if err := validatePrescription(req.RestSeconds, req.Tempo); err != nil {
+		return err
+	}
+
+	return validateProgression(req.Reps, req.WeightKg, req.Progression)
+}
+
+// validateSchedule проверяет, что пункт стоит на существующей неделе плана и в корректный день
+func validateSchedule(week int, dayOfWeek string, durationMinutes int, plan *models.ExercisePlan) error {
+	if durationMinutes <= 0 {
+		return errors.New("durationMinutes plan item must be positive")
+	}
+
+	if !models.IsValidWeekday(dayOfWeek) {
+		return fmt.Errorf("dayOfWeek plan item must be one of: %s", strings.Join(models.Weekdays, ", "))
+	}
+
+	if week < 1 || week > plan.DurationWeeks {
+		return fmt.Errorf("week plan item must be between 1 and %d", plan.DurationWeeks)
+	}
+
+	return nil
+}
+
+func validatePrescription(restSeconds int, tempo string) error {
+	if restSeconds < 0 {
+		return errors.New("restSeconds plan item is negative")
+	}
+
+	if tempo != "" && !models.IsValidTempo(tempo) {
+		return errors.New("tempo plan item must look like 3-1-X-0")
+	}
+
+	return nil
+}
+
+func validateProgression(reps int, weightKg *float64, rule models.ProgressionRule) error {
+	if weightKg != nil && (*weightKg < 0 || *weightKg > 1000) {
+		return errors.New("weightKg plan item must be between 0 and 1000")
+	}
+
+	if rule.RepsPerWeek < 0 || rule.LoadPercentPerWeek < 0 {
+		return errors.New("progression must not decrease reps or load, use deload weeks instead")
+	}
+
+	if rule.LoadPercentPerWeek > 50 {
+		return errors.New("progression loadPercentPerWeek must not exceed 50")
+	}
+
+	if rule.MaxReps < 0 || (rule.MaxReps > 0 && rule.MaxReps < reps) {
+		return errors.New("progression maxReps must not be less than reps")
+	}
+
+	if rule.DeloadEvery < 0 || rule.DeloadEvery == 1 {
+		return errors.New("progression deloadEvery must be 0 or at least 2")
+	}
+
+	if rule.DeloadPercent < 0 || rule.DeloadPercent >= 100 {
+		return errors.New("progression deloadPercent must be between 0 and 100")
 	}
 
 	return nil
 }
 
+// normalizeProgression задаёт снижение на 40% для разгрузочных недель без явного процента
+func normalizeProgression(rule models.ProgressionRule) models.ProgressionRule {
+	if rule.DeloadEvery > 0 && rule.DeloadPercent == 0 {
+		rule.DeloadPercent = 40
+	}
+	return rule
+}
+
 func (r *exercisePlanServices) up(item *models.ExercisePlanItem, req models.UpdateExercisePlanItemRequest) {
 	if req.Name != nil {
 		item.Name = *req.Name
@@ -252,8 +414,28 @@ func (r *exercisePlanServices) up(item *models.ExercisePlanItem, req models.Upda
 		item.EquipmentNeeded = *req.EquipmentNeeded
 	}
 
+	if req.Week != nil {
+		item.Week = *req.Week
+	}
+
 	if req.DayOfWeek != nil {
-		item.DayOfWeek = *req.DayOfWeek
+		item.DayOfWeek = strings.ToLower(strings.TrimSpace(*req.DayOfWeek))
+	}
+
+	if req.RestSeconds != nil {
+		item.RestSeconds = *req.RestSeconds
+	}
+
+	if req.Tempo != nil {
+		item.Tempo = strings.ToUpper(*req.Tempo)
+	}
+
+	if req.WeightKg != nil {
+		item.WeightKg = req.WeightKg
+	}
+
+	if req.Progression != nil {
+		item.Progression = normalizeProgression(*req.Progression)
 	}
 
 }
@@ -0,0 +1,623 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"healthy_body/internal/calculator"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFoodNotFound   = errors.New("продукт не найден")
+	ErrRecipeNotFound = errors.New("рецепт не найден")
+	ErrFoodInUse      = errors.New("продукт входит в рецепты")
+	ErrRecipeInUse    = errors.New("рецепт используется в планах питания")
+)
+
+// unitGrams — вес единицы количества в граммах; для жидкостей плотность принимается равной воде
+var unitGrams = map[string]float64{
+	models.UnitGram:       1,
+	models.UnitKilogram:   1000,
+	models.UnitMillilitre: 1,
+	models.UnitLitre:      1000,
+	models.UnitTeaspoon:   5,
+	models.UnitTablespoon: 15,
+}
+
+// столбцы CSV со справочником продуктов; значения указываются на 100 г
+var foodCSVColumns = []string{"name", "calories", "protein", "fat", "carbs", "fibre", "piece_grams", "aisle"}
+
+type FoodService interface {
+	CreateFood(req models.CreateFoodRequest) (*models.Food, error)
+	GetFood(id uint) (*models.Food, error)
+	ListFoods(query string) ([]models.Food, error)
+	// UpdateFood меняет продукт и пересчитывает рецепты и блюда планов, в которые он входит
+	UpdateFood(id uint, req models.UpdateFoodRequest) (*models.Food, error)
+	DeleteFood(id uint) error
+	// ImportCSV добавляет продукты из CSV и обновляет уже существующие с тем же названием.
+	// Загрузка выполняется целиком или не выполняется совсем
+	ImportCSV(r io.Reader) (*models.FoodImportResult, error)
+
+	CreateRecipe(req models.CreateRecipeRequest) (*models.Recipe, error)
+	// GetRecipe возвращает рецепт с рассчитанной пищевой ценностью
+	GetRecipe(id uint) (*models.Recipe, error)
+	ListRecipes() ([]models.Recipe, error)
+	UpdateRecipe(id uint, req models.UpdateRecipeRequest) (*models.Recipe, error)
+	DeleteRecipe(id uint) error
+}
+
+type foodService struct {
+	repo  repository.FoodRepository
+	items repository.MealPlanItemRepository
+	db    *gorm.DB
+	log   *slog.Logger
+}
+
+func NewFoodService(
+	repo repository.FoodRepository,
+	// items нужен, чтобы пересчитывать блюда планов питания при изменении рецептов
+	items repository.MealPlanItemRepository,
+	db *gorm.DB,
+	log *slog.Logger,
+) FoodService {
+	return &foodService{
+		repo:  repo,
+		items: items,
+		db:    db,
+		log:   log,
+	}
+}
+
+func (s *foodService) CreateFood(req models.CreateFoodRequest) (*models.Food, error) {
+	food := &models.Food{
+		Name:       strings.TrimSpace(req.Name),
+		Calories:   req.Calories,
+		Protein:    req.Protein,
+		Fat:        req.Fat,
+		Carbs:      req.Carbs,
+		Fibre:      req.Fibre,
+		PieceGrams: req.PieceGrams,
+		Aisle:      strings.ToLower(strings.TrimSpace(req.Aisle)),
+	}
+	if err := validateFood(food); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetFoodByName(food.Name); err == nil {
+		return nil, fmt.Errorf("продукт %q уже есть в справочнике", food.Name)
+	}
+
+	if err := s.repo.CreateFood(food); err != nil {
+		return nil, fmt.Errorf("ошибка при создании продукта: %w", err)
+	}
+
+	s.log.Info("Продукт добавлен", "id", food.ID, "name", food.Name)
+	return food, nil
+}
+
+func (s *foodService) GetFood(id uint) (*models.Food, error) {
+	food, err := s.repo.GetFood(id)
+	if err != nil {
+		return nil, ErrFoodNotFound
+	}
+
+	return food, nil
+}
+
+func (s *foodService) ListFoods(query string) ([]models.Food, error) {
+	return s.repo.ListFoods(strings.TrimSpace(query))
+}
+
+func (s *foodService) UpdateFood(id uint, req models.UpdateFoodRequest) (*models.Food, error) {
+	food, err := s.GetFood(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if existing, err := s.repo.GetFoodByName(name); err == nil && existing.ID != food.ID {
+			return nil, fmt.Errorf("продукт %q уже есть в справочнике", name)
+		}
+		food.Name = name
+	}
+	if req.Calories != nil {
+		food.Calories = *req.Calories
+	}
+	if req.Protein != nil {
+		food.Protein = *req.Protein
+	}
+	if req.Fat != nil {
+		food.Fat = *req.Fat
+	}
+	if req.Carbs != nil {
+		food.Carbs = *req.Carbs
+	}
+	if req.Fibre != nil {
+		food.Fibre = *req.Fibre
+	}
+	if req.PieceGrams != nil {
+		food.PieceGrams = req.PieceGrams
+	}
+	if req.Aisle != nil {
+		food.Aisle = strings.ToLower(strings.TrimSpace(*req.Aisle))
+	}
+	if err := validateFood(food); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).UpdateFood(food); err != nil {
+			return fmt.Errorf("ошибка при обновлении продукта: %w", err)
+		}
+
+		return s.refreshFoodRecipes(tx, []uint{food.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return food, nil
+}
+
+func (s *foodService) DeleteFood(id uint) error {
+	if _, err := s.GetFood(id); err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountFoodUsage(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrFoodInUse
+	}
+
+	return s.repo.DeleteFood(id)
+}
+
+func (s *foodService) ImportCSV(r io.Reader) (*models.FoodImportResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл: %w", err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = csvDelimiter(data)
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("некорректный CSV: %w", err)
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("в файле нет продуктов")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range foodCSVColumns[:5] {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("в заголовке нет столбца %s", name)
+		}
+	}
+
+	foods := make([]models.Food, 0, len(rows)-1)
+	seen := map[string]int{}
+	for i, row := range rows[1:] {
+		line := i + 2
+		food, err := parseFoodRow(row, columns)
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+		if err := validateFood(food); err != nil {
+			return nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+
+		key := strings.ToLower(food.Name)
+		if previous, ok := seen[key]; ok {
+			return nil, fmt.Errorf("строка %d: продукт %q уже указан в строке %d", line, food.Name, previous)
+		}
+		seen[key] = line
+		foods = append(foods, *food)
+	}
+
+	result := &models.FoodImportResult{}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var updated []uint
+
+		for i := range foods {
+			food := &foods[i]
+			existing, err := repo.GetFoodByName(food.Name)
+			if err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				if err := repo.CreateFood(food); err != nil {
+					return fmt.Errorf("ошибка при добавлении продукта %q: %w", food.Name, err)
+				}
+				result.Created++
+				continue
+			}
+
+			food.Model = existing.Model
+			if food.PieceGrams == nil {
+				food.PieceGrams = existing.PieceGrams
+			}
+			if food.Aisle == "" {
+				food.Aisle = existing.Aisle
+			}
+			if err := repo.UpdateFood(food); err != nil {
+				return fmt.Errorf("ошибка при обновлении продукта %q: %w", food.Name, err)
+			}
+			updated = append(updated, food.ID)
+			result.Updated++
+		}
+
+		return s.refreshFoodRecipes(tx, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Справочник продуктов загружен",
+		"created", result.Created,
+		"updated", result.Updated)
+
+	return result, nil
+}
+
+func (s *foodService) CreateRecipe(req models.CreateRecipeRequest) (*models.Recipe, error) {
+	recipe := &models.Recipe{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Servings:    req.Servings,
+	}
+	if recipe.Servings == 0 {
+		recipe.Servings = 1
+	}
+	if err := validateRecipe(recipe); err != nil {
+		return nil, err
+	}
+
+	ingredients, err := s.ingredients(req.Ingredients)
+	if err != nil {
+		return nil, err
+	}
+	recipe.Ingredients = ingredients
+
+	if err := s.repo.CreateRecipe(recipe); err != nil {
+		return nil, fmt.Errorf("ошибка при создании рецепта: %w", err)
+	}
+
+	s.log.Info("Рецепт создан", "id", recipe.ID, "name", recipe.Name)
+	return s.GetRecipe(recipe.ID)
+}
+
+func (s *foodService) GetRecipe(id uint) (*models.Recipe, error) {
+	recipe, err := s.repo.GetRecipe(id)
+	if err != nil {
+		return nil, ErrRecipeNotFound
+	}
+
+	fillRecipeNutrition(recipe)
+	return recipe, nil
+}
+
+func (s *foodService) ListRecipes() ([]models.Recipe, error) {
+	recipes, err := s.repo.ListRecipes()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range recipes {
+		fillRecipeNutrition(&recipes[i])
+	}
+	return recipes, nil
+}
+
+func (s *foodService) UpdateRecipe(id uint, req models.UpdateRecipeRequest) (*models.Recipe, error) {
+	recipe, err := s.repo.GetRecipe(id)
+	if err != nil {
+		return nil, ErrRecipeNotFound
+	}
+
+	if req.Name != nil {
+		recipe.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		recipe.Description = *req.Description
+	}
+	if req.Servings != nil {
+		recipe.Servings = *req.Servings
+	}
+	if err := validateRecipe(recipe); err != nil {
+		return nil, err
+	}
+
+	var ingredients []models.RecipeIngredient
+	if req.Ingredients != nil {
+		ingredients, err = s.ingredients(*req.Ingredients)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.UpdateRecipe(recipe); err != nil {
+			return fmt.Errorf("ошибка при обновлении рецепта: %w", err)
+		}
+		if req.Ingredients != nil {
+			if err := repo.ReplaceIngredients(recipe.ID, ingredients); err != nil {
+				return fmt.Errorf("ошибка при обновлении состава рецепта: %w", err)
+			}
+		}
+
+		return s.refreshRecipe(tx, recipe.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRecipe(recipe.ID)
+}
+
+func (s *foodService) DeleteRecipe(id uint) error {
+	if _, err := s.repo.GetRecipe(id); err != nil {
+		return ErrRecipeNotFound
+	}
+
+	count, err := s.items.CountByRecipe(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRecipeInUse
+	}
+
+	return s.repo.DeleteRecipe(id)
+}
+
+// ingredients проверяет состав рецепта по справочнику продуктов
+func (s *foodService) ingredients(reqs []models.RecipeIngredientRequest) ([]models.RecipeIngredient, error) {
+	if len(reqs) == 0 {
+		return nil, errors.New("в рецепте должен быть хотя бы один ингредиент")
+	}
+
+	ids := make([]uint, 0, len(reqs))
+	for _, req := range reqs {
+		ids = append(ids, req.FoodID)
+	}
+	foods, err := s.repo.GetFoods(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Food, len(foods))
+	for i := range foods {
+		byID[foods[i].ID] = &foods[i]
+	}
+
+	ingredients := make([]models.RecipeIngredient, 0, len(reqs))
+	for _, req := range reqs {
+		food, ok := byID[req.FoodID]
+		if !ok {
+			return nil, fmt.Errorf("продукт %d не найден", req.FoodID)
+		}
+
+		ingredient := models.RecipeIngredient{
+			FoodID:   food.ID,
+			Quantity: req.Quantity,
+			Unit:     strings.ToLower(strings.TrimSpace(req.Unit)),
+			Food:     food,
+		}
+		if ingredient.Unit == "" {
+			ingredient.Unit = models.UnitGram
+		}
+		if ingredient.Quantity <= 0 {
+			return nil, fmt.Errorf("количество продукта %q должно быть положительным", food.Name)
+		}
+		if _, err := ingredientGrams(ingredient); err != nil {
+			return nil, err
+		}
+
+		ingredients = append(ingredients, ingredient)
+	}
+
+	return ingredients, nil
+}
+
+// refreshFoodRecipes пересчитывает блюда планов по всем рецептам с этими продуктами
+func (s *foodService) refreshFoodRecipes(tx *gorm.DB, foodIDs []uint) error {
+	recipes := map[uint]bool{}
+	for _, foodID := range foodIDs {
+		ids, err := s.repo.WithTx(tx).RecipeIDsByFood(foodID)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			recipes[id] = true
+		}
+	}
+
+	for id := range recipes {
+		if err := s.refreshRecipe(tx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *foodService) refreshRecipe(tx *gorm.DB, recipeID uint) error {
+	recipe, err := s.repo.WithTx(tx).GetRecipe(recipeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	fillRecipeNutrition(recipe)
+	if err := s.items.WithTx(tx).RefreshRecipeNutrition(recipe.ID, recipe.PerServing); err != nil {
+		return fmt.Errorf("ошибка при пересчёте блюд рецепта: %w", err)
+	}
+
+	return nil
+}
+
+func validateFood(food *models.Food) error {
+	if food.Name == "" {
+		return errors.New("укажите название продукта")
+	}
+	if food.Calories < 0 || food.Protein < 0 || food.Fat < 0 || food.Carbs < 0 || food.Fibre < 0 {
+		return errors.New("калории и нутриенты не могут быть отрицательными")
+	}
+	if food.Protein+food.Fat+food.Carbs+food.Fibre > 100 {
+		return fmt.Errorf("в 100 г продукта %q больше 100 г нутриентов", food.Name)
+	}
+	if food.Calories > 900 {
+		return fmt.Errorf("калорийность продукта %q не может превышать 900 ккал на 100 г", food.Name)
+	}
+	if food.PieceGrams != nil && *food.PieceGrams <= 0 {
+		return errors.New("вес штуки должен быть положительным")
+	}
+
+	return nil
+}
+
+func validateRecipe(recipe *models.Recipe) error {
+	if recipe.Name == "" {
+		return errors.New("укажите название рецепта")
+	}
+	if recipe.Servings < 1 {
+		return errors.New("количество порций должно быть не меньше 1")
+	}
+
+	return nil
+}
+
+// csvDelimiter определяет разделитель по строке заголовка: таблицы, выгруженные
+// из Excel с русской локалью, разделены точкой с запятой
+func csvDelimiter(data []byte) rune {
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func parseFoodRow(row []string, columns map[string]int) (*models.Food, error) {
+	value := func(name string) string {
+		index, ok := columns[name]
+		if !ok || index >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[index])
+	}
+	number := func(name string) (float64, error) {
+		raw := strings.ReplaceAll(value(name), ",", ".")
+		if raw == "" {
+			return 0, nil
+		}
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0, fmt.Errorf("некорректное значение %s: %q", name, value(name))
+		}
+		return parsed, nil
+	}
+
+	food := &models.Food{
+		Name:  value("name"),
+		Aisle: strings.ToLower(value("aisle")),
+	}
+	targets := []*float64{&food.Calories, &food.Protein, &food.Fat, &food.Carbs, &food.Fibre}
+	for i, target := range targets {
+		parsed, err := number(foodCSVColumns[i+1])
+		if err != nil {
+			return nil, err
+		}
+		*target = parsed
+	}
+
+	if value("piece_grams") != "" {
+		pieceGrams, err := number("piece_grams")
+		if err != nil {
+			return nil, err
+		}
+		food.PieceGrams = &pieceGrams
+	}
+
+	return food, nil
+}
+
+// ingredientGrams переводит количество ингредиента в граммы
+func ingredientGrams(ingredient models.RecipeIngredient) (float64, error) {
+	if ingredient.Unit == models.UnitPiece {
+		if ingredient.Food == nil || ingredient.Food.PieceGrams == nil {
+			return 0, fmt.Errorf("для продукта %d не указан вес штуки", ingredient.FoodID)
+		}
+		return ingredient.Quantity * *ingredient.Food.PieceGrams, nil
+	}
+
+	grams, ok := unitGrams[ingredient.Unit]
+	if !ok {
+		return 0, fmt.Errorf("неизвестная единица %q: используйте g, kg, ml, l, tsp, tbsp или pcs", ingredient.Unit)
+	}
+
+	return ingredient.Quantity * grams, nil
+}
+
+// fillRecipeNutrition считает пищевую ценность рецепта целиком и на одну порцию
+func fillRecipeNutrition(recipe *models.Recipe) {
+	var total models.NutritionTotals
+	for _, ingredient := range recipe.Ingredients {
+		if ingredient.Food == nil {
+			continue
+		}
+		grams, err := ingredientGrams(ingredient)
+		if err != nil {
+			continue
+		}
+
+		ratio := grams / 100
+		total.Calories += ingredient.Food.Calories * ratio
+		total.Protein += ingredient.Food.Protein * ratio
+		total.Fat += ingredient.Food.Fat * ratio
+		total.Carbs += ingredient.Food.Carbs * ratio
+		total.Fibre += ingredient.Food.Fibre * ratio
+	}
+
+	servings := float64(max(recipe.Servings, 1))
+	recipe.Nutrition = roundNutrition(total)
+	recipe.PerServing = roundNutrition(models.NutritionTotals{
+		Calories: total.Calories / servings,
+		Protein:  total.Protein / servings,
+		Fat:      total.Fat / servings,
+		Carbs:    total.Carbs / servings,
+		Fibre:    total.Fibre / servings,
+	})
+}
+
+// recipeServingNutrition — пищевая ценность servings порций рецепта
+func recipeServingNutrition(recipe *models.Recipe, servings float64) models.NutritionTotals {
+	return models.NutritionTotals{
+		Calories: calculator.Round(recipe.PerServing.Calories * servings),
+		Protein:  calculator.Round(recipe.PerServing.Protein * servings),
+		Fat:      calculator.Round(recipe.PerServing.Fat * servings),
+		Carbs:    calculator.Round(recipe.PerServing.Carbs * servings),
+		Fibre:    calculator.Round(recipe.PerServing.Fibre * servings),
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"healthy_body/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFoodRow(t *testing.T) {
+	columns := map[string]int{}
+	for i, name := range foodCSVColumns {
+		columns[name] = i
+	}
+	piece := 60.0
+
+	tests := []struct {
+		name    string
+		row     []string
+		want    *models.Food
+		wantErr bool
+	}{
+		{
+			name: "all columns",
+			row:  []string{"Яйцо", "157", "12.7", "11.5", "0.7", "0", "60", "Молочные"},
+			want: &models.Food{Name: "Яйцо", Calories: 157, Protein: 12.7, Fat: 11.5, Carbs: 0.7, PieceGrams: &piece, Aisle: "молочные"},
+		},
+		{
+			name: "decimal comma",
+			row:  []string{"Овсянка", "352", "12,3", "6,1", "59,5", "11", "", ""},
+			want: &models.Food{Name: "Овсянка", Calories: 352, Protein: 12.3, Fat: 6.1, Carbs: 59.5, Fibre: 11},
+		},
+		{
+			name: "short row leaves optional columns empty",
+			row:  []string{" Рис ", "344", "6.7", "0.7", "78.9"},
+			want: &models.Food{Name: "Рис", Calories: 344, Protein: 6.7, Fat: 0.7, Carbs: 78.9},
+		},
+		{
+			name: "empty numbers are zero",
+			row:  []string{"Вода", "", "", "", "", "", "", ""},
+			want: &models.Food{Name: "Вода"},
+		},
+		{name: "invalid number", row: []string{"Хлеб", "много", "8", "1", "48"}, wantErr: true},
+		{name: "invalid piece weight", row: []string{"Банан", "89", "1.5", "0.1", "21.8", "2.6", "штука"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFoodRow(tt.row, columns)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFoodRow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFoodRow() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVDelimiter(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want rune
+	}{
+		{name: "comma", data: "name,calories,protein,fat,carbs\nРис,344,6.7,0.7,78.9\n", want: ','},
+		{name: "semicolon", data: "name;calories;protein;fat;carbs\nРис;344;6,7;0,7;78,9\n", want: ';'},
+		// запятые в дробных числах строк данных не сбивают определение
+		{name: "only header counts", data: "name;calories;protein;fat;carbs\n\"Рис, бурый\";344;6,7;0,7;78,9,1\n", want: ';'},
+		{name: "single column", data: "name\nРис\n", want: ','},
+		{name: "header without newline", data: "name;calories", want: ';'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := csvDelimiter([]byte(tt.data)); got != tt.want {
+				t.Errorf("csvDelimiter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Ошибки разбора возвращаются до обращения к базе, поэтому сервису не нужен репозиторий
+func TestImportCSVParsesDetectedDelimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "semicolon with decimal commas",
+			data:    "\ufeffName;Calories;Protein;Fat;Carbs\nРис;344;6,7;0,7;78,9\nХлеб;много;8;1;48\n",
+			wantErr: "строка 3: некорректное значение calories",
+		},
+		{
+			name:    "comma",
+			data:    "name,calories,protein,fat,carbs\nРис,344,6.7,0.7,78.9\nРис,344,6.7,0.7,78.9\n",
+			wantErr: "строка 3: продукт \"Рис\" уже указан в строке 2",
+		},
+		{
+			name:    "missing column",
+			data:    "name;calories;protein;fat\nРис;344;6,7;0,7\n",
+			wantErr: "в заголовке нет столбца carbs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			foods := &foodService{log: testLogger()}
+
+			_, err := foods.ImportCSV(strings.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ImportCSV() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxGiftMessageLength = 500
+
+var ErrGiftNotFound = errors.New("подарок не найден")
+
+type GiftService interface {
+	// Send списывает стоимость категории с отправителя на счёт подарков;
+	// доступ получатель получит только после того, как примет подарок
+	Send(senderID uint, req models.CreateGiftRequest) (*models.Gift, error)
+	// Accept отклоняет подарок с возвратом денег, если категория у получателя уже есть
+	Accept(recipientID, giftID uint) (*models.Gift, error)
+	// Decline возвращает деньги отправителю
+	Decline(recipientID, giftID uint) (*models.Gift, error)
+	GetByID(userID, giftID uint) (*models.Gift, error)
+	ListSent(userID uint, status string) ([]models.Gift, error)
+	ListReceived(userID uint, status string) ([]models.Gift, error)
+	// ExpireDue возвращает отправителям деньги за подарки, не принятые до срока
+	ExpireDue(now time.Time) error
+}
+
+type giftService struct {
+	repo     repository.GiftRepository
+	ledger   LedgerService
+	trainers TrainerService
+	notifier NotificationService
+	db       *gorm.DB
+	ttl      time.Duration
+	log      *slog.Logger
+}
+
+// ttl — сколько подарок ждёт ответа получателя
+func NewGiftService(
+	repo repository.GiftRepository,
+	ledger LedgerService,
+	trainers TrainerService,
+	notifier NotificationService,
+	db *gorm.DB,
+	ttl time.Duration,
+	log *slog.Logger,
+) GiftService {
+	return &giftService{
+		repo:     repo,
+		ledger:   ledger,
+		trainers: trainers,
+		notifier: notifier,
+		db:       db,
+		ttl:      ttl,
+		log:      log,
+	}
+}
+
+func (s *giftService) Send(senderID uint, req models.CreateGiftRequest) (*models.Gift, error) {
+	if req.RecipientID == 0 || req.RecipientID == senderID {
+		return nil, errors.New("укажите другого пользователя в качестве получателя")
+	}
+
+	message := strings.TrimSpace(req.Message)
+	if len([]rune(message)) > maxGiftMessageLength {
+		return nil, fmt.Errorf("сообщение не должно превышать %d символов", maxGiftMessageLength)
+	}
+
+	var gift *models.Gift
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var recipient models.User
+		if err := tx.First(&recipient, req.RecipientID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("получатель не найден")
+			}
+			return fmt.Errorf("ошибка при поиске получателя %w", err)
+		}
+
+		var category models.Categories
+		if err := tx.First(&category, req.CategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("категория не найдена")
+			}
+			return fmt.Errorf("ошибка при поиске категории %w", err)
+		}
+
+		var owned int64
+		if err := tx.Model(&models.UserPlan{}).
+			Where("user_id = ? AND categories_id = ?", recipient.ID, category.ID).
+			Count(&owned).Error; err != nil {
+			return err
+		}
+		if owned > 0 {
+			return errors.New("у получателя уже есть эта категория")
+		}
+
+		gift = &models.Gift{
+			SenderID:     senderID,
+			RecipientID:  recipient.ID,
+			CategoriesID: category.ID,
+			Amount:       category.Price,
+			Message:      message,
+			Status:       models.GiftStatusPending,
+			ExpiresAt:    time.Now().Add(s.ttl),
+		}
+
+		repo := s.repo.WithTx(tx)
+		if err := repo.Create(gift); err != nil {
+			return fmt.Errorf("ошибка при создании подарка %w", err)
+		}
+
+		entry, err := s.ledger.ChargeTo(tx, senderID, category.Price, AccountGiftsEscrow, models.EntryKindGift,
+			fmt.Sprintf("Подарок «%s» пользователю %d", category.Name, recipient.ID),
+			fmt.Sprintf("gift:%d", gift.ID))
+		if err != nil {
+			return err
+		}
+		gift.JournalEntryID = &entry.ID
+
+		return repo.Update(gift)
+	})
+	if err != nil {
+		s.log.Warn("Подарок не отправлен",
+			"sender_id", senderID,
+			"recipient_id", req.RecipientID,
+			"category_id", req.CategoryID,
+			"error", err.Error())
+		return nil, err
+	}
+
+	s.log.Info("Подарок отправлен",
+		"id", gift.ID,
+		"sender_id", senderID,
+		"recipient_id", gift.RecipientID)
+
+	s.notify(gift, s.notifier.SendGiftReceived, gift.RecipientID, gift.SenderID)
+	return gift, nil
+}
+
+// Accept переводит деньги со счёта подарков в выручку и долю тренеру и открывает получателю категорию.
+// Если категория у получателя уже есть, подарок отклоняется и деньги возвращаются отправителю
+func (s *giftService) Accept(recipientID, giftID uint) (*models.Gift, error) {
+	gift, err := s.respond(recipientID, giftID, models.GiftStatusAccepted, func(tx *gorm.DB, gift *models.Gift) error {
+		if !gift.ExpiresAt.After(time.Now()) {
+			return errors.New("срок подарка истёк")
+		}
+
+		// получатель мог купить категорию, пока подарок ждал ответа; блокировка его строки
+		// выстраивает проверку в очередь с покупкой в userService.Payment
+		var recipient models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&recipient, gift.RecipientID).Error; err != nil {
+			return fmt.Errorf("ошибка при поиске получателя %w", err)
+		}
+
+		var owned int64
+		if err := tx.Model(&models.UserPlan{}).
+			Where("user_id = ? AND categories_id = ?", recipient.ID, gift.CategoriesID).
+			Count(&owned).Error; err != nil {
+			return err
+		}
+		if owned > 0 {
+			s.log.Info("Получатель уже владеет категорией, подарок отклонён",
+				"id", gift.ID,
+				"recipient_id", recipient.ID)
+			gift.Status = models.GiftStatusDeclined
+			return s.refund(tx, gift)
+		}
+
+		share, err := s.trainers.RevenueShare(tx, gift.CategoriesID, gift.Amount)
+		if err != nil {
+			return err
+		}
+
+		entry, err := s.ledger.Recognize(tx, AccountGiftsEscrow, gift.Amount, share, models.EntryKindGift,
+			fmt.Sprintf("Подарок %d принят", gift.ID),
+			fmt.Sprintf("gift:%d", gift.ID))
+		if err != nil {
+			return err
+		}
+		gift.SettlementEntryID = &entry.ID
+
+		userPlan := &models.UserPlan{
+			UserID:         gift.RecipientID,
+			CategoriesID:   gift.CategoriesID,
+			JournalEntryID: &entry.ID,
+			Source:         models.ProgrammeSourceGift,
+		}
+		if err := tx.Create(userPlan).Error; err != nil {
+			return fmt.Errorf("ошибка при записи покупки пользователя %w", err)
+		}
+		gift.UserPlanID = &userPlan.ID
+
+		// подарок становится активной программой, только если другой ещё нет
+		return tx.Model(&models.User{}).
+			Where("id = ? AND active_categories_id IS NULL", gift.RecipientID).
+			Update("active_categories_id", gift.CategoriesID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(gift, s.notifier.SendGiftDecision, gift.SenderID, gift.RecipientID)
+	return gift, nil
+}
+
+func (s *giftService) Decline(recipientID, giftID uint) (*models.Gift, error) {
+	gift, err := s.respond(recipientID, giftID, models.GiftStatusDeclined, s.refund)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(gift, s.notifier.SendGiftDecision, gift.SenderID, gift.RecipientID)
+	return gift, nil
+}
+
+func (s *giftService) GetByID(userID, giftID uint) (*models.Gift, error) {
+	gift, err := s.repo.GetByID(giftID)
+	if err != nil || (gift.SenderID != userID && gift.RecipientID != userID) {
+		return nil, ErrGiftNotFound
+	}
+
+	return gift, nil
+}
+
+func (s *giftService) ListSent(userID uint, status string) ([]models.Gift, error) {
+	return s.repo.ListSent(userID, status)
+}
+
+func (s *giftService) ListReceived(userID uint, status string) ([]models.Gift, error) {
+	return s.repo.ListReceived(userID, status)
+}
+
+func (s *giftService) ExpireDue(now time.Time) error {
+	ids, err := s.repo.ListExpiredIDs(now)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, id := range ids {
+		gift, err := s.expireOne(id, now)
+		if err != nil {
+			failed++
+			s.log.Error("Ошибка при обработке подарка",
+				"id", id,
+				"error", err.Error())
+			continue
+		}
+		if gift != nil {
+			s.notify(gift, s.notifier.SendGiftDecision, gift.SenderID, gift.RecipientID)
+		}
+	}
+
+	if len(ids) > 0 {
+		s.log.Info("Обработаны истёкшие подарки",
+			"count", len(ids),
+			"failed", failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("не удалось обработать подарков: %d", failed)
+	}
+
+	return nil
+}
+
+// expireOne возвращает nil без ошибки, если подарок уже обработан
+func (s *giftService) expireOne(id uint, now time.Time) (*models.Gift, error) {
+	var gift *models.Gift
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		locked, err := repo.Lock(id)
+		if err != nil {
+			return err
+		}
+
+		// получатель мог ответить, пока подарок ждал обработки
+		if locked.Status != models.GiftStatusPending || locked.ExpiresAt.After(now) {
+			return nil
+		}
+
+		if err := s.refund(tx, locked); err != nil {
+			return err
+		}
+
+		locked.Status = models.GiftStatusExpired
+		locked.RespondedAt = &now
+		if err := repo.Update(locked); err != nil {
+			return err
+		}
+
+		gift = locked
+		return nil
+	})
+
+	return gift, err
+}
+
+// refund возвращает отправителю деньги со счёта подарков
+func (s *giftService) refund(tx *gorm.DB, gift *models.Gift) error {
+	if gift.JournalEntryID == nil {
+		return nil
+	}
+
+	entry, err := s.ledger.Reverse(tx, *gift.JournalEntryID, LedgerEntry{
+		Kind:        models.EntryKindRefund,
+		Description: fmt.Sprintf("Возврат подарка %d", gift.ID),
+		Reference:   fmt.Sprintf("gift:%d", gift.ID),
+	})
+	if err != nil {
+		return err
+	}
+	gift.SettlementEntryID = &entry.ID
+
+	return nil
+}
+
+func (s *giftService) respond(
+	recipientID, giftID uint,
+	status string,
+	apply func(tx *gorm.DB, gift *models.Gift) error,
+) (*models.Gift, error) {
+	var gift *models.Gift
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		var err error
+		gift, err = repo.Lock(giftID)
+		if err != nil || gift.RecipientID != recipientID {
+			return ErrGiftNotFound
+		}
+
+		if gift.Status != models.GiftStatusPending {
+			return fmt.Errorf("подарок уже обработан: %s", gift.Status)
+		}
+
+		if err := apply(tx, gift); err != nil {
+			return err
+		}
+
+		// apply может сам завершить подарок другим статусом
+		now := time.Now()
+		if gift.Status == models.GiftStatusPending {
+			gift.Status = status
+		}
+		gift.RespondedAt = &now
+
+		return repo.Update(gift)
+	})
+	if err != nil {
+		s.log.Warn("Ошибка при ответе на подарок",
+			"id", giftID,
+			"status", status,
+			"error", err.Error())
+		return nil, err
+	}
+
+	s.log.Info("Получатель ответил на подарок",
+		"id", gift.ID,
+		"status", gift.Status)
+
+	return gift, nil
+}
+
+// notify загружает участников подарка и отправляет уведомление адресату to
+func (s *giftService) notify(
+	gift *models.Gift,
+	send func(to, other *models.User, category *models.Categories, gift *models.Gift) error,
+	toID, otherID uint,
+) {
+	var to, other models.User
+	if err := s.db.First(&to, toID).Error; err != nil {
+		s.log.Error("не удалось отправить уведомление о подарке", "err", err)
+		return
+	}
+	if err := s.db.First(&other, otherID).Error; err != nil {
+		s.log.Error("не удалось отправить уведомление о подарке", "err", err)
+		return
+	}
+
+	var category models.Categories
+	if err := s.db.Unscoped().First(&category, gift.CategoriesID).Error; err != nil {
+		s.log.Error("не удалось отправить уведомление о подарке", "err", err)
+		return
+	}
+
+	if err := send(&to, &other, &category, gift); err != nil {
+		s.log.Error("не удалось отправить уведомление", "err", err)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+)
+
+const maxIdempotencyKeyLength = 255
+
+var (
+	ErrIdempotencyKeyInvalid  = errors.New("некорректный Idempotency-Key")
+	ErrIdempotencyKeyMismatch = errors.New("Idempotency-Key уже использован с другим запросом")
+	ErrIdempotencyInProgress  = errors.New("запрос с этим Idempotency-Key ещё выполняется")
+)
+
+type IdempotencyService interface {
+	// Begin резервирует ключ. Если запрос с таким ключом уже завершён,
+	// возвращает сохранённую запись с заполненным CompletedAt
+	Begin(userID uint, key, fingerprint string) (*models.IdempotencyKey, error)
+	Complete(record *models.IdempotencyKey, status int, contentType, body string) error
+	Release(record *models.IdempotencyKey) error
+}
+
+type idempotencyService struct {
+	repo repository.IdempotencyRepository
+	log  *slog.Logger
+}
+
+func NewIdempotencyService(repo repository.IdempotencyRepository, log *slog.Logger) IdempotencyService {
+	return &idempotencyService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// RequestFingerprint — хэш метода, пути и тела запроса
+func RequestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", method, path)
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (s *idempotencyService) Begin(userID uint, key, fingerprint string) (*models.IdempotencyKey, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return nil, ErrIdempotencyKeyInvalid
+	}
+
+	record := &models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+	}
+
+	created, err := s.repo.Reserve(record)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении Idempotency-Key: %w", err)
+	}
+
+	if created {
+		return record, nil
+	}
+
+	existing, err := s.repo.Get(userID, key)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении Idempotency-Key: %w", err)
+	}
+
+	if existing.Fingerprint != fingerprint {
+		s.log.Warn("Idempotency-Key использован с другим запросом", "user_id", userID)
+		return nil, ErrIdempotencyKeyMismatch
+	}
+
+	if existing.CompletedAt == nil {
+		return nil, ErrIdempotencyInProgress
+	}
+
+	s.log.Info("Повтор запроса по Idempotency-Key", "user_id", userID, "id", existing.ID)
+	return existing, nil
+}
+
+func (s *idempotencyService) Complete(record *models.IdempotencyKey, status int, contentType, body string) error {
+	return s.repo.Complete(record.ID, status, contentType, body)
+}
+
+// Release удаляет ключ, чтобы клиент мог повторить запрос, завершившийся ошибкой сервера
+func (s *idempotencyService) Release(record *models.IdempotencyKey) error {
+	return s.repo.Delete(record.ID)
+}
@@ -0,0 +1,636 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AccountPlatformRevenue     = "platform:revenue"
+	AccountPlatformAdjustments = "platform:adjustments"
+	AccountPlatformOpening     = "platform:opening_balances"
+	AccountPaymentGateway      = "platform:payment_gateway"
+	AccountGiftsEscrow         = "platform:gifts_escrow"
+	AccountPlatformPayouts     = "platform:trainer_payouts"
+	// AccountTrainerReceivables — долг тренеров перед платформой: возвращённая покупателю
+	// доля тренера, которую уже нельзя списать с его заработка, потому что она выплачена
+	AccountTrainerReceivables = "platform:trainer_receivables"
+)
+
+var (
+	ErrInsufficientFunds    = errors.New("недостаточно средств на счету")
+	ErrInsufficientEarnings = errors.New("сумма выплаты больше долга платформы перед тренером")
+)
+
+// LedgerLine — одна строка будущей проводки
+type LedgerLine struct {
+	AccountID uint
+	Amount    int
+}
+
+// RevenueShare — часть выручки, которая зачисляется тренеру — владельцу категории.
+// Нулевой TrainerID означает, что вся выручка остаётся платформе
+type RevenueShare struct {
+	TrainerID uint
+	Amount    int
+}
+
+type LedgerEntry struct {
+	Kind        string
+	Description string
+	Reference   string
+	CreatedByID *uint
+	Lines       []LedgerLine
+}
+
+type LedgerService interface {
+	WalletAccount(tx *gorm.DB, userID uint) (*models.LedgerAccount, error)
+	SystemAccount(tx *gorm.DB, code string) (*models.LedgerAccount, error)
+	TrainerAccount(tx *gorm.DB, trainerID uint) (*models.LedgerAccount, error)
+	Record(tx *gorm.DB, entry LedgerEntry) (*models.JournalEntry, error)
+	Charge(tx *gorm.DB, userID uint, amount int, share RevenueShare, kind, description, reference string) (*models.JournalEntry, error)
+	ChargeTo(tx *gorm.DB, userID uint, amount int, target, kind, description, reference string) (*models.JournalEntry, error)
+	Credit(tx *gorm.DB, userID uint, amount int, source, kind, description, reference string) (*models.JournalEntry, error)
+	Transfer(tx *gorm.DB, from, to string, amount int, kind, description, reference string) (*models.JournalEntry, error)
+	Recognize(tx *gorm.DB, from string, amount int, share RevenueShare, kind, description, reference string) (*models.JournalEntry, error)
+	PayOut(tx *gorm.DB, trainerID uint, amount int, createdByID uint, description, reference string) (*models.JournalEntry, error)
+	Reverse(tx *gorm.DB, entryID uint, entry LedgerEntry) (*models.JournalEntry, error)
+	WalletAmount(tx *gorm.DB, entryID, userID uint) (int, error)
+
+	Balance(userID uint) (int, error)
+	TrainerBalance(trainerID uint) (int, error)
+	FillBalances(users []models.User) error
+	History(userID uint) ([]models.LedgerTransaction, error)
+	Adjust(adminID, userID uint, req models.AdjustmentRequest) (*models.JournalEntry, error)
+	ImportLegacyBalances() error
+}
+
+type ledgerService struct {
+	repo repository.LedgerRepository
+	db   *gorm.DB
+	log  *slog.Logger
+}
+
+func NewLedgerService(repo repository.LedgerRepository, db *gorm.DB, log *slog.Logger) LedgerService {
+	return &ledgerService{
+		repo: repo,
+		db:   db,
+		log:  log,
+	}
+}
+
+func WalletAccountCode(userID uint) string {
+	return fmt.Sprintf("wallet:user:%d", userID)
+}
+
+func (s *ledgerService) WalletAccount(tx *gorm.DB, userID uint) (*models.LedgerAccount, error) {
+	return s.repo.WithTx(tx).GetOrCreateAccount(
+		WalletAccountCode(userID),
+		fmt.Sprintf("Кошелёк пользователя %d", userID),
+		models.AccountKindWallet,
+		&userID,
+	)
+}
+
+func TrainerAccountCode(trainerID uint) string {
+	return fmt.Sprintf("earnings:trainer:%d", trainerID)
+}
+
+func (s *ledgerService) TrainerAccount(tx *gorm.DB, trainerID uint) (*models.LedgerAccount, error) {
+	return s.repo.WithTx(tx).GetOrCreateAccount(
+		TrainerAccountCode(trainerID),
+		fmt.Sprintf("Заработок тренера %d", trainerID),
+		models.AccountKindTrainer,
+		&trainerID,
+	)
+}
+
+func (s *ledgerService) SystemAccount(tx *gorm.DB, code string) (*models.LedgerAccount, error) {
+	return s.repo.WithTx(tx).GetOrCreateAccount(code, code, models.AccountKindSystem, nil)
+}
+
+// Record записывает сбалансированную проводку внутри переданной транзакции
+func (s *ledgerService) Record(tx *gorm.DB, entry LedgerEntry) (*models.JournalEntry, error) {
+	if len(entry.Lines) < 2 {
+		return nil, errors.New("проводка должна содержать минимум две строки")
+	}
+
+	sum := 0
+	postings := make([]models.Posting, 0, len(entry.Lines))
+	for _, line := range entry.Lines {
+		sum += line.Amount
+		postings = append(postings, models.Posting{
+			AccountID: line.AccountID,
+			Amount:    line.Amount,
+		})
+	}
+
+	if sum != 0 {
+		s.log.Error("Несбалансированная проводка", "kind", entry.Kind, "sum", sum)
+		return nil, fmt.Errorf("несбалансированная проводка: сумма %d", sum)
+	}
+
+	journal := &models.JournalEntry{
+		Kind:        entry.Kind,
+		Description: entry.Description,
+		Reference:   entry.Reference,
+		CreatedByID: entry.CreatedByID,
+		Postings:    postings,
+	}
+
+	if err := s.repo.WithTx(tx).CreateEntry(journal); err != nil {
+		return nil, fmt.Errorf("ошибка при записи проводки: %w", err)
+	}
+
+	return journal, nil
+}
+
+// Charge списывает сумму с кошелька пользователя в выручку платформы, отчисляя долю тренеру
+func (s *ledgerService) Charge(tx *gorm.DB, userID uint, amount int, share RevenueShare, kind, description, reference string) (*models.JournalEntry, error) {
+	credits, err := s.revenueLines(tx, amount, share)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.debitWallet(tx, userID, amount, credits, kind, description, reference)
+}
+
+// ChargeTo списывает сумму с кошелька пользователя на системный счёт target
+func (s *ledgerService) ChargeTo(tx *gorm.DB, userID uint, amount int, target, kind, description, reference string) (*models.JournalEntry, error) {
+	to, err := s.SystemAccount(tx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.debitWallet(tx, userID, amount, []LedgerLine{{AccountID: to.ID, Amount: amount}}, kind, description, reference)
+}
+
+// debitWallet списывает сумму с кошелька пользователя на счета из credits, если хватает средств
+func (s *ledgerService) debitWallet(tx *gorm.DB, userID uint, amount int, credits []LedgerLine, kind, description, reference string) (*models.JournalEntry, error) {
+	if amount < 0 {
+		return nil, errors.New("сумма списания не может быть отрицательной")
+	}
+
+	wallet, err := s.lockedWallet(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.repo.WithTx(tx).AccountBalance(wallet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if balance < amount {
+		s.log.Warn("Недостаточно средств на счету",
+			"user_id", userID,
+			"balance", balance,
+			"amount", amount)
+		return nil, ErrInsufficientFunds
+	}
+
+	return s.Record(tx, LedgerEntry{
+		Kind:        kind,
+		Description: description,
+		Reference:   reference,
+		Lines:       append([]LedgerLine{{AccountID: wallet.ID, Amount: -amount}}, credits...),
+	})
+}
+
+// revenueLines делит сумму между выручкой платформы и счётом заработка тренера
+func (s *ledgerService) revenueLines(tx *gorm.DB, amount int, share RevenueShare) ([]LedgerLine, error) {
+	if share.Amount < 0 || share.Amount > amount {
+		return nil, fmt.Errorf("доля тренера %d вне суммы %d", share.Amount, amount)
+	}
+
+	revenue, err := s.SystemAccount(tx, AccountPlatformRevenue)
+	if err != nil {
+		return nil, err
+	}
+
+	if share.TrainerID == 0 || share.Amount == 0 {
+		return []LedgerLine{{AccountID: revenue.ID, Amount: amount}}, nil
+	}
+
+	trainer, err := s.TrainerAccount(tx, share.TrainerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return []LedgerLine{
+		{AccountID: revenue.ID, Amount: amount - share.Amount},
+		{AccountID: trainer.ID, Amount: share.Amount},
+	}, nil
+}
+
+// Credit зачисляет сумму на кошелёк пользователя с системного счёта source
+func (s *ledgerService) Credit(tx *gorm.DB, userID uint, amount int, source, kind, description, reference string) (*models.JournalEntry, error) {
+	if amount <= 0 {
+		return nil, errors.New("сумма зачисления должна быть положительной")
+	}
+
+	wallet, err := s.WalletAccount(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := s.SystemAccount(tx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Record(tx, LedgerEntry{
+		Kind:        kind,
+		Description: description,
+		Reference:   reference,
+		Lines: []LedgerLine{
+			{AccountID: from.ID, Amount: -amount},
+			{AccountID: wallet.ID, Amount: amount},
+		},
+	})
+}
+
+// Transfer переводит сумму между системными счетами
+func (s *ledgerService) Transfer(tx *gorm.DB, from, to string, amount int, kind, description, reference string) (*models.JournalEntry, error) {
+	if amount < 0 {
+		return nil, errors.New("сумма перевода не может быть отрицательной")
+	}
+
+	fromAccount, err := s.SystemAccount(tx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toAccount, err := s.SystemAccount(tx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Record(tx, LedgerEntry{
+		Kind:        kind,
+		Description: description,
+		Reference:   reference,
+		Lines: []LedgerLine{
+			{AccountID: fromAccount.ID, Amount: -amount},
+			{AccountID: toAccount.ID, Amount: amount},
+		},
+	})
+}
+
+// Recognize переводит сумму с системного счёта from в выручку платформы, отчисляя долю тренеру
+func (s *ledgerService) Recognize(tx *gorm.DB, from string, amount int, share RevenueShare, kind, description, reference string) (*models.JournalEntry, error) {
+	if amount < 0 {
+		return nil, errors.New("сумма перевода не может быть отрицательной")
+	}
+
+	fromAccount, err := s.SystemAccount(tx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := s.revenueLines(tx, amount, share)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Record(tx, LedgerEntry{
+		Kind:        kind,
+		Description: description,
+		Reference:   reference,
+		Lines:       append([]LedgerLine{{AccountID: fromAccount.ID, Amount: -amount}}, credits...),
+	})
+}
+
+// PayOut списывает выплату со счёта заработка тренера; больше, чем заработано, выплатить нельзя
+func (s *ledgerService) PayOut(tx *gorm.DB, trainerID uint, amount int, createdByID uint, description, reference string) (*models.JournalEntry, error) {
+	if amount <= 0 {
+		return nil, errors.New("сумма выплаты должна быть положительной")
+	}
+
+	account, err := s.TrainerAccount(tx, trainerID)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := s.repo.WithTx(tx)
+	if err := repo.LockAccount(account.ID); err != nil {
+		return nil, err
+	}
+
+	owed, err := repo.AccountBalance(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if owed < amount {
+		s.log.Warn("Выплата больше заработка тренера",
+			"trainer_id", trainerID,
+			"owed", owed,
+			"amount", amount)
+		return nil, ErrInsufficientEarnings
+	}
+
+	payouts, err := s.SystemAccount(tx, AccountPlatformPayouts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Record(tx, LedgerEntry{
+		Kind:        models.EntryKindPayout,
+		Description: description,
+		Reference:   reference,
+		CreatedByID: &createdByID,
+		Lines: []LedgerLine{
+			{AccountID: account.ID, Amount: -amount},
+			{AccountID: payouts.ID, Amount: amount},
+		},
+	})
+}
+
+// Reverse записывает проводку, обратную entryID. Строки entry игнорируются —
+// они берутся из исходной проводки с противоположным знаком
+func (s *ledgerService) Reverse(tx *gorm.DB, entryID uint, entry LedgerEntry) (*models.JournalEntry, error) {
+	original, err := s.repo.WithTx(tx).GetEntry(entryID)
+	if err != nil {
+		return nil, fmt.Errorf("исходная проводка не найдена: %w", err)
+	}
+
+	entry.Lines = make([]LedgerLine, 0, len(original.Postings))
+	for _, posting := range original.Postings {
+		lines, err := s.reverseLine(tx, posting)
+		if err != nil {
+			return nil, err
+		}
+		entry.Lines = append(entry.Lines, lines...)
+	}
+
+	return s.Record(tx, entry)
+}
+
+// reverseLine возвращает строки, отменяющие posting. Со счёта заработка тренера
+// списывается не больше его баланса: остаток после выплаты ложится на AccountTrainerReceivables
+func (s *ledgerService) reverseLine(tx *gorm.DB, posting models.Posting) ([]LedgerLine, error) {
+	line := LedgerLine{AccountID: posting.AccountID, Amount: -posting.Amount}
+	if posting.Amount <= 0 {
+		return []LedgerLine{line}, nil
+	}
+
+	repo := s.repo.WithTx(tx)
+	account, err := repo.GetAccount(posting.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Kind != models.AccountKindTrainer {
+		return []LedgerLine{line}, nil
+	}
+
+	if err := repo.LockAccount(account.ID); err != nil {
+		return nil, err
+	}
+
+	owed, err := repo.AccountBalance(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if owed >= posting.Amount {
+		return []LedgerLine{line}, nil
+	}
+
+	receivables, err := s.SystemAccount(tx, AccountTrainerReceivables)
+	if err != nil {
+		return nil, err
+	}
+
+	covered := max(owed, 0)
+	s.log.Warn("Доля тренера уже выплачена, остаток записан в долг тренера",
+		"account_id", account.ID,
+		"owed", owed,
+		"shortfall", posting.Amount-covered)
+
+	lines := []LedgerLine{{AccountID: receivables.ID, Amount: covered - posting.Amount}}
+	if covered > 0 {
+		lines = append([]LedgerLine{{AccountID: account.ID, Amount: -covered}}, lines...)
+	}
+
+	return lines, nil
+}
+
+// WalletAmount возвращает сумму, списанную проводкой с кошелька пользователя
+func (s *ledgerService) WalletAmount(tx *gorm.DB, entryID, userID uint) (int, error) {
+	entry, err := s.repo.WithTx(tx).GetEntry(entryID)
+	if err != nil {
+		return 0, fmt.Errorf("проводка не найдена: %w", err)
+	}
+
+	wallet, err := s.WalletAccount(tx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	amount := 0
+	for _, posting := range entry.Postings {
+		if posting.AccountID == wallet.ID {
+			amount -= posting.Amount
+		}
+	}
+
+	return amount, nil
+}
+
+func (s *ledgerService) Balance(userID uint) (int, error) {
+	balances, err := s.repo.UserBalances([]uint{userID})
+	if err != nil {
+		return 0, err
+	}
+
+	return balances[userID], nil
+}
+
+// TrainerBalance возвращает, сколько платформа должна выплатить тренеру
+func (s *ledgerService) TrainerBalance(trainerID uint) (int, error) {
+	account, err := s.TrainerAccount(s.db, trainerID)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.AccountBalance(account.ID)
+}
+
+// FillBalances проставляет вычисленный баланс в переданных пользователей
+func (s *ledgerService) FillBalances(users []models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+
+	balances, err := s.repo.UserBalances(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range users {
+		users[i].Balance = balances[users[i].ID]
+	}
+
+	return nil
+}
+
+func (s *ledgerService) History(userID uint) ([]models.LedgerTransaction, error) {
+	wallet, err := s.WalletAccount(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.repo.ListAccountTransactions(wallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении истории операций: %w", err)
+	}
+
+	balance := 0
+	for i := range transactions {
+		balance += transactions[i].Amount
+		transactions[i].BalanceAfter = balance
+	}
+
+	// новые операции первыми
+	for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+		transactions[i], transactions[j] = transactions[j], transactions[i]
+	}
+
+	return transactions, nil
+}
+
+// Adjust — ручная корректировка баланса администратором с обязательной причиной
+func (s *ledgerService) Adjust(adminID, userID uint, req models.AdjustmentRequest) (*models.JournalEntry, error) {
+	if req.Amount == 0 {
+		return nil, errors.New("сумма корректировки не может быть нулевой")
+	}
+
+	if len(req.Reason) < 3 {
+		return nil, errors.New("укажите причину корректировки")
+	}
+
+	var entry *models.JournalEntry
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("пользователь не найден")
+			}
+			return fmt.Errorf("ошибка при поиске пользователя %w", err)
+		}
+
+		wallet, err := s.lockedWallet(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if req.Amount < 0 {
+			balance, err := s.repo.WithTx(tx).AccountBalance(wallet.ID)
+			if err != nil {
+				return err
+			}
+			if balance+req.Amount < 0 {
+				return ErrInsufficientFunds
+			}
+		}
+
+		adjustments, err := s.SystemAccount(tx, AccountPlatformAdjustments)
+		if err != nil {
+			return err
+		}
+
+		entry, err = s.Record(tx, LedgerEntry{
+			Kind:        models.EntryKindAdjustment,
+			Description: req.Reason,
+			Reference:   fmt.Sprintf("user:%d", userID),
+			CreatedByID: &adminID,
+			Lines: []LedgerLine{
+				{AccountID: wallet.ID, Amount: req.Amount},
+				{AccountID: adjustments.ID, Amount: -req.Amount},
+			},
+		})
+		return err
+	})
+	if err != nil {
+		s.log.Error("Ошибка при корректировке баланса",
+			"user_id", userID,
+			"admin_id", adminID,
+			"error", err.Error())
+		return nil, err
+	}
+
+	s.log.Info("Баланс скорректирован",
+		"user_id", userID,
+		"admin_id", adminID,
+		"amount", req.Amount,
+		"reason", req.Reason)
+
+	return entry, nil
+}
+
+// ImportLegacyBalances переносит значения старой колонки users.balance
+// в леджер входящими остатками и удаляет колонку
+func (s *ledgerService) ImportLegacyBalances() error {
+	if !s.db.Migrator().HasColumn("users", "balance") {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var rows []struct {
+			ID      uint
+			Balance int
+		}
+		if err := tx.Table("users").Select("id, balance").Where("balance <> 0").Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		opening, err := s.SystemAccount(tx, AccountPlatformOpening)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			wallet, err := s.WalletAccount(tx, row.ID)
+			if err != nil {
+				return err
+			}
+
+			if _, err := s.Record(tx, LedgerEntry{
+				Kind:        models.EntryKindOpeningBalance,
+				Description: "Перенос баланса из профиля пользователя",
+				Reference:   fmt.Sprintf("user:%d", row.ID),
+				Lines: []LedgerLine{
+					{AccountID: wallet.ID, Amount: row.Balance},
+					{AccountID: opening.ID, Amount: -row.Balance},
+				},
+			}); err != nil {
+				return err
+			}
+		}
+
+		s.log.Info("Балансы перенесены в леджер", "count", len(rows))
+		return tx.Migrator().DropColumn("users", "balance")
+	})
+}
+
+func (s *ledgerService) lockedWallet(tx *gorm.DB, userID uint) (*models.LedgerAccount, error) {
+	wallet, err := s.WalletAccount(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.WithTx(tx).LockAccount(wallet.ID); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"io"
+	"log/slog"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeLedgerRepository хранит счета и проводки в памяти; транзакции не нужны,
+// поэтому WithTx возвращает тот же репозиторий
+type fakeLedgerRepository struct {
+	accounts map[string]*models.LedgerAccount
+	entries  []*models.JournalEntry
+}
+
+func newFakeLedgerRepository() *fakeLedgerRepository {
+	return &fakeLedgerRepository{accounts: map[string]*models.LedgerAccount{}}
+}
+
+func (r *fakeLedgerRepository) WithTx(tx *gorm.DB) repository.LedgerRepository {
+	return r
+}
+
+func (r *fakeLedgerRepository) GetOrCreateAccount(code, name, kind string, userID *uint) (*models.LedgerAccount, error) {
+	if account, ok := r.accounts[code]; ok {
+		return account, nil
+	}
+
+	account := &models.LedgerAccount{Code: code, Name: name, Kind: kind, UserID: userID}
+	account.ID = uint(len(r.accounts) + 1)
+	r.accounts[code] = account
+	return account, nil
+}
+
+func (r *fakeLedgerRepository) GetAccount(id uint) (*models.LedgerAccount, error) {
+	for _, account := range r.accounts {
+		if account.ID == id {
+			return account, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeLedgerRepository) LockAccount(id uint) error {
+	return nil
+}
+
+func (r *fakeLedgerRepository) CreateEntry(entry *models.JournalEntry) error {
+	entry.ID = uint(len(r.entries) + 1)
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *fakeLedgerRepository) GetEntry(id uint) (*models.JournalEntry, error) {
+	if id == 0 || int(id) > len(r.entries) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.entries[id-1], nil
+}
+
+func (r *fakeLedgerRepository) AccountBalance(accountID uint) (int, error) {
+	balance := 0
+	for _, entry := range r.entries {
+		for _, posting := range entry.Postings {
+			if posting.AccountID == accountID {
+				balance += posting.Amount
+			}
+		}
+	}
+	return balance, nil
+}
+
+func (r *fakeLedgerRepository) UserBalances(userIDs []uint) (map[uint]int, error) {
+	balances := make(map[uint]int, len(userIDs))
+	for _, id := range userIDs {
+		balance, _ := r.AccountBalance(r.accounts[WalletAccountCode(id)].ID)
+		balances[id] = balance
+	}
+	return balances, nil
+}
+
+func (r *fakeLedgerRepository) ListAccountTransactions(accountID uint) ([]models.LedgerTransaction, error) {
+	return nil, nil
+}
+
+// balance возвращает баланс счёта по коду; несуществующий счёт считается пустым
+func (r *fakeLedgerRepository) balance(code string) int {
+	account, ok := r.accounts[code]
+	if !ok {
+		return 0
+	}
+	balance, _ := r.AccountBalance(account.ID)
+	return balance
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestLedger(t *testing.T) (*ledgerService, *fakeLedgerRepository) {
+	t.Helper()
+
+	repo := newFakeLedgerRepository()
+	return &ledgerService{repo: repo, log: testLogger()}, repo
+}
+
+// fund зачисляет пользователю amount со счёта пополнений
+func fund(t *testing.T, ledger *ledgerService, userID uint, amount int) {
+	t.Helper()
+
+	if _, err := ledger.Credit(nil, userID, amount, AccountPaymentGateway, models.EntryKindTopUp, "пополнение", "test"); err != nil {
+		t.Fatalf("Credit: %v", err)
+	}
+}
+
+func TestRecordRejectsInvalidEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []LedgerLine
+		wantErr bool
+	}{
+		{name: "balanced", lines: []LedgerLine{{AccountID: 1, Amount: -100}, {AccountID: 2, Amount: 100}}},
+		{name: "balanced with split", lines: []LedgerLine{{AccountID: 1, Amount: -100}, {AccountID: 2, Amount: 30}, {AccountID: 3, Amount: 70}}},
+		{name: "unbalanced", lines: []LedgerLine{{AccountID: 1, Amount: -100}, {AccountID: 2, Amount: 99}}, wantErr: true},
+		{name: "single line", lines: []LedgerLine{{AccountID: 1, Amount: 0}}, wantErr: true},
+		{name: "no lines", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger, repo := newTestLedger(t)
+
+			_, err := ledger.Record(nil, LedgerEntry{Kind: models.EntryKindAdjustment, Lines: tt.lines})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Record() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			wantEntries := 1
+			if tt.wantErr {
+				wantEntries = 0
+			}
+			if len(repo.entries) != wantEntries {
+				t.Errorf("записано проводок %d, ожидалось %d", len(repo.entries), wantEntries)
+			}
+		})
+	}
+}
+
+func TestChargeChecksWalletBalance(t *testing.T) {
+	tests := []struct {
+		name        string
+		funded      int
+		amount      int
+		wantErr     error
+		wantBalance int
+	}{
+		{name: "enough funds", funded: 500, amount: 200, wantBalance: 300},
+		{name: "exact balance", funded: 200, amount: 200, wantBalance: 0},
+		{name: "insufficient funds", funded: 100, amount: 150, wantErr: ErrInsufficientFunds, wantBalance: 100},
+		{name: "empty wallet", amount: 1, wantErr: ErrInsufficientFunds},
+		{name: "free item", funded: 0, amount: 0},
+	}
+
+	const userID = 7
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger, repo := newTestLedger(t)
+			if tt.funded > 0 {
+				fund(t, ledger, userID, tt.funded)
+			}
+
+			_, err := ledger.Charge(nil, userID, tt.amount, RevenueShare{}, models.EntryKindPurchase, "покупка", "category:1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Charge() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := repo.balance(WalletAccountCode(userID)); got != tt.wantBalance {
+				t.Errorf("баланс кошелька %d, ожидалось %d", got, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestChargeRejectsNegativeAmount(t *testing.T) {
+	ledger, _ := newTestLedger(t)
+	fund(t, ledger, 1, 100)
+
+	if _, err := ledger.Charge(nil, 1, -10, RevenueShare{}, models.EntryKindPurchase, "покупка", "category:1"); err == nil {
+		t.Fatal("Charge() с отрицательной суммой должен вернуть ошибку")
+	}
+}
+
+func TestChargeSplitsRevenueWithTrainer(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      int
+		share       RevenueShare
+		wantErr     bool
+		wantRevenue int
+		wantTrainer int
+	}{
+		{name: "no trainer", amount: 1000, wantRevenue: 1000},
+		{name: "trainer share", amount: 1000, share: RevenueShare{TrainerID: 3, Amount: 800}, wantRevenue: 200, wantTrainer: 800},
+		{name: "whole amount to trainer", amount: 1000, share: RevenueShare{TrainerID: 3, Amount: 1000}, wantTrainer: 1000},
+		{name: "zero share", amount: 1000, share: RevenueShare{TrainerID: 3}, wantRevenue: 1000},
+		{name: "share above amount", amount: 1000, share: RevenueShare{TrainerID: 3, Amount: 1001}, wantErr: true},
+		{name: "negative share", amount: 1000, share: RevenueShare{TrainerID: 3, Amount: -1}, wantErr: true},
+	}
+
+	const userID = 1
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger, repo := newTestLedger(t)
+			fund(t, ledger, userID, 5000)
+
+			_, err := ledger.Charge(nil, userID, tt.amount, tt.share, models.EntryKindPurchase, "покупка", "category:1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Charge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := repo.balance(AccountPlatformRevenue); got != tt.wantRevenue {
+				t.Errorf("выручка платформы %d, ожидалось %d", got, tt.wantRevenue)
+			}
+			if got := repo.balance(TrainerAccountCode(tt.share.TrainerID)); got != tt.wantTrainer {
+				t.Errorf("заработок тренера %d, ожидалось %d", got, tt.wantTrainer)
+			}
+
+			wantWallet := 5000
+			if !tt.wantErr {
+				wantWallet -= tt.amount
+			}
+			if got := repo.balance(WalletAccountCode(userID)); got != wantWallet {
+				t.Errorf("баланс кошелька %d, ожидалось %d", got, wantWallet)
+			}
+		})
+	}
+}
+
+func TestReverseRestoresBalances(t *testing.T) {
+	tests := []struct {
+		name  string
+		share RevenueShare
+	}{
+		{name: "platform only"},
+		{name: "with trainer share", share: RevenueShare{TrainerID: 3, Amount: 640}},
+	}
+
+	const userID = 1
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger, repo := newTestLedger(t)
+			fund(t, ledger, userID, 1000)
+
+			charge, err := ledger.Charge(nil, userID, 800, tt.share, models.EntryKindPurchase, "покупка", "category:1")
+			if err != nil {
+				t.Fatalf("Charge: %v", err)
+			}
+
+			reversal, err := ledger.Reverse(nil, charge.ID, LedgerEntry{
+				Kind: models.EntryKindRefund,
+				// строки из запроса игнорируются
+				Lines: []LedgerLine{{AccountID: 99, Amount: 1}},
+			})
+			if err != nil {
+				t.Fatalf("Reverse: %v", err)
+			}
+
+			if len(reversal.Postings) != len(charge.Postings) {
+				t.Fatalf("в возврате %d строк, в исходной проводке %d", len(reversal.Postings), len(charge.Postings))
+			}
+			for i, posting := range reversal.Postings {
+				original := charge.Postings[i]
+				if posting.AccountID != original.AccountID || posting.Amount != -original.Amount {
+					t.Errorf("строка %d: %+v не зеркальна %+v", i, posting, original)
+				}
+			}
+
+			for code, want := range map[string]int{
+				WalletAccountCode(userID):              1000,
+				AccountPlatformRevenue:                 0,
+				TrainerAccountCode(tt.share.TrainerID): 0,
+			} {
+				if got := repo.balance(code); got != want {
+					t.Errorf("баланс %s = %d, ожидалось %d", code, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReverseUnknownEntry(t *testing.T) {
+	ledger, _ := newTestLedger(t)
+
+	if _, err := ledger.Reverse(nil, 42, LedgerEntry{Kind: models.EntryKindRefund}); err == nil {
+		t.Fatal("Reverse() несуществующей проводки должен вернуть ошибку")
+	}
+}
+
+func TestPayOutLimitedByEarnings(t *testing.T) {
+	ledger, repo := newTestLedger(t)
+	fund(t, ledger, 1, 1000)
+	if _, err := ledger.Charge(nil, 1, 1000, RevenueShare{TrainerID: 3, Amount: 800}, models.EntryKindPurchase, "покупка", "category:1"); err != nil {
+		t.Fatalf("Charge: %v", err)
+	}
+
+	if _, err := ledger.PayOut(nil, 3, 801, 9, "выплата", "trainer:3"); !errors.Is(err, ErrInsufficientEarnings) {
+		t.Fatalf("PayOut() больше заработка: error = %v, want %v", err, ErrInsufficientEarnings)
+	}
+	if _, err := ledger.PayOut(nil, 3, 800, 9, "выплата", "trainer:3"); err != nil {
+		t.Fatalf("PayOut: %v", err)
+	}
+
+	if got := repo.balance(TrainerAccountCode(3)); got != 0 {
+		t.Errorf("долг перед тренером %d, ожидалось 0", got)
+	}
+	if got := repo.balance(AccountPlatformPayouts); got != 800 {
+		t.Errorf("выплачено %d, ожидалось 800", got)
+	}
+}
+
+func TestReverseAfterPayoutRecordsTrainerDebt(t *testing.T) {
+	tests := []struct {
+		name            string
+		payout          int
+		wantTrainer     int
+		wantReceivables int
+	}{
+		{name: "nothing paid out", payout: 0, wantTrainer: 200, wantReceivables: 0},
+		{name: "partly paid out", payout: 500, wantTrainer: 0, wantReceivables: -300},
+		{name: "fully paid out", payout: 800, wantTrainer: 0, wantReceivables: -600},
+	}
+
+	const userID, trainerID = 1, 3
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger, repo := newTestLedger(t)
+			fund(t, ledger, userID, 2000)
+
+			if _, err := ledger.Charge(nil, userID, 250, RevenueShare{TrainerID: trainerID, Amount: 200}, models.EntryKindPurchase, "покупка", "category:2"); err != nil {
+				t.Fatalf("Charge: %v", err)
+			}
+			charge, err := ledger.Charge(nil, userID, 1000, RevenueShare{TrainerID: trainerID, Amount: 600}, models.EntryKindPurchase, "покупка", "category:1")
+			if err != nil {
+				t.Fatalf("Charge: %v", err)
+			}
+			if tt.payout > 0 {
+				if _, err := ledger.PayOut(nil, trainerID, tt.payout, 9, "выплата", "trainer:3"); err != nil {
+					t.Fatalf("PayOut: %v", err)
+				}
+			}
+
+			if _, err := ledger.Reverse(nil, charge.ID, LedgerEntry{Kind: models.EntryKindRefund}); err != nil {
+				t.Fatalf("Reverse: %v", err)
+			}
+
+			for code, want := range map[string]int{
+				WalletAccountCode(userID):     1750,
+				AccountPlatformRevenue:        50,
+				TrainerAccountCode(trainerID): tt.wantTrainer,
+				AccountTrainerReceivables:     tt.wantReceivables,
+			} {
+				if got := repo.balance(code); got != want {
+					t.Errorf("баланс %s = %d, ожидалось %d", code, got, want)
+				}
+			}
+		})
+	}
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"healthy_body/internal/models"
 	"healthy_body/internal/repository"
 	"log/slog"
+	"slices"
 )
 
 type MealPlanItemsService interface {
@@ -17,15 +19,23 @@ type MealPlanItemsService interface {
 
 type mealPlanItemsService struct {
 	mealPlanItems repository.MealPlanItemRepository
+	mealPlans     repository.MealPlanRepository
+	foods         FoodService
 	logger        *slog.Logger
 }
 
 func NewMealPlanItemsService(
 	mealPlanItems repository.MealPlanItemRepository,
+	// mealPlans нужен, чтобы проверять день приёма пищи по длительности плана
+	mealPlans repository.MealPlanRepository,
+	// foods считает пищевую ценность блюд, привязанных к рецептам
+	foods FoodService,
 	logger *slog.Logger,
 ) MealPlanItemsService {
 	return &mealPlanItemsService{
 		mealPlanItems: mealPlanItems,
+		mealPlans:     mealPlans,
+		foods:         foods,
 		logger:        logger,
 	}
 }
@@ -35,10 +45,13 @@ func (s *mealPlanItemsService) CreateMealPlanItem(req models.CreateMealPlanItemR
 		s.logger.Warn("attempt to create item with empty meal plan id")
 		return nil, errors.New("meal_plan_id is required")
 	}
-	if req.Name == "" {
+	if req.Name == "" && req.RecipeID == nil {
 		s.logger.Warn("attempt to create item with empty name")
 		return nil, errors.New("name is required")
 	}
+	if req.Day == 0 {
+		req.Day = 1
+	}
 
 	item := &models.MealPlanItem{
 		Name:        req.Name,
@@ -46,8 +59,26 @@ func (s *mealPlanItemsService) CreateMealPlanItem(req models.CreateMealPlanItemR
 		Calories:    req.Calories,
 		Protein:     req.Protein,
 		Carbs:       req.Carbs,
+		Fat:         req.Fat,
+		Fibre:       req.Fibre,
+		Day:         req.Day,
+		MealSlot:    req.MealSlot,
+		RecipeID:    req.RecipeID,
+		Servings:    req.Servings,
+		DietTags:    req.DietTags,
+		Allergens:   req.Allergens,
 		MealPlanId:  req.MealPlanId,
 	}
+	if item.Servings == 0 {
+		item.Servings = 1
+	}
+
+	if err := s.applyRecipe(item); err != nil {
+		return nil, err
+	}
+	if err := s.validate(item); err != nil {
+		return nil, err
+	}
 
 	if err := s.mealPlanItems.Create(item); err != nil {
 		s.logger.Error("failed to create meal plan item", "err", err)
@@ -94,10 +125,45 @@ func (s *mealPlanItemsService) UpdateMealPlanItem(id uint, req *models.UpdateMea
 	if req.Carbs != nil {
 		mealPlanItems.Carbs = *req.Carbs
 	}
+	if req.Fat != nil {
+		mealPlanItems.Fat = *req.Fat
+	}
+	if req.Fibre != nil {
+		mealPlanItems.Fibre = *req.Fibre
+	}
+	if req.Day != nil {
+		mealPlanItems.Day = *req.Day
+	}
+	if req.MealSlot != nil {
+		mealPlanItems.MealSlot = *req.MealSlot
+	}
+	if req.RecipeID != nil {
+		if *req.RecipeID == 0 {
+			mealPlanItems.RecipeID = nil
+		} else {
+			mealPlanItems.RecipeID = req.RecipeID
+		}
+	}
+	if req.Servings != nil {
+		mealPlanItems.Servings = *req.Servings
+	}
+	if req.DietTags != nil {
+		mealPlanItems.DietTags = *req.DietTags
+	}
+	if req.Allergens != nil {
+		mealPlanItems.Allergens = *req.Allergens
+	}
 	if req.MealPlanId != nil {
 		mealPlanItems.MealPlanId = *req.MealPlanId
 	}
 
+	if err := s.applyRecipe(mealPlanItems); err != nil {
+		return nil, err
+	}
+	if err := s.validate(mealPlanItems); err != nil {
+		return nil, err
+	}
+
 	if err := s.mealPlanItems.Update(mealPlanItems); err != nil {
 		s.logger.Error("failed to update meal plan items", "id", id)
 		return nil, err
@@ -131,3 +197,75 @@ func (s *mealPlanItemsService) DeleteMealPlanItem(id uint) error {
 	s.logger.Info("meal plan item deleted successfully", "id", id)
 	return nil
 }
+
+// applyRecipe считает калории и нутриенты блюда по рецепту вместо введённых вручную
+func (s *mealPlanItemsService) applyRecipe(item *models.MealPlanItem) error {
+	if item.RecipeID == nil {
+		return nil
+	}
+	if item.Servings <= 0 {
+		s.logger.Warn("invalid servings", "servings", item.Servings)
+		return errors.New("servings must be greater than zero")
+	}
+
+	recipe, err := s.foods.GetRecipe(*item.RecipeID)
+	if err != nil {
+		s.logger.Warn("recipe for item not found", "recipe_id", *item.RecipeID)
+		return err
+	}
+
+	nutrition := recipeServingNutrition(recipe, item.Servings)
+	item.Calories = nutrition.Calories
+	item.Protein = nutrition.Protein
+	item.Fat = nutrition.Fat
+	item.Carbs = nutrition.Carbs
+	item.Fibre = nutrition.Fibre
+	if item.Name == "" {
+		item.Name = recipe.Name
+	}
+
+	return nil
+}
+
+// validate проверяет пищевую ценность, приём пищи, теги и то, что день укладывается в TotalDays плана;
+// диетические теги и аллергены приводятся к единому виду
+func (s *mealPlanItemsService) validate(item *models.MealPlanItem) error {
+	if item.Calories < 0 || item.Protein < 0 || item.Carbs < 0 || item.Fat < 0 || item.Fibre < 0 {
+		s.logger.Warn("attempt to save item with negative nutrients", "id", item.ID)
+		return errors.New("calories and nutrients must not be negative")
+	}
+	if item.MealSlot != "" && !models.IsValidMealSlot(item.MealSlot) {
+		s.logger.Warn("invalid meal slot", "meal_slot", item.MealSlot)
+		return fmt.Errorf("meal_slot must be one of %v", models.MealSlots)
+	}
+
+	tags, ok := models.NormalizeTags(item.DietTags, models.DietTags)
+	if !ok {
+		s.logger.Warn("invalid diet tags", "diet_tags", item.DietTags)
+		return fmt.Errorf("diet_tags must be from %v", models.DietTags)
+	}
+	// веганское блюдо подходит и вегетарианцам
+	if slices.Contains(tags, models.DietVegan) && !slices.Contains(tags, models.DietVegetarian) {
+		tags, _ = models.NormalizeTags(append(tags, models.DietVegetarian), models.DietTags)
+	}
+	item.DietTags = tags
+
+	allergens, ok := models.NormalizeTags(item.Allergens, models.Allergens)
+	if !ok {
+		s.logger.Warn("invalid allergens", "allergens", item.Allergens)
+		return fmt.Errorf("allergens must be from %v", models.Allergens)
+	}
+	item.Allergens = allergens
+
+	mealPlan, err := s.mealPlans.GetMealPlanByID(item.MealPlanId)
+	if err != nil {
+		s.logger.Warn("meal plan for item not found", "meal_plan_id", item.MealPlanId)
+		return errors.New("meal plan not found")
+	}
+	if item.Day < 1 || item.Day > mealPlan.TotalDays {
+		s.logger.Warn("item day is out of meal plan range", "day", item.Day, "total_days", mealPlan.TotalDays)
+		return fmt.Errorf("day must be between 1 and %d", mealPlan.TotalDays)
+	}
+
+	return nil
+}
@@ -2,34 +2,54 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"healthy_body/internal/calculator"
 	"healthy_body/internal/models"
 	"healthy_body/internal/repository"
 	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strings"
 )
 
 type MealPlanService interface {
 	CreateMealPlan(req models.CreateMealPlanRequest) (*models.MealPlan, error)
-	ListMealPlan() ([]models.MealPlan, error)
+	ListMealPlan(filter models.MealPlanFilter) ([]models.MealPlan, error)
 	UpdateMealPlan(id uint, req *models.UpdateMealPlanRequest) (*models.MealPlan, error)
 	GetMealPlanByID(id uint) (*models.MealPlan, error)
 	DeleteMealPlan(id uint) error
+	// Nutrition суммирует пищевую ценность плана по дням, приёмам пищи и за весь план.
+	// План передаётся целиком, чтобы считать и по черновику, и по опубликованной версии
+	Nutrition(mealPlan *models.MealPlan) *models.MealPlanNutrition
+	// DietWarnings перечисляет, чем план не подходит под диеты и аллергены пользователя
+	DietWarnings(mealPlan *models.MealPlan, prefs models.DietaryPreferences) []string
+	// ShoppingList собирает продукты для блюд плана за дни с fromDay по toDay включительно;
+	// нулевые границы означают весь план
+	ShoppingList(mealPlan *models.MealPlan, fromDay, toDay int) (*models.ShoppingList, error)
+	// CompareWithNeeds сравнивает среднесуточную пищевую ценность плана с целями пользователя
+	CompareWithNeeds(mealPlan *models.MealPlan, targets calculator.MacroTargets) *models.MealPlanComparison
 }
 
 type mealPlanService struct {
 	mealPlans repository.MealPlanRepository
 	logger    *slog.Logger
 	category  CategoryServices
+	foods     FoodService
 }
 
 func NewMealPlanService(
 	mealPlans repository.MealPlanRepository,
 	logger *slog.Logger,
 	category CategoryServices,
+	// foods нужен для состава блюд в списке покупок
+	foods FoodService,
 ) MealPlanService {
 	return &mealPlanService{
 		mealPlans: mealPlans,
 		logger:    logger,
 		category:  category,
+		foods:     foods,
 	}
 }
 
@@ -62,13 +82,22 @@ func (s *mealPlanService) CreateMealPlan(req models.CreateMealPlanRequest) (*mod
 	return &mealPlan, nil
 }
 
-func (s *mealPlanService) ListMealPlan() ([]models.MealPlan, error) {
+func (s *mealPlanService) ListMealPlan(filter models.MealPlanFilter) ([]models.MealPlan, error) {
 	mealPlans, err := s.mealPlans.List()
 	if err != nil {
 		s.logger.Error("failed to fetch meal plans")
 		return nil, err
 	}
 
+	filtered := mealPlans[:0]
+	for i := range mealPlans {
+		fillDietInfo(&mealPlans[i])
+		if filter.Matches(&mealPlans[i]) {
+			filtered = append(filtered, mealPlans[i])
+		}
+	}
+	mealPlans = filtered
+
 	if len(mealPlans) == 0 {
 		s.logger.Warn("service: no meal plans")
 	}
@@ -96,6 +125,16 @@ func (s *mealPlanService) UpdateMealPlan(id uint, req *models.UpdateMealPlanRequ
 		mealPlan.CategoriesID = req.CategoriesID
 	}
 	if req.TotalDays != nil {
+		if *req.TotalDays <= 0 {
+			s.logger.Error("invalid total_days")
+			return nil, errors.New("total days must be greater than zero")
+		}
+		for _, meal := range mealPlan.Meals {
+			if meal.Day > *req.TotalDays {
+				s.logger.Warn("total_days is less than item day", "id", id, "day", meal.Day)
+				return nil, fmt.Errorf("meal plan has items on day %d, total days can not be less", meal.Day)
+			}
+		}
 		mealPlan.TotalDays = *req.TotalDays
 	}
 
@@ -130,5 +169,342 @@ func (s *mealPlanService) GetMealPlanByID(id uint) (*models.MealPlan, error) {
 		s.logger.Error("failed to get meal plan", "id", id, "error", err)
 		return nil, err
 	}
+	fillDietInfo(mealPlan)
 	return mealPlan, nil
 }
+
+func (s *mealPlanService) DietWarnings(mealPlan *models.MealPlan, prefs models.DietaryPreferences) []string {
+	var warnings []string
+
+	for _, tag := range prefs.DietTags {
+		if slices.Contains(mealPlan.DietTags, tag) {
+			continue
+		}
+		var meals []string
+		for _, meal := range mealPlan.Meals {
+			if !slices.Contains(meal.DietTags, tag) {
+				meals = append(meals, meal.Name)
+			}
+		}
+		if len(meals) == 0 {
+			warnings = append(warnings, fmt.Sprintf("meal plan is not marked as %s", tag))
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("not %s: %s", tag, strings.Join(meals, ", ")))
+	}
+
+	for _, allergen := range prefs.Allergens {
+		if !slices.Contains(mealPlan.Allergens, allergen) {
+			continue
+		}
+		var meals []string
+		for _, meal := range mealPlan.Meals {
+			if slices.Contains(meal.Allergens, allergen) {
+				meals = append(meals, meal.Name)
+			}
+		}
+		warnings = append(warnings, fmt.Sprintf("contains %s: %s", allergen, strings.Join(meals, ", ")))
+	}
+
+	return warnings
+}
+
+// fillDietInfo выводит теги плана из блюд: тег плана должен быть у каждого блюда,
+// аллерген плана — хотя бы у одного
+func fillDietInfo(mealPlan *models.MealPlan) {
+	mealPlan.DietTags = []string{}
+	mealPlan.Allergens = []string{}
+	if len(mealPlan.Meals) == 0 {
+		return
+	}
+
+	for _, tag := range models.DietTags {
+		all := true
+		for _, meal := range mealPlan.Meals {
+			if !slices.Contains(meal.DietTags, tag) {
+				all = false
+				break
+			}
+		}
+		if all {
+			mealPlan.DietTags = append(mealPlan.DietTags, tag)
+		}
+	}
+
+	for _, allergen := range models.Allergens {
+		for _, meal := range mealPlan.Meals {
+			if slices.Contains(meal.Allergens, allergen) {
+				mealPlan.Allergens = append(mealPlan.Allergens, allergen)
+				break
+			}
+		}
+	}
+}
+
+func (s *mealPlanService) Nutrition(mealPlan *models.MealPlan) *models.MealPlanNutrition {
+	days := mealPlan.TotalDays
+	if days < 1 {
+		days = 1
+	}
+
+	nutrition := &models.MealPlanNutrition{
+		MealPlanID: mealPlan.ID,
+		TotalDays:  days,
+		Days:       make([]models.DayNutrition, days),
+	}
+	// слоты дня идут в порядке приёмов пищи, блюда без слота — в конце
+	slotOrder := map[string]int{"": len(models.MealSlots)}
+	for i, slot := range models.MealSlots {
+		slotOrder[slot] = i
+	}
+	slots := make([][]models.MealSlotNutrition, days)
+	for i := range slots {
+		nutrition.Days[i].Day = i + 1
+		slots[i] = make([]models.MealSlotNutrition, len(models.MealSlots)+1)
+		for slot, index := range slotOrder {
+			slots[i][index].MealSlot = slot
+		}
+	}
+
+	for _, meal := range mealPlan.Meals {
+		day := meal.Day
+		if day < 1 || day > days {
+			s.logger.Warn("meal plan item is out of plan days", "id", meal.ID, "day", meal.Day)
+			continue
+		}
+		index, ok := slotOrder[meal.MealSlot]
+		if !ok {
+			index = slotOrder[""]
+		}
+
+		slot := &slots[day-1][index]
+		slot.Items++
+		addNutrition(&slot.Totals, meal)
+		addNutrition(&nutrition.Days[day-1].Totals, meal)
+		addNutrition(&nutrition.Totals, meal)
+	}
+
+	for i := range nutrition.Days {
+		dayNutrition := &nutrition.Days[i]
+		dayNutrition.Totals = roundNutrition(dayNutrition.Totals)
+		dayNutrition.Macros = macroSplit(dayNutrition.Totals)
+		dayNutrition.Slots = []models.MealSlotNutrition{}
+		for _, slot := range slots[i] {
+			if slot.Items > 0 {
+				slot.Totals = roundNutrition(slot.Totals)
+				dayNutrition.Slots = append(dayNutrition.Slots, slot)
+			}
+		}
+	}
+
+	total := nutrition.Totals
+	nutrition.Totals = roundNutrition(total)
+	nutrition.DailyAverage = roundNutrition(models.NutritionTotals{
+		Calories: total.Calories / float64(days),
+		Protein:  total.Protein / float64(days),
+		Fat:      total.Fat / float64(days),
+		Carbs:    total.Carbs / float64(days),
+		Fibre:    total.Fibre / float64(days),
+	})
+	nutrition.Macros = macroSplit(total)
+
+	return nutrition
+}
+
+func (s *mealPlanService) CompareWithNeeds(mealPlan *models.MealPlan, targets calculator.MacroTargets) *models.MealPlanComparison {
+	nutrition := s.Nutrition(mealPlan)
+
+	daily := nutrition.DailyAverage
+	goal := models.NutritionTotals{
+		Calories: targets.Calories,
+		Protein:  targets.ProteinG,
+		Fat:      targets.FatG,
+		Carbs:    targets.CarbsG,
+		Fibre:    targets.FibreG,
+	}
+
+	comparison := &models.MealPlanComparison{
+		MealPlanID:   nutrition.MealPlanID,
+		Days:         nutrition.TotalDays,
+		DailyAverage: daily,
+		Targets:      goal,
+		Difference: roundNutrition(models.NutritionTotals{
+			Calories: daily.Calories - goal.Calories,
+			Protein:  daily.Protein - goal.Protein,
+			Fat:      daily.Fat - goal.Fat,
+			Carbs:    daily.Carbs - goal.Carbs,
+			Fibre:    daily.Fibre - goal.Fibre,
+		}),
+	}
+	if goal.Calories > 0 {
+		comparison.CaloriesPercent = calculator.Round(daily.Calories / goal.Calories * 100)
+	}
+
+	return comparison
+}
+
+func (s *mealPlanService) ShoppingList(mealPlan *models.MealPlan, fromDay, toDay int) (*models.ShoppingList, error) {
+	days := max(mealPlan.TotalDays, 1)
+	if fromDay == 0 && toDay == 0 {
+		fromDay, toDay = 1, days
+	}
+	if fromDay < 1 || toDay < fromDay || toDay > days {
+		s.logger.Warn("invalid shopping list days", "id", mealPlan.ID, "from", fromDay, "to", toDay)
+		return nil, fmt.Errorf("days must be within 1-%d", days)
+	}
+
+	list := &models.ShoppingList{
+		MealPlanID:    mealPlan.ID,
+		MealPlanName:  mealPlan.Name,
+		FromDay:       fromDay,
+		ToDay:         toDay,
+		Aisles:        []models.ShoppingListAisle{},
+		WithoutRecipe: []string{},
+	}
+
+	recipes := map[uint]*models.Recipe{}
+	lines := map[shoppingKey]*shoppingLine{}
+	for _, meal := range mealPlan.Meals {
+		if meal.Day < fromDay || meal.Day > toDay {
+			continue
+		}
+		if meal.RecipeID == nil {
+			list.WithoutRecipe = append(list.WithoutRecipe, meal.Name)
+			continue
+		}
+
+		recipe, ok := recipes[*meal.RecipeID]
+		if !ok {
+			var err error
+			recipe, err = s.foods.GetRecipe(*meal.RecipeID)
+			if err != nil {
+				s.logger.Error("failed to get recipe for shopping list", "recipe_id", *meal.RecipeID, "error", err)
+				return nil, err
+			}
+			recipes[recipe.ID] = recipe
+		}
+
+		scale := meal.Servings / float64(max(recipe.Servings, 1))
+		for _, ingredient := range recipe.Ingredients {
+			if ingredient.Food == nil {
+				continue
+			}
+			dimension, amount := baseAmount(ingredient.Unit, ingredient.Quantity*scale)
+			key := shoppingKey{foodID: ingredient.FoodID, dimension: dimension}
+			line, ok := lines[key]
+			if !ok {
+				line = &shoppingLine{food: ingredient.Food, dimension: dimension}
+				lines[key] = line
+			}
+			line.amount += amount
+		}
+	}
+
+	aisles := map[string][]models.ShoppingListItem{}
+	for _, line := range lines {
+		aisle := line.food.Aisle
+		if aisle == "" {
+			aisle = models.DefaultAisle
+		}
+		quantity, unit := displayAmount(line.dimension, line.amount)
+		aisles[aisle] = append(aisles[aisle], models.ShoppingListItem{
+			FoodID:   line.food.ID,
+			Name:     line.food.Name,
+			Quantity: quantity,
+			Unit:     unit,
+		})
+	}
+
+	for aisle, items := range aisles {
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].Name != items[j].Name {
+				return items[i].Name < items[j].Name
+			}
+			return items[i].Unit < items[j].Unit
+		})
+		list.Aisles = append(list.Aisles, models.ShoppingListAisle{Aisle: aisle, Items: items})
+	}
+	// отдел «прочее» всегда в конце списка
+	sort.Slice(list.Aisles, func(i, j int) bool {
+		if (list.Aisles[i].Aisle == models.DefaultAisle) != (list.Aisles[j].Aisle == models.DefaultAisle) {
+			return list.Aisles[j].Aisle == models.DefaultAisle
+		}
+		return list.Aisles[i].Aisle < list.Aisles[j].Aisle
+	})
+
+	return list, nil
+}
+
+const (
+	dimensionMass   = "mass"
+	dimensionVolume = "volume"
+	dimensionPieces = "pieces"
+)
+
+type shoppingKey struct {
+	foodID    uint
+	dimension string
+}
+
+type shoppingLine struct {
+	food      *models.Food
+	dimension string
+	amount    float64
+}
+
+// baseAmount переводит количество в граммы, миллилитры или штуки.
+// Ложки считаются по массе, как и при расчёте пищевой ценности рецепта
+func baseAmount(unit string, quantity float64) (string, float64) {
+	switch unit {
+	case models.UnitMillilitre:
+		return dimensionVolume, quantity
+	case models.UnitLitre:
+		return dimensionVolume, quantity * 1000
+	case models.UnitPiece:
+		return dimensionPieces, quantity
+	}
+
+	return dimensionMass, quantity * unitGrams[unit]
+}
+
+// displayAmount выбирает единицу для списка покупок: от 1000 г — килограммы,
+// от 1000 мл — литры, штуки округляются вверх
+func displayAmount(dimension string, amount float64) (float64, string) {
+	switch dimension {
+	case dimensionPieces:
+		return math.Ceil(amount - 1e-9), models.UnitPiece
+	case dimensionVolume:
+		if amount >= 1000 {
+			return calculator.Round(amount / 1000), models.UnitLitre
+		}
+		return math.Round(amount), models.UnitMillilitre
+	}
+
+	if amount >= 1000 {
+		return calculator.Round(amount / 1000), models.UnitKilogram
+	}
+	return math.Round(amount), models.UnitGram
+}
+
+func addNutrition(totals *models.NutritionTotals, meal models.MealPlanItem) {
+	totals.Calories += meal.Calories
+	totals.Protein += meal.Protein
+	totals.Fat += meal.Fat
+	totals.Carbs += meal.Carbs
+	totals.Fibre += meal.Fibre
+}
+
+func roundNutrition(totals models.NutritionTotals) models.NutritionTotals {
+	return models.NutritionTotals{
+		Calories: calculator.Round(totals.Calories),
+		Protein:  calculator.Round(totals.Protein),
+		Fat:      calculator.Round(totals.Fat),
+		Carbs:    calculator.Round(totals.Carbs),
+		Fibre:    calculator.Round(totals.Fibre),
+	}
+}
+
+func macroSplit(totals models.NutritionTotals) models.MacroSplit {
+	protein, fat, carbs := calculator.EnergySplit(totals.Protein, totals.Fat, totals.Carbs)
+	return models.MacroSplit{ProteinPercent: protein, FatPercent: fat, CarbsPercent: carbs}
+}
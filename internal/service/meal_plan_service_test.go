@@ -0,0 +1,64 @@
+package service
+
+import (
+	"healthy_body/internal/models"
+	"testing"
+)
+
+func TestShoppingAmounts(t *testing.T) {
+	type amount struct {
+		unit     string
+		quantity float64
+	}
+
+	tests := []struct {
+		name          string
+		amounts       []amount
+		wantDimension string
+		wantQuantity  float64
+		wantUnit      string
+	}{
+		{name: "grams", amounts: []amount{{models.UnitGram, 250}}, wantDimension: dimensionMass, wantQuantity: 250, wantUnit: models.UnitGram},
+		{name: "grams and kilograms merge", amounts: []amount{{models.UnitGram, 600}, {models.UnitKilogram, 0.5}}, wantDimension: dimensionMass, wantQuantity: 1.1, wantUnit: models.UnitKilogram},
+		{name: "spoons are mass", amounts: []amount{{models.UnitTablespoon, 2}, {models.UnitTeaspoon, 1}, {models.UnitGram, 10}}, wantDimension: dimensionMass, wantQuantity: 45, wantUnit: models.UnitGram},
+		{name: "grams rounded", amounts: []amount{{models.UnitGram, 12.4}, {models.UnitGram, 0.3}}, wantDimension: dimensionMass, wantQuantity: 13, wantUnit: models.UnitGram},
+		{name: "millilitres", amounts: []amount{{models.UnitMillilitre, 200}, {models.UnitMillilitre, 150}}, wantDimension: dimensionVolume, wantQuantity: 350, wantUnit: models.UnitMillilitre},
+		{name: "millilitres and litres merge", amounts: []amount{{models.UnitMillilitre, 750}, {models.UnitLitre, 0.5}}, wantDimension: dimensionVolume, wantQuantity: 1.25, wantUnit: models.UnitLitre},
+		{name: "pieces rounded up", amounts: []amount{{models.UnitPiece, 1.5}, {models.UnitPiece, 0.75}}, wantDimension: dimensionPieces, wantQuantity: 3, wantUnit: models.UnitPiece},
+		// погрешность дробей не добавляет лишнюю штуку
+		{name: "whole pieces stay whole", amounts: []amount{{models.UnitPiece, 0.1}, {models.UnitPiece, 0.2}, {models.UnitPiece, 0.7}}, wantDimension: dimensionPieces, wantQuantity: 1, wantUnit: models.UnitPiece},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dimension string
+			var total float64
+			for i, a := range tt.amounts {
+				d, value := baseAmount(a.unit, a.quantity)
+				if i > 0 && d != dimension {
+					t.Fatalf("%s попал в измерение %s, а не %s", a.unit, d, dimension)
+				}
+				dimension = d
+				total += value
+			}
+			if dimension != tt.wantDimension {
+				t.Fatalf("измерение %s, ожидалось %s", dimension, tt.wantDimension)
+			}
+
+			quantity, unit := displayAmount(dimension, total)
+			if quantity != tt.wantQuantity || unit != tt.wantUnit {
+				t.Errorf("displayAmount() = %v %s, want %v %s", quantity, unit, tt.wantQuantity, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestBaseAmountKeepsDimensionsApart(t *testing.T) {
+	mass, _ := baseAmount(models.UnitGram, 100)
+	volume, _ := baseAmount(models.UnitMillilitre, 100)
+	pieces, _ := baseAmount(models.UnitPiece, 1)
+
+	if mass == volume || mass == pieces || volume == pieces {
+		t.Errorf("граммы (%s), миллилитры (%s) и штуки (%s) не должны складываться", mass, volume, pieces)
+	}
+}
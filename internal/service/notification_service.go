@@ -6,4 +6,9 @@ import (
 
 type NotificationService interface {
 	SendPaymentSuccess(user *models.User, category *models.Categories) error
+	SendRefundDecision(user *models.User, category *models.Categories, refund *models.RefundRequest) error
+	// SendGiftReceived сообщает получателю о новом подарке
+	SendGiftReceived(recipient, sender *models.User, category *models.Categories, gift *models.Gift) error
+	// SendGiftDecision сообщает отправителю, что подарок принят, отклонён или истёк
+	SendGiftDecision(sender, recipient *models.User, category *models.Categories, gift *models.Gift) error
 }
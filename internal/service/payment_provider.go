@@ -0,0 +1,148 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"sync"
+)
+
+var (
+	ErrInvalidSignature = errors.New("неверная подпись уведомления")
+	ErrPaymentNotFound  = errors.New("платёж не найден у провайдера")
+)
+
+// PaymentIntent — платёж, созданный у провайдера; Status принимает
+// значения models.TopUpStatus*
+type PaymentIntent struct {
+	ID              string
+	Amount          int
+	Status          string
+	ConfirmationURL string
+}
+
+// PaymentEvent — уведомление провайдера об изменении статуса платежа
+type PaymentEvent struct {
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status"`
+	Amount    int    `json:"amount"`
+}
+
+// PaymentProvider — платёжный шлюз, через который пользователь пополняет кошелёк
+type PaymentProvider interface {
+	Name() string
+	// CreateIntent создаёт платёж и возвращает ссылку, по которой пользователь его оплачивает
+	CreateIntent(amount int, reference string) (*PaymentIntent, error)
+	// Confirm запрашивает у провайдера актуальное состояние платежа
+	Confirm(paymentID string) (*PaymentIntent, error)
+	// HandleCallback проверяет подпись уведомления и разбирает его
+	HandleCallback(payload []byte, signature string) (*PaymentEvent, error)
+}
+
+const FakeProviderName = "fake"
+
+// FakePaymentProvider хранит платежи в памяти и подписывает уведомления
+// HMAC-SHA256; нужен для локального запуска и демонстраций
+type FakePaymentProvider struct {
+	secret []byte
+
+	mu       sync.Mutex
+	payments map[string]*PaymentIntent
+}
+
+func NewFakePaymentProvider(secret string) *FakePaymentProvider {
+	return &FakePaymentProvider{
+		secret:   []byte(secret),
+		payments: make(map[string]*PaymentIntent),
+	}
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakePaymentProvider) CreateIntent(amount int, reference string) (*PaymentIntent, error) {
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	id := "fake_" + hex.EncodeToString(raw)
+	intent := &PaymentIntent{
+		ID:              id,
+		Amount:          amount,
+		Status:          models.TopUpStatusPending,
+		ConfirmationURL: fmt.Sprintf("/wallet/fake/%s/pay", id),
+	}
+
+	p.mu.Lock()
+	p.payments[id] = intent
+	p.mu.Unlock()
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakePaymentProvider) Confirm(paymentID string) (*PaymentIntent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.payments[paymentID]
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakePaymentProvider) HandleCallback(payload []byte, signature string) (*PaymentEvent, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.sign(payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event PaymentEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("некорректное уведомление: %w", err)
+	}
+
+	return &event, nil
+}
+
+// Pay имитирует оплату на стороне провайдера: помечает платёж успешным
+// и возвращает подписанное уведомление, которое провайдер отправил бы на callback
+func (p *FakePaymentProvider) Pay(paymentID string) (payload []byte, signature string, err error) {
+	p.mu.Lock()
+	intent, ok := p.payments[paymentID]
+	if ok {
+		intent.Status = models.TopUpStatusSucceeded
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		return nil, "", ErrPaymentNotFound
+	}
+
+	payload, err = json.Marshal(PaymentEvent{
+		PaymentID: intent.ID,
+		Status:    models.TopUpStatusSucceeded,
+		Amount:    intent.Amount,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return payload, hex.EncodeToString(p.sign(payload)), nil
+}
+
+func (p *FakePaymentProvider) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,449 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPlanNotFound        = errors.New("план не найден")
+	ErrPlanNotPublished    = errors.New("план ещё не опубликован")
+	ErrPlanVersionNotFound = errors.New("версия плана не найдена")
+	ErrPlanPrivate         = errors.New("личный план клиента не публикуется")
+)
+
+type PlanVersionService interface {
+	// PublishExercisePlan сохраняет текущий черновик плана как новую неизменяемую версию
+	PublishExercisePlan(planID, userID uint, req models.PublishPlanRequest) (*models.PlanVersion, error)
+	PublishMealPlan(planID, userID uint, req models.PublishPlanRequest) (*models.PlanVersion, error)
+	// Versions возвращает историю публикаций плана, от новых версий к старым
+	Versions(kind string, planID uint) ([]models.PlanVersion, error)
+	// ExercisePlanFor возвращает план в том виде, в каком его должен видеть user: авторам —
+	// черновик или запрошенную версию, купившим программу — закреплённую версию, остальным — последнюю.
+	// Личные планы клиентов не публикуются и всегда отдаются черновиком
+	ExercisePlanFor(user *models.User, planID uint, version int) (*models.ExercisePlan, error)
+	MealPlanFor(user *models.User, planID uint, version int) (*models.MealPlan, error)
+	// ResolveExercisePlan и ResolveMealPlan делают то же для уже загруженного черновика,
+	// чтобы списки не перечитывали каждый план
+	ResolveExercisePlan(user *models.User, draft *models.ExercisePlan) (*models.ExercisePlan, error)
+	ResolveMealPlan(user *models.User, draft *models.MealPlan) (*models.MealPlan, error)
+	// ResolveCategory заменяет черновики планов категории версиями, которые должен видеть user;
+	// неопубликованные планы видят только авторы
+	ResolveCategory(user *models.User, category *models.Categories) error
+	// ProgrammeVersions показывает, какие версии планов видит покупатель и есть ли новее
+	ProgrammeVersions(userID, categoryID uint) (*models.ProgrammeVersions, error)
+	// UpgradeProgramme переводит покупку на последние опубликованные версии планов
+	UpgradeProgramme(userID, categoryID uint) (*models.ProgrammeVersions, error)
+	ImportLegacyPlanVersions() error
+}
+
+type planVersionService struct {
+	repo          repository.PlanVersionRepository
+	exercisePlans repository.ExercisePlanRepo
+	mealPlans     repository.MealPlanRepository
+	policy        AccessPolicy
+	db            *gorm.DB
+	log           *slog.Logger
+}
+
+func NewPlanVersionService(
+	repo repository.PlanVersionRepository,
+	exercisePlans repository.ExercisePlanRepo,
+	mealPlans repository.MealPlanRepository,
+	// policy отличает авторов, которым доступны черновики, от покупателей
+	policy AccessPolicy,
+	db *gorm.DB,
+	log *slog.Logger,
+) PlanVersionService {
+	return &planVersionService{
+		repo:          repo,
+		exercisePlans: exercisePlans,
+		mealPlans:     mealPlans,
+		policy:        policy,
+		db:            db,
+		log:           log,
+	}
+}
+
+func (s *planVersionService) PublishExercisePlan(planID, userID uint, req models.PublishPlanRequest) (*models.PlanVersion, error) {
+	plan, err := s.exercisePlans.GetByIDExercisePlan(planID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if plan.ClientID != nil {
+		return nil, ErrPlanPrivate
+	}
+
+	return s.publish(models.PlanKindExercise, planID, userID, req.Notes, time.Now(), plan)
+}
+
+func (s *planVersionService) PublishMealPlan(planID, userID uint, req models.PublishPlanRequest) (*models.PlanVersion, error) {
+	plan, err := s.mealPlans.GetMealPlanByID(planID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if plan.ClientID != nil {
+		return nil, ErrPlanPrivate
+	}
+
+	return s.publish(models.PlanKindMeal, planID, userID, req.Notes, time.Now(), plan)
+}
+
+func (s *planVersionService) publish(kind string, planID, userID uint, notes string, at time.Time, plan any) (*models.PlanVersion, error) {
+	snapshot, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &models.PlanVersion{
+		PlanKind:    kind,
+		PlanID:      planID,
+		Notes:       notes,
+		PublishedBy: userID,
+		PublishedAt: at,
+		Snapshot:    string(snapshot),
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.LockPlan(kind, planID); err != nil {
+			return err
+		}
+
+		version.Version = 1
+		latest, err := repo.Latest(kind, planID)
+		if err == nil {
+			version.Version = latest.Version + 1
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return repo.Create(version)
+	})
+	if err != nil {
+		s.log.Error("Ошибка при публикации плана", "kind", kind, "plan_id", planID, "error", err)
+		return nil, err
+	}
+
+	s.log.Info("План опубликован", "kind", kind, "plan_id", planID, "version", version.Version, "user_id", userID)
+	return version, nil
+}
+
+func (s *planVersionService) Versions(kind string, planID uint) ([]models.PlanVersion, error) {
+	return s.repo.List(kind, planID)
+}
+
+func (s *planVersionService) ExercisePlanFor(user *models.User, planID uint, number int) (*models.ExercisePlan, error) {
+	draft, err := s.exercisePlans.GetByIDExercisePlan(planID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.exercisePlanVersion(user, draft, number)
+}
+
+func (s *planVersionService) ResolveExercisePlan(user *models.User, draft *models.ExercisePlan) (*models.ExercisePlan, error) {
+	return s.exercisePlanVersion(user, draft, 0)
+}
+
+func (s *planVersionService) exercisePlanVersion(user *models.User, draft *models.ExercisePlan, number int) (*models.ExercisePlan, error) {
+	if draft.ClientID != nil {
+		return draft, nil
+	}
+
+	planID := draft.ID
+	version, latest, err := s.resolve(user, models.PlanKindExercise, planID, draft.CategoriesID, number)
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		draft.LatestVersion = latest
+		return draft, nil
+	}
+
+	var plan models.ExercisePlan
+	if err := json.Unmarshal([]byte(version.Snapshot), &plan); err != nil {
+		s.log.Error("Повреждён снимок плана", "kind", version.PlanKind, "plan_id", planID, "version", version.Version, "error", err)
+		return nil, err
+	}
+	plan.Version = version.Version
+	plan.LatestVersion = latest
+
+	return &plan, nil
+}
+
+func (s *planVersionService) MealPlanFor(user *models.User, planID uint, number int) (*models.MealPlan, error) {
+	draft, err := s.mealPlans.GetMealPlanByID(planID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mealPlanVersion(user, draft, number)
+}
+
+func (s *planVersionService) ResolveMealPlan(user *models.User, draft *models.MealPlan) (*models.MealPlan, error) {
+	return s.mealPlanVersion(user, draft, 0)
+}
+
+func (s *planVersionService) mealPlanVersion(user *models.User, draft *models.MealPlan, number int) (*models.MealPlan, error) {
+	if draft.ClientID != nil {
+		fillDietInfo(draft)
+		return draft, nil
+	}
+
+	planID := draft.ID
+
+	var categoryID uint
+	if draft.CategoriesID != nil {
+		categoryID = *draft.CategoriesID
+	}
+
+	version, latest, err := s.resolve(user, models.PlanKindMeal, planID, categoryID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := draft
+	if version != nil {
+		plan = &models.MealPlan{}
+		if err := json.Unmarshal([]byte(version.Snapshot), plan); err != nil {
+			s.log.Error("Повреждён снимок плана", "kind", version.PlanKind, "plan_id", planID, "version", version.Version, "error", err)
+			return nil, err
+		}
+		plan.Version = version.Version
+	}
+	plan.LatestVersion = latest
+	fillDietInfo(plan)
+
+	return plan, nil
+}
+
+func (s *planVersionService) ResolveCategory(user *models.User, category *models.Categories) error {
+	exercisePlans := make([]models.ExercisePlan, 0, len(category.ExercisePlans))
+	for i := range category.ExercisePlans {
+		plan, err := s.ResolveExercisePlan(user, &category.ExercisePlans[i])
+		if errors.Is(err, ErrPlanNotPublished) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		exercisePlans = append(exercisePlans, *plan)
+	}
+
+	mealPlans := make([]models.MealPlan, 0, len(category.MealPlans))
+	for i := range category.MealPlans {
+		plan, err := s.ResolveMealPlan(user, &category.MealPlans[i])
+		if errors.Is(err, ErrPlanNotPublished) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		mealPlans = append(mealPlans, *plan)
+	}
+
+	category.ExercisePlans = exercisePlans
+	category.MealPlans = mealPlans
+	return nil
+}
+
+// resolve выбирает версию плана для пользователя; nil означает черновик
+func (s *planVersionService) resolve(user *models.User, kind string, planID, categoryID uint, number int) (*models.PlanVersion, int, error) {
+	latest, err := s.repo.Latest(kind, planID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, 0, err
+	}
+	latestNumber := 0
+	if latest != nil {
+		latestNumber = latest.Version
+	}
+
+	if user != nil && s.policy.Can(user.Role, PermAuthorPlans) {
+		if number == 0 {
+			return nil, latestNumber, nil
+		}
+		version, err := s.repo.Get(kind, planID, number)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrPlanVersionNotFound
+		}
+		return version, latestNumber, err
+	}
+
+	if latest == nil {
+		return nil, 0, ErrPlanNotPublished
+	}
+	if user == nil || categoryID == 0 {
+		return latest, latestNumber, nil
+	}
+
+	userPlan, err := s.repo.GetUserPlan(user.ID, categoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return latest, latestNumber, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	version, err := s.repo.PublishedBefore(kind, planID, pinnedAt(userPlan))
+	if err != nil {
+		return nil, 0, err
+	}
+	return version, latestNumber, nil
+}
+
+func (s *planVersionService) ProgrammeVersions(userID, categoryID uint) (*models.ProgrammeVersions, error) {
+	userPlan, err := s.repo.GetUserPlan(userID, categoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProgrammeNotOwned
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.programmeVersions(userPlan)
+}
+
+func (s *planVersionService) UpgradeProgramme(userID, categoryID uint) (*models.ProgrammeVersions, error) {
+	userPlan, err := s.repo.GetUserPlan(userID, categoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProgrammeNotOwned
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.repo.PinUserPlan(userPlan.ID, now); err != nil {
+		return nil, err
+	}
+	userPlan.VersionPinnedAt = &now
+
+	s.log.Info("Программа переведена на последние версии планов", "user_id", userID, "categories_id", categoryID)
+	return s.programmeVersions(userPlan)
+}
+
+func (s *planVersionService) programmeVersions(userPlan *models.UserPlan) (*models.ProgrammeVersions, error) {
+	result := &models.ProgrammeVersions{
+		CategoriesID: userPlan.CategoriesID,
+		PinnedAt:     pinnedAt(userPlan),
+		Plans:        []models.PinnedPlanVersion{},
+	}
+
+	exercisePlans, err := s.repo.CategoryExercisePlans(userPlan.CategoriesID)
+	if err != nil {
+		return nil, err
+	}
+	for _, plan := range exercisePlans {
+		if err := s.addPinned(result, models.PlanKindExercise, plan.ID, plan.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	mealPlans, err := s.repo.CategoryMealPlans(userPlan.CategoriesID)
+	if err != nil {
+		return nil, err
+	}
+	for _, plan := range mealPlans {
+		if err := s.addPinned(result, models.PlanKindMeal, plan.ID, plan.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// addPinned добавляет план в сводку; неопубликованные планы покупателю не видны и пропускаются
+func (s *planVersionService) addPinned(result *models.ProgrammeVersions, kind string, planID uint, name string) error {
+	latest, err := s.repo.Latest(kind, planID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	pinned, err := s.repo.PublishedBefore(kind, planID, result.PinnedAt)
+	if err != nil {
+		return err
+	}
+
+	result.Plans = append(result.Plans, models.PinnedPlanVersion{
+		PlanKind:      kind,
+		PlanID:        planID,
+		Name:          name,
+		Version:       pinned.Version,
+		LatestVersion: latest.Version,
+	})
+	if pinned.Version < latest.Version {
+		result.UpgradeAvailable = true
+	}
+
+	return nil
+}
+
+// ImportLegacyPlanVersions публикует первую версию каждого плана, созданного до появления версий,
+// с датой создания плана, чтобы все прежние покупатели видели план таким, как сейчас
+func (s *planVersionService) ImportLegacyPlanVersions() error {
+	count := 0
+
+	exerciseIDs, err := s.repo.UnversionedPlanIDs(models.PlanKindExercise)
+	if err != nil {
+		return err
+	}
+	for _, id := range exerciseIDs {
+		plan, err := s.exercisePlans.GetByIDExercisePlan(id)
+		if err != nil {
+			return err
+		}
+		if _, err := s.publish(models.PlanKindExercise, id, 0, "Перенос плана, созданного до появления версий", plan.CreatedAt, plan); err != nil {
+			return err
+		}
+		count++
+	}
+
+	mealIDs, err := s.repo.UnversionedPlanIDs(models.PlanKindMeal)
+	if err != nil {
+		return err
+	}
+	for _, id := range mealIDs {
+		plan, err := s.mealPlans.GetMealPlanByID(id)
+		if err != nil {
+			return err
+		}
+		if _, err := s.publish(models.PlanKindMeal, id, 0, "Перенос плана, созданного до появления версий", plan.CreatedAt, plan); err != nil {
+			return err
+		}
+		count++
+	}
+
+	if count > 0 {
+		s.log.Info("Опубликованы первые версии существующих планов", "count", count)
+	}
+	return nil
+}
+
+func pinnedAt(userPlan *models.UserPlan) time.Time {
+	if userPlan.VersionPinnedAt != nil {
+		return *userPlan.VersionPinnedAt
+	}
+	return userPlan.CreatedAt
+}
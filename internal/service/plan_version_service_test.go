@@ -0,0 +1,164 @@
+package service
+
+import (
+	"encoding/json"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakePlanVersionRepository хранит опубликованные версии и покупки в памяти;
+// методы публикации тестам не нужны и не реализованы
+type fakePlanVersionRepository struct {
+	repository.PlanVersionRepository
+	versions  map[string][]models.PlanVersion // по kind, версии от старых к новым
+	userPlans map[uint]*models.UserPlan       // по пользователю
+}
+
+func (r *fakePlanVersionRepository) planVersions(kind string, planID uint) []models.PlanVersion {
+	var result []models.PlanVersion
+	for _, version := range r.versions[kind] {
+		if version.PlanID == planID {
+			result = append(result, version)
+		}
+	}
+	return result
+}
+
+func (r *fakePlanVersionRepository) Latest(kind string, planID uint) (*models.PlanVersion, error) {
+	versions := r.planVersions(kind, planID)
+	if len(versions) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &versions[len(versions)-1], nil
+}
+
+func (r *fakePlanVersionRepository) PublishedBefore(kind string, planID uint, at time.Time) (*models.PlanVersion, error) {
+	versions := r.planVersions(kind, planID)
+	if len(versions) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	result := versions[0]
+	for _, version := range versions {
+		if !version.PublishedAt.After(at) {
+			result = version
+		}
+	}
+	return &result, nil
+}
+
+func (r *fakePlanVersionRepository) GetUserPlan(userID, categoryID uint) (*models.UserPlan, error) {
+	userPlan, ok := r.userPlans[userID]
+	if !ok || userPlan.CategoriesID != categoryID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return userPlan, nil
+}
+
+func snapshotVersion(t *testing.T, kind string, planID uint, number int, at time.Time, plan any) models.PlanVersion {
+	t.Helper()
+
+	snapshot, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return models.PlanVersion{PlanKind: kind, PlanID: planID, Version: number, PublishedAt: at, Snapshot: string(snapshot)}
+}
+
+func TestResolveCategoryHidesDrafts(t *testing.T) {
+	january := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	february := january.AddDate(0, 1, 0)
+	march := january.AddDate(0, 2, 0)
+	mealCategory := uint(1)
+
+	repo := &fakePlanVersionRepository{
+		versions: map[string][]models.PlanVersion{
+			models.PlanKindExercise: {
+				snapshotVersion(t, models.PlanKindExercise, 10, 1, january, models.ExercisePlan{Name: "силовая v1", CategoriesID: 1}),
+				snapshotVersion(t, models.PlanKindExercise, 10, 2, march, models.ExercisePlan{Name: "силовая v2", CategoriesID: 1}),
+			},
+			models.PlanKindMeal: {
+				snapshotVersion(t, models.PlanKindMeal, 20, 1, january, models.MealPlan{Name: "меню v1", CategoriesID: &mealCategory}),
+			},
+		},
+		userPlans: map[uint]*models.UserPlan{},
+	}
+	purchase := &models.UserPlan{UserID: 1, CategoriesID: 1}
+	purchase.CreatedAt = february
+	repo.userPlans[1] = purchase
+	versions := &planVersionService{repo: repo, policy: NewAccessPolicy(), log: testLogger()}
+
+	buyer := &models.User{Role: models.RoleClient}
+	buyer.ID = 1
+	client := &models.User{Role: models.RoleClient}
+	client.ID = 2
+	trainer := &models.User{Role: models.RoleTrainer}
+	trainer.ID = 3
+
+	tests := []struct {
+		name          string
+		user          *models.User
+		wantExercise  []string
+		wantMeal      []string
+		wantVersion   int
+		wantLatestNum int
+	}{
+		{name: "buyer sees pinned version", user: buyer, wantExercise: []string{"силовая v1"}, wantMeal: []string{"меню v1"}, wantVersion: 1, wantLatestNum: 2},
+		{name: "client without purchase sees latest", user: client, wantExercise: []string{"силовая v2"}, wantMeal: []string{"меню v1"}, wantVersion: 2, wantLatestNum: 2},
+		{name: "guest sees latest", wantExercise: []string{"силовая v2"}, wantMeal: []string{"меню v1"}, wantVersion: 2, wantLatestNum: 2},
+		{name: "author sees drafts", user: trainer, wantExercise: []string{"силовая черновик", "новый план"}, wantMeal: []string{"меню черновик"}, wantLatestNum: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category := &models.Categories{
+				ExercisePlans: []models.ExercisePlan{
+					{Model: gorm.Model{ID: 10}, Name: "силовая черновик", CategoriesID: 1},
+					{Model: gorm.Model{ID: 11}, Name: "новый план", CategoriesID: 1},
+				},
+				MealPlans: []models.MealPlan{
+					{Model: gorm.Model{ID: 20}, Name: "меню черновик", CategoriesID: &mealCategory},
+				},
+			}
+			category.ID = 1
+
+			if err := versions.ResolveCategory(tt.user, category); err != nil {
+				t.Fatalf("ResolveCategory: %v", err)
+			}
+
+			var exercise, meal []string
+			for _, plan := range category.ExercisePlans {
+				exercise = append(exercise, plan.Name)
+			}
+			for _, plan := range category.MealPlans {
+				meal = append(meal, plan.Name)
+			}
+			if !slices.Equal(exercise, tt.wantExercise) || !slices.Equal(meal, tt.wantMeal) {
+				t.Fatalf("планы %v и %v, ожидались %v и %v", exercise, meal, tt.wantExercise, tt.wantMeal)
+			}
+
+			first := category.ExercisePlans[0]
+			if first.Version != tt.wantVersion || first.LatestVersion != tt.wantLatestNum {
+				t.Errorf("версия %d из %d, ожидалась %d из %d", first.Version, first.LatestVersion, tt.wantVersion, tt.wantLatestNum)
+			}
+		})
+	}
+}
+
+func TestResolvePrivatePlanKeepsDraft(t *testing.T) {
+	versions := &planVersionService{repo: &fakePlanVersionRepository{}, policy: NewAccessPolicy(), log: testLogger()}
+	clientID := uint(2)
+	draft := &models.ExercisePlan{Name: "личный план", ClientID: &clientID}
+
+	plan, err := versions.ResolveExercisePlan(&models.User{Role: models.RoleClient}, draft)
+	if err != nil {
+		t.Fatalf("ResolveExercisePlan: %v", err)
+	}
+	if plan != draft {
+		t.Errorf("личный план должен отдаваться черновиком, получено %+v", plan)
+	}
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrPromoNotFound = errors.New("промокод не найден")
+
+// PromoApplication — результат проверки промокода для конкретной покупки
+type PromoApplication struct {
+	Promo         *models.PromoCode
+	TargetType    string
+	TargetID      uint
+	OriginalPrice int
+	Discount      int
+	FinalPrice    int
+}
+
+type PromoService interface {
+	Create(req models.CreatePromoCodeRequest) (*models.PromoCode, error)
+	List() ([]models.PromoCode, error)
+	Update(id uint, req models.UpdatePromoCodeRequest) (*models.PromoCode, error)
+	Redemptions(id uint) ([]models.PromoRedemption, error)
+	Quote(userID uint, req models.PromoQuoteRequest) (*models.PromoQuote, error)
+
+	// Apply проверяет промокод внутри транзакции покупки и блокирует его до её конца
+	Apply(tx *gorm.DB, userID uint, code, targetType string, targetID uint, price int) (*PromoApplication, error)
+	// Redeem фиксирует применение промокода, проверенного через Apply
+	Redeem(tx *gorm.DB, userID uint, app *PromoApplication, entryID uint) error
+	// Release возвращает промокоду применение, если оплаченную проводкой entryID покупку вернули
+	Release(tx *gorm.DB, entryID uint) error
+}
+
+type promoService struct {
+	repo repository.PromoRepository
+	db   *gorm.DB
+	log  *slog.Logger
+}
+
+func NewPromoService(repo repository.PromoRepository, db *gorm.DB, log *slog.Logger) PromoService {
+	return &promoService{
+		repo: repo,
+		db:   db,
+		log:  log,
+	}
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *promoService) Create(req models.CreatePromoCodeRequest) (*models.PromoCode, error) {
+	code := normalizePromoCode(req.Code)
+	if len(code) < 3 {
+		return nil, errors.New("промокод должен содержать минимум 3 символа")
+	}
+
+	switch req.DiscountType {
+	case models.PromoDiscountPercent:
+		if req.Value <= 0 || req.Value > 100 {
+			return nil, errors.New("процент скидки должен быть от 1 до 100")
+		}
+	case models.PromoDiscountFixed:
+		if req.Value <= 0 {
+			return nil, errors.New("размер скидки должен быть положительным")
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный тип скидки: %q", req.DiscountType)
+	}
+
+	if req.MaxRedemptions < 0 || req.MaxPerUser < 0 {
+		return nil, errors.New("лимиты применений не могут быть отрицательными")
+	}
+
+	if req.ValidFrom != nil && req.ValidUntil != nil && !req.ValidUntil.After(*req.ValidFrom) {
+		return nil, errors.New("окончание действия должно быть позже начала")
+	}
+
+	promo := &models.PromoCode{
+		Code:           code,
+		DiscountType:   req.DiscountType,
+		Value:          req.Value,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerUser:     req.MaxPerUser,
+		Active:         true,
+	}
+	for _, id := range req.CategoryIDs {
+		promo.Scopes = append(promo.Scopes, models.PromoCodeScope{TargetType: models.PromoTargetCategory, TargetID: id})
+	}
+	for _, id := range req.SubscriptionIDs {
+		promo.Scopes = append(promo.Scopes, models.PromoCodeScope{TargetType: models.PromoTargetSubscription, TargetID: id})
+	}
+
+	if err := s.repo.Create(promo); err != nil {
+		return nil, fmt.Errorf("ошибка при создании промокода: %w", err)
+	}
+
+	s.log.Info("Промокод создан", "id", promo.ID, "code", promo.Code)
+	return promo, nil
+}
+
+func (s *promoService) List() ([]models.PromoCode, error) {
+	return s.repo.List()
+}
+
+func (s *promoService) Update(id uint, req models.UpdatePromoCodeRequest) (*models.PromoCode, error) {
+	promo, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrPromoNotFound
+	}
+
+	if req.Active != nil {
+		promo.Active = *req.Active
+	}
+	if req.ValidFrom != nil {
+		promo.ValidFrom = req.ValidFrom
+	}
+	if req.ValidUntil != nil {
+		promo.ValidUntil = req.ValidUntil
+	}
+	if req.MaxRedemptions != nil {
+		if *req.MaxRedemptions < 0 {
+			return nil, errors.New("лимит применений не может быть отрицательным")
+		}
+		promo.MaxRedemptions = *req.MaxRedemptions
+	}
+	if req.MaxPerUser != nil {
+		if *req.MaxPerUser < 0 {
+			return nil, errors.New("лимит применений не может быть отрицательным")
+		}
+		promo.MaxPerUser = *req.MaxPerUser
+	}
+
+	if promo.ValidFrom != nil && promo.ValidUntil != nil && !promo.ValidUntil.After(*promo.ValidFrom) {
+		return nil, errors.New("окончание действия должно быть позже начала")
+	}
+
+	if err := s.repo.Update(promo); err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении промокода: %w", err)
+	}
+
+	return promo, nil
+}
+
+func (s *promoService) Redemptions(id uint) ([]models.PromoRedemption, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, ErrPromoNotFound
+	}
+
+	return s.repo.ListRedemptions(id)
+}
+
+// Quote показывает цену с промокодом, ничего не записывая
+func (s *promoService) Quote(userID uint, req models.PromoQuoteRequest) (*models.PromoQuote, error) {
+	var price int
+	switch req.TargetType {
+	case models.PromoTargetCategory:
+		var category models.Categories
+		if err := s.db.First(&category, req.TargetID).Error; err != nil {
+			return nil, fmt.Errorf("категория не найдена")
+		}
+		price = category.Price
+	case models.PromoTargetSubscription:
+		var sub models.Subscription
+		if err := s.db.First(&sub, req.TargetID).Error; err != nil {
+			return nil, fmt.Errorf("подписка не найдена")
+		}
+		price = sub.Price
+	default:
+		return nil, fmt.Errorf("неизвестный тип покупки: %q", req.TargetType)
+	}
+
+	app, err := s.check(s.repo, userID, req.Code, req.TargetType, req.TargetID, price, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PromoQuote{
+		Code:          app.Promo.Code,
+		OriginalPrice: app.OriginalPrice,
+		Discount:      app.Discount,
+		FinalPrice:    app.FinalPrice,
+	}, nil
+}
+
+func (s *promoService) Apply(tx *gorm.DB, userID uint, code, targetType string, targetID uint, price int) (*PromoApplication, error) {
+	return s.check(s.repo.WithTx(tx), userID, code, targetType, targetID, price, true)
+}
+
+func (s *promoService) Redeem(tx *gorm.DB, userID uint, app *PromoApplication, entryID uint) error {
+	repo := s.repo.WithTx(tx)
+
+	app.Promo.RedemptionCount++
+	if err := repo.Update(app.Promo); err != nil {
+		return fmt.Errorf("ошибка при применении промокода: %w", err)
+	}
+
+	return repo.CreateRedemption(&models.PromoRedemption{
+		PromoCodeID:    app.Promo.ID,
+		UserID:         userID,
+		TargetType:     app.TargetType,
+		TargetID:       app.TargetID,
+		OriginalPrice:  app.OriginalPrice,
+		Discount:       app.Discount,
+		FinalPrice:     app.FinalPrice,
+		JournalEntryID: &entryID,
+	})
+}
+
+func (s *promoService) Release(tx *gorm.DB, entryID uint) error {
+	repo := s.repo.WithTx(tx)
+
+	redemption, err := repo.GetRedemptionByEntry(entryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := repo.DecrementRedemptions(redemption.PromoCodeID); err != nil {
+		return fmt.Errorf("ошибка при возврате применения промокода: %w", err)
+	}
+
+	s.log.Info("Применение промокода возвращено",
+		"promo_code_id", redemption.PromoCodeID,
+		"journal_entry_id", entryID)
+
+	return nil
+}
+
+func (s *promoService) check(
+	repo repository.PromoRepository,
+	userID uint,
+	code, targetType string,
+	targetID uint,
+	price int,
+	lock bool,
+) (*PromoApplication, error) {
+	promo, err := repo.GetByCode(normalizePromoCode(code), lock)
+	if err != nil {
+		return nil, ErrPromoNotFound
+	}
+
+	now := time.Now()
+	if !promo.Active {
+		return nil, errors.New("промокод отключён")
+	}
+	if promo.ValidFrom != nil && now.Before(*promo.ValidFrom) {
+		return nil, errors.New("промокод ещё не действует")
+	}
+	if promo.ValidUntil != nil && !now.Before(*promo.ValidUntil) {
+		return nil, errors.New("срок действия промокода истёк")
+	}
+	if promo.MaxRedemptions > 0 && promo.RedemptionCount >= promo.MaxRedemptions {
+		return nil, errors.New("промокод больше недоступен")
+	}
+
+	if promo.MaxPerUser > 0 {
+		used, err := repo.CountUserRedemptions(promo.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(promo.MaxPerUser) {
+			return nil, errors.New("вы уже использовали этот промокод")
+		}
+	}
+
+	if len(promo.Scopes) > 0 {
+		applicable := false
+		for _, scope := range promo.Scopes {
+			if scope.TargetType == targetType && scope.TargetID == targetID {
+				applicable = true
+				break
+			}
+		}
+		if !applicable {
+			return nil, errors.New("промокод не действует на эту покупку")
+		}
+	}
+
+	discount := promo.Value
+	if promo.DiscountType == models.PromoDiscountPercent {
+		discount = price * promo.Value / 100
+	}
+	if discount > price {
+		discount = price
+	}
+
+	return &PromoApplication{
+		Promo:         promo,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		OriginalPrice: price,
+		Discount:      discount,
+		FinalPrice:    price - discount,
+	}, nil
+}
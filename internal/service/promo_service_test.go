@@ -0,0 +1,67 @@
+package service
+
+import (
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakePromoRepository хранит промокоды и их применения в памяти
+type fakePromoRepository struct {
+	repository.PromoRepository
+	promos      map[uint]*models.PromoCode
+	redemptions []models.PromoRedemption
+}
+
+func (r *fakePromoRepository) WithTx(tx *gorm.DB) repository.PromoRepository {
+	return r
+}
+
+func (r *fakePromoRepository) GetRedemptionByEntry(entryID uint) (*models.PromoRedemption, error) {
+	for i := range r.redemptions {
+		if entry := r.redemptions[i].JournalEntryID; entry != nil && *entry == entryID {
+			return &r.redemptions[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakePromoRepository) DecrementRedemptions(promoID uint) error {
+	if promo := r.promos[promoID]; promo != nil && promo.RedemptionCount > 0 {
+		promo.RedemptionCount--
+	}
+	return nil
+}
+
+func TestReleaseReturnsRedemptionToLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		entryID   uint
+		wantCount int
+	}{
+		{name: "purchase with promo", entryID: 5, wantCount: 1},
+		{name: "purchase without promo", entryID: 6, wantCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promo := &models.PromoCode{Code: "SPRING", MaxRedemptions: 2, RedemptionCount: 2}
+			promo.ID = 1
+			entryID := uint(5)
+			repo := &fakePromoRepository{
+				promos:      map[uint]*models.PromoCode{promo.ID: promo},
+				redemptions: []models.PromoRedemption{{PromoCodeID: promo.ID, UserID: 3, JournalEntryID: &entryID}},
+			}
+			promos := &promoService{repo: repo, log: testLogger()}
+
+			if err := promos.Release(nil, tt.entryID); err != nil {
+				t.Fatalf("Release() error = %v", err)
+			}
+			if promo.RedemptionCount != tt.wantCount {
+				t.Errorf("RedemptionCount = %d, ожидалось %d", promo.RedemptionCount, tt.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"healthy_body/internal/calculator"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// веса критериев подбора программы; критерии, для которых не хватает данных,
+// не учитываются, и оценка нормируется на оставшиеся
+const (
+	goalWeight       = 30
+	experienceWeight = 20
+	equipmentWeight  = 20
+	scheduleWeight   = 15
+	dietWeight       = 15
+)
+
+var goalLabels = map[calculator.Goal]string{
+	calculator.GoalCut:      "снижение веса",
+	calculator.GoalMaintain: "поддержание формы",
+	calculator.GoalBulk:     "набор массы",
+}
+
+type RecommendationService interface {
+	// Recommend оценивает все категории по анкете; user может быть nil для анонимного запроса
+	Recommend(user *models.User, req models.RecommendationRequest) (*models.RecommendationResult, error)
+}
+
+type recommendationService struct {
+	categories repository.CategoryRepo
+	body       repository.BodyRepository
+	log        *slog.Logger
+}
+
+func NewRecommendationService(categories repository.CategoryRepo, body repository.BodyRepository, log *slog.Logger) RecommendationService {
+	return &recommendationService{
+		categories: categories,
+		body:       body,
+		log:        log,
+	}
+}
+
+// recommendationProfile — анкета после проверки, дополненная BMI и профилем пользователя
+type recommendationProfile struct {
+	goal        calculator.Goal
+	goalFromBmi bool
+	bmi         *float64
+	bmiClass    calculator.BmiClass
+	level       int // индекс в models.Difficulties, -1 — не указан
+	equipment   []string
+	daysPerWeek int
+	diet        models.MealPlanFilter
+}
+
+func (s *recommendationService) Recommend(user *models.User, req models.RecommendationRequest) (*models.RecommendationResult, error) {
+	profile, err := s.profile(user, req)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categories.ListWithPlans()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при загрузке программ: %w", err)
+	}
+
+	result := &models.RecommendationResult{
+		Goal:            string(profile.goal),
+		Bmi:             profile.bmi,
+		Recommendations: make([]models.ProgrammeRecommendation, 0, len(categories)),
+	}
+	if profile.bmi != nil {
+		result.BmiClass = profile.bmiClass.Label
+	}
+
+	for i := range categories {
+		result.Recommendations = append(result.Recommendations, scoreCategory(&categories[i], profile))
+	}
+
+	// при равной оценке выше идёт более дешёвая программа
+	slices.SortStableFunc(result.Recommendations, func(a, b models.ProgrammeRecommendation) int {
+		if a.Score != b.Score {
+			return cmp.Compare(b.Score, a.Score)
+		}
+		return cmp.Compare(a.Price, b.Price)
+	})
+
+	return result, nil
+}
+
+func (s *recommendationService) profile(user *models.User, req models.RecommendationRequest) (*recommendationProfile, error) {
+	profile := &recommendationProfile{level: -1}
+
+	switch {
+	case req.WeightKg != nil || req.HeightCm != nil:
+		if req.WeightKg == nil || req.HeightCm == nil {
+			return nil, errors.New("для расчёта BMI укажите и вес, и рост")
+		}
+		if *req.WeightKg <= 0 || *req.WeightKg > 500 {
+			return nil, errors.New("вес должен быть от 0 до 500 кг")
+		}
+		if *req.HeightCm < 50 || *req.HeightCm > 280 {
+			return nil, errors.New("рост должен быть от 50 до 280 см")
+		}
+		_, bmi := calculator.BmiCalc(*req.WeightKg, *req.HeightCm)
+		profile.bmi = &bmi
+	case user != nil:
+		latest, err := s.body.LatestMeasurement(user.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if latest != nil {
+			profile.bmi = &latest.Bmi
+		}
+	}
+	if profile.bmi != nil {
+		profile.bmiClass = calculator.ClassifyBmi(*profile.bmi)
+	}
+
+	profile.goal = calculator.Goal(strings.ToLower(strings.TrimSpace(req.Goal)))
+	if profile.goal == "" && profile.bmi != nil {
+		profile.goal, profile.goalFromBmi = goalForBmi(*profile.bmi), true
+	}
+	if profile.goal != "" && !calculator.IsValidGoal(profile.goal) {
+		return nil, calculator.ErrInvalidGoal
+	}
+
+	if experience := strings.ToLower(strings.TrimSpace(req.Experience)); experience != "" {
+		profile.level = slices.Index(models.Difficulties, experience)
+		if profile.level < 0 {
+			return nil, fmt.Errorf("допустимые уровни подготовки: %s", strings.Join(models.Difficulties, ", "))
+		}
+	}
+
+	equipment, ok := models.NormalizeTags(append(slices.Clone(req.Equipment), "bodyweight"), models.EquipmentTypes)
+	if !ok {
+		return nil, fmt.Errorf("допустимый инвентарь: %s", strings.Join(models.EquipmentTypes, ", "))
+	}
+	profile.equipment = equipment
+
+	if req.DaysPerWeek < 0 || req.DaysPerWeek > len(models.Weekdays) {
+		return nil, errors.New("количество тренировок в неделю должно быть от 1 до 7")
+	}
+	profile.daysPerWeek = req.DaysPerWeek
+
+	prefs := models.DietaryPreferences{DietTags: req.DietTags, Allergens: req.Allergens}
+	if len(prefs.DietTags) == 0 && len(prefs.Allergens) == 0 && user != nil {
+		prefs = models.DietaryPreferences{DietTags: user.DietTags, Allergens: user.Allergens}
+	}
+	tags, ok := models.NormalizeTags(prefs.DietTags, models.DietTags)
+	if !ok {
+		return nil, fmt.Errorf("допустимые диеты: %s", strings.Join(models.DietTags, ", "))
+	}
+	allergens, ok := models.NormalizeTags(prefs.Allergens, models.Allergens)
+	if !ok {
+		return nil, fmt.Errorf("допустимые аллергены: %s", strings.Join(models.Allergens, ", "))
+	}
+	profile.diet = models.MealPlanFilter{DietTags: tags, ExcludeAllergens: allergens}
+
+	return profile, nil
+}
+
+// goalForBmi — цель по умолчанию: при избыточном весе снижение, при дефиците набор
+func goalForBmi(bmi float64) calculator.Goal {
+	switch {
+	case bmi >= 25:
+		return calculator.GoalCut
+	case bmi < 18.5:
+		return calculator.GoalBulk
+	default:
+		return calculator.GoalMaintain
+	}
+}
+
+// scoreCategory оценивает программу по каждому критерию и объясняет оценку
+func scoreCategory(category *models.Categories, profile *recommendationProfile) models.ProgrammeRecommendation {
+	recommendation := models.ProgrammeRecommendation{
+		CategoriesID: category.ID,
+		Name:         category.Name,
+		Description:  category.Description,
+		Price:        category.Price,
+		Reasons:      []string{},
+	}
+
+	var earned, possible float64
+	add := func(points, weight float64, reason string) {
+		earned += points
+		possible += weight
+		if reason != "" {
+			recommendation.Reasons = append(recommendation.Reasons, reason)
+		}
+	}
+
+	if points, reason, ok := scoreGoal(category, profile); ok {
+		add(points, goalWeight, reason)
+	}
+
+	var library []models.Exercise
+	for _, plan := range category.ExercisePlans {
+		for _, item := range plan.Exercises {
+			if item.Exercise != nil {
+				library = append(library, *item.Exercise)
+			}
+		}
+	}
+	if points, reason, ok := scoreExperience(library, profile); ok {
+		add(points, experienceWeight, reason)
+	}
+	if points, reason, ok := scoreEquipment(library, profile); ok {
+		add(points, equipmentWeight, reason)
+	}
+	if points, reason, ok := scoreSchedule(category.ExercisePlans, profile); ok {
+		add(points, scheduleWeight, reason)
+	}
+	if points, reason, ok := scoreDiet(category.MealPlans, profile); ok {
+		add(points, dietWeight, reason)
+	}
+
+	if possible == 0 {
+		recommendation.Reasons = append(recommendation.Reasons, "недостаточно данных, чтобы оценить программу")
+		return recommendation
+	}
+
+	recommendation.Score = math.Round(earned/possible*1000) / 10
+	return recommendation
+}
+
+func scoreGoal(category *models.Categories, profile *recommendationProfile) (float64, string, bool) {
+	target := calculator.Goal(category.Goal)
+	if profile.goal == "" || target == "" {
+		return 0, "", false
+	}
+
+	// при дефиците массы тела снижать вес нельзя, при ожирении не стоит набирать
+	if profile.bmi != nil {
+		if target == calculator.GoalCut && *profile.bmi < 18.5 {
+			return 0, fmt.Sprintf("программа на снижение веса не рекомендуется при BMI %.1f (%s)", *profile.bmi, profile.bmiClass.Label), true
+		}
+		if target == calculator.GoalBulk && *profile.bmi >= 30 {
+			return 0, fmt.Sprintf("программа на набор массы не рекомендуется при BMI %.1f (%s)", *profile.bmi, profile.bmiClass.Label), true
+		}
+	}
+
+	switch {
+	case target == profile.goal && profile.goalFromBmi:
+		return goalWeight, fmt.Sprintf("при BMI %.1f (%s) подходит цель «%s», на неё и рассчитана программа",
+			*profile.bmi, profile.bmiClass.Label, goalLabels[target]), true
+	case target == profile.goal:
+		return goalWeight, fmt.Sprintf("программа рассчитана на вашу цель — %s", goalLabels[target]), true
+	case target == calculator.GoalMaintain || profile.goal == calculator.GoalMaintain:
+		return goalWeight / 2, fmt.Sprintf("программа рассчитана на цель «%s», а ваша — %s",
+			goalLabels[target], goalLabels[profile.goal]), true
+	default:
+		return 0, fmt.Sprintf("программа рассчитана на противоположную цель — %s", goalLabels[target]), true
+	}
+}
+
+func scoreExperience(library []models.Exercise, profile *recommendationProfile) (float64, string, bool) {
+	if profile.level < 0 || len(library) == 0 {
+		return 0, "", false
+	}
+
+	hardest := 0
+	for _, exercise := range library {
+		hardest = max(hardest, slices.Index(models.Difficulties, exercise.Difficulty))
+	}
+
+	switch gap := hardest - profile.level; {
+	case gap <= 0:
+		return experienceWeight, "сложность упражнений соответствует вашему уровню подготовки", true
+	case gap == 1:
+		return experienceWeight / 2, fmt.Sprintf("часть упражнений рассчитана на уровень %s — сложнее вашего", models.Difficulties[hardest]), true
+	default:
+		return 0, fmt.Sprintf("упражнения уровня %s слишком сложны для уровня %s",
+			models.Difficulties[hardest], models.Difficulties[profile.level]), true
+	}
+}
+
+func scoreEquipment(library []models.Exercise, profile *recommendationProfile) (float64, string, bool) {
+	if len(library) == 0 {
+		return 0, "", false
+	}
+
+	var missing []string
+	available := 0
+	for _, exercise := range library {
+		ok := true
+		for _, equipment := range exercise.Equipment {
+			if slices.Contains(profile.equipment, equipment) {
+				continue
+			}
+			ok = false
+			if !slices.Contains(missing, equipment) {
+				missing = append(missing, equipment)
+			}
+		}
+		if ok {
+			available++
+		}
+	}
+
+	points := equipmentWeight * float64(available) / float64(len(library))
+	if len(missing) == 0 {
+		return points, "для всех упражнений хватает вашего инвентаря", true
+	}
+	slices.Sort(missing)
+	return points, fmt.Sprintf("для %d из %d упражнений нужен инвентарь, которого у вас нет: %s",
+		len(library)-available, len(library), strings.Join(missing, ", ")), true
+}
+
+func scoreSchedule(plans []models.ExercisePlan, profile *recommendationProfile) (float64, string, bool) {
+	if profile.daysPerWeek == 0 {
+		return 0, "", false
+	}
+
+	// из нескольких планов категории берётся тот, что лучше ложится в график
+	best := -1
+	for _, plan := range plans {
+		days := trainingDaysPerWeek(plan)
+		if days == 0 {
+			continue
+		}
+		if best < 0 || scheduleFit(days, profile.daysPerWeek) > scheduleFit(best, profile.daysPerWeek) {
+			best = days
+		}
+	}
+	if best < 0 {
+		return 0, "", false
+	}
+
+	points := scheduleWeight * scheduleFit(best, profile.daysPerWeek)
+	if best > profile.daysPerWeek {
+		return points, fmt.Sprintf("тренировок в неделю по плану: %d, а у вас есть время на %d", best, profile.daysPerWeek), true
+	}
+	return points, fmt.Sprintf("тренировок в неделю по плану: %d — укладывается в ваш график", best), true
+}
+
+// scheduleFit — от 0 до 1; лишняя тренировка хуже, чем свободный день
+func scheduleFit(planDays, userDays int) float64 {
+	switch gap := planDays - userDays; {
+	case gap > 1:
+		return 0
+	case gap == 1:
+		return 1.0 / 3
+	case gap >= -1:
+		return 1
+	default:
+		return 2.0 / 3
+	}
+}
+
+// trainingDaysPerWeek — наибольшее число тренировочных дней в одной неделе плана
+func trainingDaysPerWeek(plan models.ExercisePlan) int {
+	days := map[int][]string{}
+	for _, item := range plan.Exercises {
+		if !models.IsValidWeekday(item.DayOfWeek) || slices.Contains(days[item.Week], item.DayOfWeek) {
+			continue
+		}
+		days[item.Week] = append(days[item.Week], item.DayOfWeek)
+	}
+
+	result := 0
+	for _, weekDays := range days {
+		result = max(result, len(weekDays))
+	}
+	return result
+}
+
+func scoreDiet(plans []models.MealPlan, profile *recommendationProfile) (float64, string, bool) {
+	if len(plans) == 0 || (len(profile.diet.DietTags) == 0 && len(profile.diet.ExcludeAllergens) == 0) {
+		return 0, "", false
+	}
+
+	matching := 0
+	for i := range plans {
+		fillDietInfo(&plans[i])
+		if profile.diet.Matches(&plans[i]) {
+			matching++
+		}
+	}
+
+	if matching == 0 {
+		return 0, "ни один план питания программы не подходит под ваши диету и аллергены", true
+	}
+	return dietWeight, fmt.Sprintf("планов питания под вашу диету и аллергены: %d из %d", matching, len(plans)), true
+}
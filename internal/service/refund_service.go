@@ -0,0 +1,272 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrRefundNotFound = errors.New("заявка на возврат не найдена")
+
+type RefundService interface {
+	Request(userID uint, req models.CreateRefundRequest) (*models.RefundRequest, error)
+	Approve(adminID, refundID uint, req models.RefundDecisionRequest) (*models.RefundRequest, error)
+	Reject(adminID, refundID uint, req models.RefundDecisionRequest) (*models.RefundRequest, error)
+	GetByID(id uint) (*models.RefundRequest, error)
+	List(filter models.RefundFilter) ([]models.RefundRequest, error)
+}
+
+type refundService struct {
+	repo     repository.RefundRepository
+	ledger   LedgerService
+	promos   PromoService
+	notifier NotificationService
+	db       *gorm.DB
+	window   time.Duration
+	log      *slog.Logger
+}
+
+// window — сколько времени после покупки можно запросить возврат
+func NewRefundService(
+	repo repository.RefundRepository,
+	ledger LedgerService,
+	promos PromoService,
+	notifier NotificationService,
+	db *gorm.DB,
+	window time.Duration,
+	log *slog.Logger,
+) RefundService {
+	return &refundService{
+		repo:     repo,
+		ledger:   ledger,
+		promos:   promos,
+		notifier: notifier,
+		db:       db,
+		window:   window,
+		log:      log,
+	}
+}
+
+func (s *refundService) Request(userID uint, req models.CreateRefundRequest) (*models.RefundRequest, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) < 3 {
+		return nil, errors.New("укажите причину возврата")
+	}
+
+	var refund *models.RefundRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// последняя действующая покупка категории; подарки возврату не подлежат
+		var plan models.UserPlan
+		err := tx.Select("user_plans.*").
+			Joins("JOIN journal_entries ON journal_entries.id = user_plans.journal_entry_id").
+			Where("user_plans.user_id = ? AND user_plans.categories_id = ? AND journal_entries.kind = ?",
+				userID, req.CategoryID, models.EntryKindPurchase).
+			Order("user_plans.created_at DESC").
+			First(&plan).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("покупка категории не найдена")
+			}
+			return fmt.Errorf("ошибка при поиске покупки: %w", err)
+		}
+
+		if time.Since(plan.CreatedAt) > s.window {
+			return fmt.Errorf("срок возврата истёк: возврат возможен в течение %d дней после покупки", int(s.window.Hours()/24))
+		}
+
+		repo := s.repo.WithTx(tx)
+		open, err := repo.HasOpen(plan.ID)
+		if err != nil {
+			return err
+		}
+		if open {
+			return errors.New("по этой покупке уже есть заявка на возврат")
+		}
+
+		amount, err := s.ledger.WalletAmount(tx, *plan.JournalEntryID, userID)
+		if err != nil {
+			return err
+		}
+		if amount <= 0 {
+			return errors.New("по этой покупке нечего возвращать")
+		}
+
+		refund = &models.RefundRequest{
+			UserID:       userID,
+			UserPlanID:   plan.ID,
+			CategoriesID: plan.CategoriesID,
+			Amount:       amount,
+			Reason:       reason,
+			Status:       models.RefundStatusPending,
+		}
+
+		return repo.Create(refund)
+	})
+	if err != nil {
+		s.log.Warn("Заявка на возврат не создана",
+			"user_id", userID,
+			"category_id", req.CategoryID,
+			"error", err.Error())
+		return nil, err
+	}
+
+	s.log.Info("Создана заявка на возврат",
+		"id", refund.ID,
+		"user_id", userID,
+		"amount", refund.Amount)
+
+	return refund, nil
+}
+
+// Approve возвращает деньги на баланс и отзывает доступ к категории
+func (s *refundService) Approve(adminID, refundID uint, req models.RefundDecisionRequest) (*models.RefundRequest, error) {
+	refund, err := s.decide(adminID, refundID, models.RefundStatusApproved, req.Reason, func(tx *gorm.DB, refund *models.RefundRequest) error {
+		var plan models.UserPlan
+		if err := tx.First(&plan, refund.UserPlanID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("покупка уже отозвана")
+			}
+			return err
+		}
+
+		entry, err := s.ledger.Reverse(tx, *plan.JournalEntryID, LedgerEntry{
+			Kind:        models.EntryKindRefund,
+			Description: fmt.Sprintf("Возврат покупки категории %d", refund.CategoriesID),
+			Reference:   fmt.Sprintf("refund:%d", refund.ID),
+			CreatedByID: &adminID,
+		})
+		if err != nil {
+			return err
+		}
+		refund.JournalEntryID = &entry.ID
+
+		// возвращённая покупка не должна занимать место в лимите промокода
+		if err := s.promos.Release(tx, *plan.JournalEntryID); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&plan).Error; err != nil {
+			return fmt.Errorf("ошибка при отзыве покупки: %w", err)
+		}
+
+		// если возвращена активная программа, активной становится последняя оставшаяся покупка
+		var latest models.UserPlan
+		var activeID *uint
+		if err := tx.Where("user_id = ?", refund.UserID).Order("created_at DESC").First(&latest).Error; err == nil {
+			activeID = &latest.CategoriesID
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return tx.Model(&models.User{}).
+			Where("id = ? AND active_categories_id = ?", refund.UserID, refund.CategoriesID).
+			Update("active_categories_id", activeID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(refund)
+	return refund, nil
+}
+
+func (s *refundService) Reject(adminID, refundID uint, req models.RefundDecisionRequest) (*models.RefundRequest, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, errors.New("укажите причину отказа")
+	}
+
+	refund, err := s.decide(adminID, refundID, models.RefundStatusRejected, req.Reason, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(refund)
+	return refund, nil
+}
+
+func (s *refundService) GetByID(id uint) (*models.RefundRequest, error) {
+	refund, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrRefundNotFound
+	}
+
+	return refund, nil
+}
+
+func (s *refundService) List(filter models.RefundFilter) ([]models.RefundRequest, error) {
+	return s.repo.List(filter)
+}
+
+func (s *refundService) decide(
+	adminID, refundID uint,
+	status, reason string,
+	apply func(tx *gorm.DB, refund *models.RefundRequest) error,
+) (*models.RefundRequest, error) {
+	var refund *models.RefundRequest
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		var err error
+		refund, err = repo.Lock(refundID)
+		if err != nil {
+			return ErrRefundNotFound
+		}
+
+		if refund.Status != models.RefundStatusPending {
+			return fmt.Errorf("заявка уже рассмотрена: %s", refund.Status)
+		}
+
+		if apply != nil {
+			if err := apply(tx, refund); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		refund.Status = status
+		refund.DecisionReason = strings.TrimSpace(reason)
+		refund.DecidedByID = &adminID
+		refund.DecidedAt = &now
+
+		return repo.Update(refund)
+	})
+	if err != nil {
+		s.log.Error("Ошибка при рассмотрении заявки на возврат",
+			"id", refundID,
+			"status", status,
+			"error", err.Error())
+		return nil, err
+	}
+
+	s.log.Info("Заявка на возврат рассмотрена",
+		"id", refund.ID,
+		"status", refund.Status,
+		"admin_id", adminID)
+
+	return refund, nil
+}
+
+func (s *refundService) notify(refund *models.RefundRequest) {
+	var user models.User
+	if err := s.db.First(&user, refund.UserID).Error; err != nil {
+		s.log.Error("не удалось отправить уведомление о возврате", "err", err)
+		return
+	}
+
+	var category models.Categories
+	if err := s.db.Unscoped().First(&category, refund.CategoriesID).Error; err != nil {
+		s.log.Error("не удалось отправить уведомление о возврате", "err", err)
+		return
+	}
+
+	if err := s.notifier.SendRefundDecision(&user, &category, refund); err != nil {
+		s.log.Error("не удалось отправить уведомление", "err", err)
+	}
+}
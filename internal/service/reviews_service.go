@@ -47,7 +47,7 @@ func (s *reviewsService) CreateReview(req models.CreateReviewRequest, userID uin
 	}
 
 	newReview := models.Reviews{
-		UserID:     req.UserID,
+		UserID:     userID,
 		CategoriesID: req.CategoriesID,
 		Rating:     req.Rating,
 		Content:    req.Content,
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Job — периодическая фоновая задача
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(now time.Time) error
+}
+
+// Scheduler запускает фоновые задачи внутри процесса сервера.
+// Каждая задача выполняется сразу при старте и затем с заданным интервалом
+type Scheduler struct {
+	jobs []Job
+	log  *slog.Logger
+}
+
+func NewScheduler(log *slog.Logger) *Scheduler {
+	return &Scheduler{log: log}
+}
+
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	s.run(job, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.run(job, now)
+		}
+	}
+}
+
+func (s *Scheduler) run(job Job, now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.log.Error("Паника в фоновой задаче", "job", job.Name, "panic", r)
+		}
+	}()
+
+	started := time.Now()
+	if err := job.Run(now); err != nil {
+		s.log.Error("Ошибка в фоновой задаче",
+			"job", job.Name,
+			"error", err.Error())
+		return
+	}
+
+	s.log.Debug("Фоновая задача выполнена",
+		"job", job.Name,
+		"duration", time.Since(started))
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrUserSubscriptionNotFound = errors.New("подписка пользователя не найдена")
+
+// SubscriptionLifecycle управляет подписками пользователей: покупкой,
+// продлением, отменой в конце периода и истечением срока
+type SubscriptionLifecycle interface {
+	Purchase(userID, subID uint, promoCode string) (*models.UserSubscription, error)
+	Renew(userID, userSubID uint) (*models.UserSubscription, error)
+	Cancel(userID, userSubID uint) (*models.UserSubscription, error)
+	SetAutoRenew(userID, userSubID uint, enabled bool) (*models.UserSubscription, error)
+	GetWithEvents(userID, userSubID uint) (*models.UserSubscription, error)
+	// ProcessDue продлевает или завершает подписки, срок которых истёк к моменту now
+	ProcessDue(now time.Time) error
+}
+
+type subscriptionLifecycle struct {
+	repo     repository.UserSubscriptionRepository
+	sub      SubscriptionService
+	ledger   LedgerService
+	promos   PromoService
+	trainers TrainerService
+	db       *gorm.DB
+	log      *slog.Logger
+}
+
+func NewSubscriptionLifecycle(
+	repo repository.UserSubscriptionRepository,
+	sub SubscriptionService,
+	ledger LedgerService,
+	promos PromoService,
+	// trainers — чтобы зачислить тренеру долю от подписки на его категорию
+	trainers TrainerService,
+	db *gorm.DB,
+	log *slog.Logger,
+) SubscriptionLifecycle {
+	return &subscriptionLifecycle{
+		repo:     repo,
+		sub:      sub,
+		ledger:   ledger,
+		promos:   promos,
+		trainers: trainers,
+		db:       db,
+		log:      log,
+	}
+}
+
+// Purchase оформляет подписку; промокод действует только на первый период
+func (s *subscriptionLifecycle) Purchase(userID, subID uint, promoCode string) (*models.UserSubscription, error) {
+	sub, err := s.sub.GetSubByID(subID)
+	if err != nil {
+		return nil, fmt.Errorf("subscription not found: %w", err)
+	}
+
+	var userSub *models.UserSubscription
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("пользователь не найден")
+			}
+			return fmt.Errorf("user not found: %w", err)
+		}
+
+		price := sub.Price
+		var promo *PromoApplication
+		if promoCode != "" {
+			if promo, err = s.promos.Apply(tx, userID, promoCode, models.PromoTargetSubscription, subID, price); err != nil {
+				return err
+			}
+			price = promo.FinalPrice
+		}
+
+		entry, err := s.charge(tx, userID, sub, price)
+		if err != nil {
+			return err
+		}
+
+		if promo != nil {
+			if err := s.promos.Redeem(tx, userID, promo, entry.ID); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		userSub = &models.UserSubscription{
+			UserID:         userID,
+			SubscriptionID: subID,
+			StartDate:      now,
+			EndDate:        now.Add(subscriptionPeriod(sub)),
+			IsActive:       true,
+			Status:         models.SubscriptionStatusActive,
+			JournalEntryID: &entry.ID,
+		}
+
+		repo := s.repo.WithTx(tx)
+		if err := repo.Create(userSub); err != nil {
+			return fmt.Errorf("cannot create user subscription: %w", err)
+		}
+
+		return s.event(repo, userSub, models.SubscriptionEventCreated, "", "", &entry.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Подписка оформлена",
+		"user_id", userID,
+		"subscription_id", subID,
+		"end_date", userSub.EndDate)
+
+	return userSub, nil
+}
+
+// Renew оплачивает ещё один период. Период добавляется к текущей дате окончания,
+// а для истёкшей подписки отсчитывается от момента продления
+func (s *subscriptionLifecycle) Renew(userID, userSubID uint) (*models.UserSubscription, error) {
+	return s.update(userID, userSubID, func(repo repository.UserSubscriptionRepository, tx *gorm.DB, userSub *models.UserSubscription) error {
+		return s.renew(repo, tx, userSub, time.Now())
+	})
+}
+
+// Cancel отключает продление: подписка действует до конца оплаченного периода
+func (s *subscriptionLifecycle) Cancel(userID, userSubID uint) (*models.UserSubscription, error) {
+	return s.update(userID, userSubID, func(repo repository.UserSubscriptionRepository, tx *gorm.DB, userSub *models.UserSubscription) error {
+		if userSub.Status != models.SubscriptionStatusActive {
+			return errors.New("подписка уже неактивна")
+		}
+
+		if userSub.CancelAtPeriodEnd {
+			return errors.New("подписка уже отменена")
+		}
+
+		now := time.Now()
+		userSub.CancelAtPeriodEnd = true
+		userSub.AutoRenew = false
+		userSub.CanceledAt = &now
+
+		if err := repo.Update(userSub); err != nil {
+			return err
+		}
+
+		return s.event(repo, userSub, models.SubscriptionEventCanceled, userSub.Status,
+			fmt.Sprintf("действует до %s", userSub.EndDate.Format(time.RFC3339)), nil)
+	})
+}
+
+func (s *subscriptionLifecycle) SetAutoRenew(userID, userSubID uint, enabled bool) (*models.UserSubscription, error) {
+	return s.update(userID, userSubID, func(repo repository.UserSubscriptionRepository, tx *gorm.DB, userSub *models.UserSubscription) error {
+		if userSub.AutoRenew == enabled {
+			return nil
+		}
+
+		if enabled && userSub.Status != models.SubscriptionStatusActive {
+			return errors.New("автопродление доступно только для активной подписки")
+		}
+
+		event := models.SubscriptionEventAutoRenewOff
+		if enabled {
+			event = models.SubscriptionEventAutoRenewOn
+			// включение автопродления отменяет запланированную отмену
+			userSub.CancelAtPeriodEnd = false
+			userSub.CanceledAt = nil
+		}
+		userSub.AutoRenew = enabled
+
+		if err := repo.Update(userSub); err != nil {
+			return err
+		}
+
+		return s.event(repo, userSub, event, userSub.Status, "", nil)
+	})
+}
+
+func (s *subscriptionLifecycle) GetWithEvents(userID, userSubID uint) (*models.UserSubscription, error) {
+	userSub, err := s.repo.GetByID(userSubID)
+	if err != nil || userSub.UserID != userID {
+		return nil, ErrUserSubscriptionNotFound
+	}
+
+	if userSub.Events, err = s.repo.ListEvents(userSubID); err != nil {
+		return nil, fmt.Errorf("ошибка при получении истории подписки: %w", err)
+	}
+
+	return userSub, nil
+}
+
+func (s *subscriptionLifecycle) ProcessDue(now time.Time) error {
+	ids, err := s.repo.ListDueIDs(now)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, id := range ids {
+		if err := s.processOne(id, now); err != nil {
+			failed++
+			s.log.Error("Ошибка при обработке подписки",
+				"id", id,
+				"error", err.Error())
+		}
+	}
+
+	if len(ids) > 0 {
+		s.log.Info("Обработаны истёкшие подписки",
+			"count", len(ids),
+			"failed", failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("не удалось обработать подписок: %d", failed)
+	}
+
+	return nil
+}
+
+// processOne обрабатывает одну подписку в отдельной транзакции,
+// чтобы ошибка у одного пользователя не откатывала остальных
+func (s *subscriptionLifecycle) processOne(id uint, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		userSub, err := repo.Lock(id)
+		if err != nil {
+			return err
+		}
+
+		// подписку могли продлить, пока она ждала обработки
+		if userSub.Status != models.SubscriptionStatusActive || userSub.EndDate.After(now) {
+			return nil
+		}
+
+		if userSub.AutoRenew && !userSub.CancelAtPeriodEnd {
+			err := s.renew(repo, tx, userSub, now)
+			if err == nil {
+				return nil
+			}
+			if !errors.Is(err, ErrInsufficientFunds) {
+				return err
+			}
+
+			if err := s.event(repo, userSub, models.SubscriptionEventRenewalFailed, userSub.Status, err.Error(), nil); err != nil {
+				return err
+			}
+		}
+
+		from := userSub.Status
+		userSub.Status = models.SubscriptionStatusExpired
+		userSub.IsActive = false
+		userSub.ExpiredAt = &now
+
+		if err := repo.Update(userSub); err != nil {
+			return err
+		}
+
+		return s.event(repo, userSub, models.SubscriptionEventExpired, from, "", nil)
+	})
+}
+
+func (s *subscriptionLifecycle) renew(repo repository.UserSubscriptionRepository, tx *gorm.DB, userSub *models.UserSubscription, now time.Time) error {
+	sub, err := s.sub.GetSubByID(userSub.SubscriptionID)
+	if err != nil {
+		return fmt.Errorf("subscription not found: %w", err)
+	}
+
+	entry, err := s.charge(tx, userSub.UserID, sub, sub.Price)
+	if err != nil {
+		return err
+	}
+
+	start := userSub.EndDate
+	if start.Before(now) {
+		start = now
+	}
+
+	from := userSub.Status
+	userSub.EndDate = start.Add(subscriptionPeriod(sub))
+	userSub.Status = models.SubscriptionStatusActive
+	userSub.IsActive = true
+	userSub.CancelAtPeriodEnd = false
+	userSub.CanceledAt = nil
+	userSub.ExpiredAt = nil
+	userSub.RenewedAt = &now
+
+	if err := repo.Update(userSub); err != nil {
+		return err
+	}
+
+	return s.event(repo, userSub, models.SubscriptionEventRenewed, from,
+		fmt.Sprintf("действует до %s", userSub.EndDate.Format(time.RFC3339)), &entry.ID)
+}
+
+// charge списывает стоимость подписки — общая логика покупки, ручного и автоматического продления
+func (s *subscriptionLifecycle) charge(tx *gorm.DB, userID uint, sub *models.Subscription, price int) (*models.JournalEntry, error) {
+	share, err := s.trainers.RevenueShare(tx, sub.CategoriesID, price)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ledger.Charge(tx, userID, price, share, models.EntryKindSubscription,
+		fmt.Sprintf("Оплата подписки «%s»", sub.Name),
+		fmt.Sprintf("subscription:%d", sub.ID))
+}
+
+func (s *subscriptionLifecycle) update(
+	userID, userSubID uint,
+	apply func(repo repository.UserSubscriptionRepository, tx *gorm.DB, userSub *models.UserSubscription) error,
+) (*models.UserSubscription, error) {
+	var userSub *models.UserSubscription
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		var err error
+		userSub, err = repo.Lock(userSubID)
+		if err != nil || userSub.UserID != userID {
+			return ErrUserSubscriptionNotFound
+		}
+
+		return apply(repo, tx, userSub)
+	})
+	if err != nil {
+		s.log.Warn("Не удалось изменить подписку",
+			"id", userSubID,
+			"user_id", userID,
+			"error", err.Error())
+		return nil, err
+	}
+
+	return userSub, nil
+}
+
+func (s *subscriptionLifecycle) event(repo repository.UserSubscriptionRepository, userSub *models.UserSubscription, kind, from, details string, entryID *uint) error {
+	return repo.CreateEvent(&models.SubscriptionEvent{
+		UserSubscriptionID: userSub.ID,
+		Event:              kind,
+		FromStatus:         from,
+		ToStatus:           userSub.Status,
+		Details:            details,
+		JournalEntryID:     entryID,
+	})
+}
+
+func subscriptionPeriod(sub *models.Subscription) time.Duration {
+	return time.Hour * 24 * time.Duration(sub.DurationDays)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+var ErrInvalidToken = errors.New("недействительный или просроченный токен")
+
+// TokenClaims — полезная нагрузка JWT, подписанного HS256
+type TokenClaims struct {
+	Subject   string `json:"sub"`
+	Type      string `json:"typ"`
+	ID        string `json:"jti,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func (c *TokenClaims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+
+	return uint(id), nil
+}
+
+type TokenManager interface {
+	NewAccessToken(userID uint) (string, error)
+	NewRefreshToken(userID uint) (token string, claims *TokenClaims, err error)
+	Parse(token string, tokenType string) (*TokenClaims, error)
+	AccessTTL() time.Duration
+}
+
+type hmacTokenManager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenManager(secret string, accessTTL, refreshTTL time.Duration) TokenManager {
+	return &hmacTokenManager{
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func (m *hmacTokenManager) AccessTTL() time.Duration {
+	return m.accessTTL
+}
+
+func (m *hmacTokenManager) NewAccessToken(userID uint) (string, error) {
+	now := time.Now()
+	return m.sign(&TokenClaims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		Type:      TokenTypeAccess,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(m.accessTTL).Unix(),
+	})
+}
+
+func (m *hmacTokenManager) NewRefreshToken(userID uint) (string, *TokenClaims, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &TokenClaims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		Type:      TokenTypeRefresh,
+		ID:        hex.EncodeToString(jti),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(m.refreshTTL).Unix(),
+	}
+
+	token, err := m.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, claims, nil
+}
+
+func (m *hmacTokenManager) Parse(token string, tokenType string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal(signature, m.signature(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Type != tokenType || time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+func (m *hmacTokenManager) sign(claims *TokenClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(m.signature(unsigned)), nil
+}
+
+func (m *hmacTokenManager) signature(data string) []byte {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const maxTopUpAmount = 1_000_000
+
+var (
+	ErrTopUpNotFound   = errors.New("пополнение не найдено")
+	ErrUnknownProvider = errors.New("неизвестный платёжный провайдер")
+	ErrTopUpsDisabled  = errors.New("пополнение баланса временно недоступно")
+)
+
+type TopUpService interface {
+	CreateTopUp(userID uint, req models.TopUpRequest) (*models.TopUp, error)
+	Confirm(userID, topUpID uint) (*models.TopUp, error)
+	HandleCallback(provider string, payload []byte, signature string) (*models.TopUp, error)
+	// GetByPayment находит пополнение пользователя по ID платежа у провайдера
+	GetByPayment(userID uint, provider, paymentID string) (*models.TopUp, error)
+	ListTopUps(userID uint) ([]models.TopUp, error)
+}
+
+type topUpService struct {
+	repo      repository.TopUpRepository
+	ledger    LedgerService
+	db        *gorm.DB
+	log       *slog.Logger
+	provider  PaymentProvider
+	providers map[string]PaymentProvider
+}
+
+// provider используется для новых пополнений; остальные провайдеры
+// нужны только для приёма уведомлений по уже созданным платежам.
+// provider может быть nil — тогда новые пополнения не создаются
+func NewTopUpService(
+	repo repository.TopUpRepository,
+	ledger LedgerService,
+	db *gorm.DB,
+	log *slog.Logger,
+	provider PaymentProvider,
+	others ...PaymentProvider,
+) TopUpService {
+	providers := map[string]PaymentProvider{}
+	for _, p := range append([]PaymentProvider{provider}, others...) {
+		if p != nil {
+			providers[p.Name()] = p
+		}
+	}
+
+	return &topUpService{
+		repo:      repo,
+		ledger:    ledger,
+		db:        db,
+		log:       log,
+		provider:  provider,
+		providers: providers,
+	}
+}
+
+func (s *topUpService) CreateTopUp(userID uint, req models.TopUpRequest) (*models.TopUp, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("сумма пополнения должна быть положительной")
+	}
+
+	if req.Amount > maxTopUpAmount {
+		return nil, fmt.Errorf("сумма пополнения не может превышать %d", maxTopUpAmount)
+	}
+
+	if s.provider == nil {
+		return nil, ErrTopUpsDisabled
+	}
+
+	intent, err := s.provider.CreateIntent(req.Amount, fmt.Sprintf("user:%d", userID))
+	if err != nil {
+		s.log.Error("Ошибка при создании платежа",
+			"provider", s.provider.Name(),
+			"error", err.Error())
+		return nil, fmt.Errorf("ошибка при создании платежа: %w", err)
+	}
+
+	topUp := &models.TopUp{
+		UserID:            userID,
+		Amount:            req.Amount,
+		Provider:          s.provider.Name(),
+		ProviderPaymentID: intent.ID,
+		Status:            models.TopUpStatusPending,
+		ConfirmationURL:   intent.ConfirmationURL,
+	}
+
+	if err := s.repo.Create(topUp); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении пополнения: %w", err)
+	}
+
+	s.log.Info("Создано пополнение",
+		"id", topUp.ID,
+		"user_id", userID,
+		"amount", req.Amount)
+
+	return topUp, nil
+}
+
+// Confirm запрашивает у провайдера статус платежа и, если он оплачен, зачисляет средства
+func (s *topUpService) Confirm(userID, topUpID uint) (*models.TopUp, error) {
+	topUp, err := s.repo.GetByID(topUpID)
+	if err != nil || topUp.UserID != userID {
+		return nil, ErrTopUpNotFound
+	}
+
+	if topUp.Status != models.TopUpStatusPending {
+		return topUp, nil
+	}
+
+	provider, ok := s.providers[topUp.Provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	intent, err := provider.Confirm(topUp.ProviderPaymentID)
+	if err != nil {
+		s.log.Error("Ошибка при проверке платежа",
+			"id", topUp.ID,
+			"error", err.Error())
+		return nil, fmt.Errorf("ошибка при проверке платежа: %w", err)
+	}
+
+	return s.apply(topUp.Provider, PaymentEvent{
+		PaymentID: intent.ID,
+		Status:    intent.Status,
+		Amount:    intent.Amount,
+	})
+}
+
+func (s *topUpService) HandleCallback(providerName string, payload []byte, signature string) (*models.TopUp, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	event, err := provider.HandleCallback(payload, signature)
+	if err != nil {
+		s.log.Warn("Отклонено уведомление провайдера",
+			"provider", providerName,
+			"error", err.Error())
+		return nil, err
+	}
+
+	return s.apply(providerName, *event)
+}
+
+func (s *topUpService) GetByPayment(userID uint, provider, paymentID string) (*models.TopUp, error) {
+	topUp, err := s.repo.GetByProviderPayment(provider, paymentID)
+	if err != nil || topUp.UserID != userID {
+		return nil, ErrTopUpNotFound
+	}
+
+	return topUp, nil
+}
+
+func (s *topUpService) ListTopUps(userID uint) ([]models.TopUp, error) {
+	return s.repo.ListByUser(userID)
+}
+
+// apply переводит пополнение в итоговый статус. Строка пополнения блокируется,
+// поэтому повторные уведомления и параллельные подтверждения зачисляют средства один раз
+func (s *topUpService) apply(provider string, event PaymentEvent) (*models.TopUp, error) {
+	var topUp *models.TopUp
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		var err error
+		topUp, err = repo.LockByProviderPayment(provider, event.PaymentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTopUpNotFound
+			}
+			return err
+		}
+
+		if topUp.Status != models.TopUpStatusPending {
+			return nil
+		}
+
+		switch event.Status {
+		case models.TopUpStatusSucceeded:
+			if event.Amount != topUp.Amount {
+				return fmt.Errorf("сумма платежа %d не совпадает с суммой пополнения %d", event.Amount, topUp.Amount)
+			}
+
+			entry, err := s.ledger.Credit(tx, topUp.UserID, topUp.Amount, AccountPaymentGateway,
+				models.EntryKindTopUp,
+				fmt.Sprintf("Пополнение через %s", provider),
+				fmt.Sprintf("topup:%d", topUp.ID))
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			topUp.Status = models.TopUpStatusSucceeded
+			topUp.JournalEntryID = &entry.ID
+			topUp.ConfirmedAt = &now
+		case models.TopUpStatusFailed:
+			topUp.Status = models.TopUpStatusFailed
+		default:
+			// платёж ещё не завершён
+			return nil
+		}
+
+		return repo.Update(topUp)
+	})
+	if err != nil {
+		s.log.Error("Ошибка при обработке платежа",
+			"provider", provider,
+			"payment_id", event.PaymentID,
+			"error", err.Error())
+		return nil, err
+	}
+
+	s.log.Info("Статус пополнения обновлён",
+		"id", topUp.ID,
+		"status", topUp.Status)
+
+	return topUp, nil
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"testing"
+)
+
+func TestCreateTopUpWithoutProvider(t *testing.T) {
+	topUps := NewTopUpService(nil, nil, nil, testLogger(), nil)
+
+	_, err := topUps.CreateTopUp(1, models.TopUpRequest{Amount: 100})
+	if !errors.Is(err, ErrTopUpsDisabled) {
+		t.Fatalf("CreateTopUp() без провайдера: error = %v, want %v", err, ErrTopUpsDisabled)
+	}
+
+	if _, err := topUps.HandleCallback(FakeProviderName, []byte("{}"), ""); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("HandleCallback() без провайдера: error = %v, want %v", err, ErrUnknownProvider)
+	}
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTrainerNotFound      = errors.New("профиль тренера не найден")
+	ErrTrainerProfileExists = errors.New("профиль тренера уже создан")
+)
+
+// сколько последних выплат показывать в кабинете тренера
+const dashboardPayouts = 10
+
+type TrainerService interface {
+	CreateProfile(userID uint, req models.CreateTrainerProfileRequest) (*models.TrainerProfile, error)
+	// GetProfile возвращает профиль тренера вместе с его категориями
+	GetProfile(userID uint) (*models.TrainerProfile, error)
+	ListProfiles() ([]models.TrainerProfile, error)
+	UpdateProfile(userID uint, req models.UpdateTrainerProfileRequest) (*models.TrainerProfile, error)
+	SetCommission(userID uint, req models.SetCommissionRequest) (*models.TrainerProfile, error)
+
+	// RevenueShare считает долю тренера — владельца категории в сумме продажи внутри транзакции tx.
+	// У категории без владельца доля пустая, и вся выручка остаётся платформе
+	RevenueShare(tx *gorm.DB, categoryID uint, amount int) (RevenueShare, error)
+
+	Dashboard(trainerID uint) (*models.TrainerDashboard, error)
+	// CreatePayout отмечает, что платформа выплатила тренеру amount вне системы
+	CreatePayout(adminID, trainerID uint, req models.CreatePayoutRequest) (*models.TrainerPayout, error)
+	Payouts(trainerID uint) ([]models.TrainerPayout, error)
+}
+
+type trainerService struct {
+	repo              repository.TrainerRepository
+	ledger            LedgerService
+	db                *gorm.DB
+	commissionPercent int
+	log               *slog.Logger
+}
+
+func NewTrainerService(
+	repo repository.TrainerRepository,
+	ledger LedgerService,
+	db *gorm.DB,
+	// commissionPercent — комиссия платформы для тренеров без индивидуальной
+	commissionPercent int,
+	log *slog.Logger,
+) TrainerService {
+	return &trainerService{
+		repo:              repo,
+		ledger:            ledger,
+		db:                db,
+		commissionPercent: commissionPercent,
+		log:               log,
+	}
+}
+
+func (s *trainerService) CreateProfile(userID uint, req models.CreateTrainerProfileRequest) (*models.TrainerProfile, error) {
+	if _, err := s.repo.GetByUserID(userID); err == nil {
+		return nil, ErrTrainerProfileExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	profile := &models.TrainerProfile{
+		UserID:      userID,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Bio:         strings.TrimSpace(req.Bio),
+		Specialties: normalizeSpecialties(req.Specialties),
+		PhotoURL:    strings.TrimSpace(req.PhotoURL),
+	}
+	if err := validateTrainerProfile(profile); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(profile); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Создан профиль тренера", "user_id", userID)
+	return profile, nil
+}
+
+func (s *trainerService) GetProfile(userID uint) (*models.TrainerProfile, error) {
+	profile, err := s.profile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.repo.Categories(userID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Categories = categories
+
+	return profile, nil
+}
+
+func (s *trainerService) ListProfiles() ([]models.TrainerProfile, error) {
+	return s.repo.List()
+}
+
+func (s *trainerService) UpdateProfile(userID uint, req models.UpdateTrainerProfileRequest) (*models.TrainerProfile, error) {
+	profile, err := s.profile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DisplayName != nil {
+		profile.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Bio != nil {
+		profile.Bio = strings.TrimSpace(*req.Bio)
+	}
+	if req.Specialties != nil {
+		profile.Specialties = normalizeSpecialties(*req.Specialties)
+	}
+	if req.PhotoURL != nil {
+		profile.PhotoURL = strings.TrimSpace(*req.PhotoURL)
+	}
+	if err := validateTrainerProfile(profile); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(profile); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+func (s *trainerService) SetCommission(userID uint, req models.SetCommissionRequest) (*models.TrainerProfile, error) {
+	if req.CommissionPercent != nil && (*req.CommissionPercent < 0 || *req.CommissionPercent > 100) {
+		return nil, errors.New("комиссия должна быть от 0 до 100 процентов")
+	}
+
+	profile, err := s.profile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.CommissionPercent = req.CommissionPercent
+	if err := s.repo.Update(profile); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Изменена комиссия тренера", "user_id", userID, "commission_percent", s.commission(profile))
+	return profile, nil
+}
+
+func (s *trainerService) RevenueShare(tx *gorm.DB, categoryID uint, amount int) (RevenueShare, error) {
+	profile, err := s.repo.WithTx(tx).CategoryTrainer(categoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return RevenueShare{}, nil
+	}
+	if err != nil {
+		return RevenueShare{}, err
+	}
+
+	// комиссия округляется до целого по правилам арифметики
+	commission := (amount*s.commission(profile) + 50) / 100
+	return RevenueShare{TrainerID: profile.UserID, Amount: amount - commission}, nil
+}
+
+func (s *trainerService) Dashboard(trainerID uint) (*models.TrainerDashboard, error) {
+	profile, err := s.profile(trainerID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.repo.Categories(trainerID)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := s.repo.Sales(trainerID)
+	if err != nil {
+		return nil, err
+	}
+	subscribers, totalSubscribers, err := s.repo.ActiveSubscribers(trainerID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &models.TrainerDashboard{
+		TrainerID:         trainerID,
+		CommissionPercent: s.commission(profile),
+		Categories:        make([]models.TrainerCategoryStats, 0, len(categories)),
+		ActiveSubscribers: totalSubscribers,
+	}
+	for _, category := range categories {
+		stats := models.TrainerCategoryStats{
+			CategoriesID:      category.ID,
+			Name:              category.Name,
+			Price:             category.Price,
+			Purchases:         sales[category.ID][models.ProgrammeSourcePurchase],
+			Gifts:             sales[category.ID][models.ProgrammeSourceGift],
+			ActiveSubscribers: subscribers[category.ID],
+		}
+		dashboard.Categories = append(dashboard.Categories, stats)
+		dashboard.Purchases += stats.Purchases
+		dashboard.Gifts += stats.Gifts
+	}
+
+	if dashboard.Owed, err = s.ledger.TrainerBalance(trainerID); err != nil {
+		return nil, err
+	}
+	if dashboard.PaidOut, err = s.repo.PaidOut(trainerID); err != nil {
+		return nil, err
+	}
+	dashboard.Earned = dashboard.Owed + dashboard.PaidOut
+
+	if dashboard.RecentPayouts, err = s.repo.ListPayouts(trainerID, dashboardPayouts); err != nil {
+		return nil, err
+	}
+
+	return dashboard, nil
+}
+
+func (s *trainerService) CreatePayout(adminID, trainerID uint, req models.CreatePayoutRequest) (*models.TrainerPayout, error) {
+	if _, err := s.profile(trainerID); err != nil {
+		return nil, err
+	}
+
+	payout := &models.TrainerPayout{
+		TrainerID:   trainerID,
+		Amount:      req.Amount,
+		Note:        strings.TrimSpace(req.Note),
+		CreatedByID: adminID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		entry, err := s.ledger.PayOut(tx, trainerID, req.Amount, adminID,
+			fmt.Sprintf("Выплата тренеру %d", trainerID),
+			fmt.Sprintf("trainer:%d", trainerID))
+		if err != nil {
+			return err
+		}
+		payout.JournalEntryID = entry.ID
+
+		return s.repo.WithTx(tx).CreatePayout(payout)
+	})
+	if err != nil {
+		s.log.Warn("Выплата тренеру не проведена",
+			"trainer_id", trainerID,
+			"amount", req.Amount,
+			"error", err.Error())
+		return nil, err
+	}
+
+	s.log.Info("Проведена выплата тренеру",
+		"id", payout.ID,
+		"trainer_id", trainerID,
+		"amount", payout.Amount,
+		"admin_id", adminID)
+
+	return payout, nil
+}
+
+func (s *trainerService) Payouts(trainerID uint) ([]models.TrainerPayout, error) {
+	if _, err := s.profile(trainerID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListPayouts(trainerID, 0)
+}
+
+func (s *trainerService) profile(userID uint) (*models.TrainerProfile, error) {
+	profile, err := s.repo.GetByUserID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTrainerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+func (s *trainerService) commission(profile *models.TrainerProfile) int {
+	if profile.CommissionPercent != nil {
+		return *profile.CommissionPercent
+	}
+	return s.commissionPercent
+}
+
+func validateTrainerProfile(profile *models.TrainerProfile) error {
+	if profile.DisplayName == "" {
+		return errors.New("укажите имя тренера")
+	}
+
+	if profile.PhotoURL != "" && !strings.HasPrefix(profile.PhotoURL, "http://") && !strings.HasPrefix(profile.PhotoURL, "https://") {
+		return errors.New("ссылка на фото должна начинаться с http:// или https://")
+	}
+
+	return nil
+}
+
+// normalizeSpecialties убирает пустые и повторяющиеся специализации
+func normalizeSpecialties(specialties []string) []string {
+	result := make([]string, 0, len(specialties))
+	for _, specialty := range specialties {
+		specialty = strings.TrimSpace(specialty)
+		if specialty != "" && !slices.Contains(result, specialty) {
+			result = append(result, specialty)
+		}
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeTrainerRepository отвечает только на поиск владельца категории;
+// остальные методы не нужны тестам и не реализованы
+type fakeTrainerRepository struct {
+	repository.TrainerRepository
+	owners map[uint]*models.TrainerProfile
+}
+
+func (r *fakeTrainerRepository) WithTx(tx *gorm.DB) repository.TrainerRepository {
+	return r
+}
+
+func (r *fakeTrainerRepository) CategoryTrainer(categoryID uint) (*models.TrainerProfile, error) {
+	profile, ok := r.owners[categoryID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return profile, nil
+}
+
+func TestRevenueShare(t *testing.T) {
+	individual := 5
+	repo := &fakeTrainerRepository{owners: map[uint]*models.TrainerProfile{
+		1: {UserID: 10},
+		2: {UserID: 20, CommissionPercent: &individual},
+	}}
+	trainers := NewTrainerService(repo, nil, nil, 20, testLogger())
+
+	tests := []struct {
+		name       string
+		categoryID uint
+		amount     int
+		want       RevenueShare
+	}{
+		{name: "category without trainer", categoryID: 3, amount: 1000, want: RevenueShare{}},
+		{name: "default commission", categoryID: 1, amount: 1000, want: RevenueShare{TrainerID: 10, Amount: 800}},
+		{name: "fraction below half rounds down", categoryID: 1, amount: 1002, want: RevenueShare{TrainerID: 10, Amount: 802}},      // 200.4 → 200
+		{name: "fraction above half rounds up", categoryID: 1, amount: 1003, want: RevenueShare{TrainerID: 10, Amount: 802}},        // 200.6 → 201
+		{name: "commission rounds down to zero", categoryID: 1, amount: 2, want: RevenueShare{TrainerID: 10, Amount: 2}},            // 0.4 → 0
+		{name: "small amount", categoryID: 1, amount: 3, want: RevenueShare{TrainerID: 10, Amount: 2}},                              // 0.6 → 1
+		{name: "individual commission, half rounds up", categoryID: 2, amount: 990, want: RevenueShare{TrainerID: 20, Amount: 940}}, // 49.5 → 50
+		{name: "free category", categoryID: 1, amount: 0, want: RevenueShare{TrainerID: 10, Amount: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := trainers.RevenueShare(nil, tt.categoryID, tt.amount)
+			if err != nil {
+				t.Fatalf("RevenueShare: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RevenueShare(%d, %d) = %+v, want %+v", tt.categoryID, tt.amount, got, tt.want)
+			}
+			if got.Amount < 0 || got.Amount > tt.amount {
+				t.Errorf("доля %d вне суммы %d", got.Amount, tt.amount)
+			}
+		})
+	}
+}
@@ -6,43 +6,68 @@ import (
 	"healthy_body/internal/models"
 	"healthy_body/internal/repository"
 	"log/slog"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEmailTaken        = errors.New("пользователь с таким email уже существует")
+	ErrProgrammeNotOwned = errors.New("программа не куплена пользователем")
+	ErrProgrammeOwned    = errors.New("эта категория уже есть у пользователя")
 )
 
 type UserService interface {
 	CreateUser(req models.CreateUserRequest) (*models.User, error)
 	GetAllUsers() ([]models.User, error)
 	GetUserByID(id uint) (*models.User, error)
-	GetUserPlan(userID uint) (*models.Categories, error)
-	GetUserCategory(userID uint) (*models.User, error)
+	GetUserPlan(userID uint) ([]models.OwnedProgramme, error)
+	GetUserCategory(userID uint) ([]models.OwnedProgramme, error)
+	SetActiveProgramme(userID, categoryID uint) (*models.User, error)
+	// SetDietaryPreferences заменяет диеты и аллергены пользователя
+	SetDietaryPreferences(userID uint, prefs models.DietaryPreferences) (*models.User, error)
 	GetUserSub(userID uint) (*models.User, error)
 	UpdateUser(id uint, req models.UpdateUserRequest) (*models.User, error)
+	SetRole(id uint, role string) (*models.User, error)
 	Delete(id uint) error
+	ImportLegacyActiveProgrammes() error
 
-	Payment(userID uint, categoryID uint) error
-	SubPayment(userID, subID uint) error
+	Payment(userID uint, categoryID uint, promoCode string) error
+	SubPayment(userID, subID uint, promoCode string) error
 	PaymentToAnother(userID uint, categoryID uint, secondUserID uint) error
 }
 
 type userService struct {
-	userRepo     repository.UserRepository
-	log          *slog.Logger
-	db           *gorm.DB
-	sub          SubscriptionService
-	categoryRepo repository.CategoryRepo
-	notifier     NotificationService
+	userRepo      repository.UserRepository
+	log           *slog.Logger
+	db            *gorm.DB
+	sub           SubscriptionService
+	categoryRepo  repository.CategoryRepo
+	notifier      NotificationService
+	ledger        LedgerService
+	subscriptions SubscriptionLifecycle
+	promos        PromoService
+	gifts         GiftService
+	trainers      TrainerService
+	versions      PlanVersionService
 }
 
-func NewUserService(userRepo repository.UserRepository, log *slog.Logger, db *gorm.DB, sub SubscriptionService, categoryRepo repository.CategoryRepo, notifier NotificationService) UserService {
+func NewUserService(userRepo repository.UserRepository, log *slog.Logger, db *gorm.DB, sub SubscriptionService, categoryRepo repository.CategoryRepo, notifier NotificationService, ledger LedgerService, subscriptions SubscriptionLifecycle, promos PromoService, gifts GiftService, trainers TrainerService, versions PlanVersionService) UserService {
 	return &userService{
-		userRepo:     userRepo,
-		log:          log,
-		db:           db,
-		sub:          sub,
-		categoryRepo: categoryRepo,
-		notifier:     notifier,
+		userRepo:      userRepo,
+		log:           log,
+		db:            db,
+		sub:           sub,
+		categoryRepo:  categoryRepo,
+		notifier:      notifier,
+		ledger:        ledger,
+		subscriptions: subscriptions,
+		promos:        promos,
+		gifts:         gifts,
+		trainers:      trainers,
+		versions:      versions,
 	}
 }
 
@@ -56,11 +81,32 @@ func (s *userService) CreateUser(req models.CreateUserRequest) (*models.User, er
 
 	}
 
+	if !strings.Contains(req.Email, "@") {
+		s.log.Warn("Некорректный email при регистрации", "почта", req.Email)
+		return nil, fmt.Errorf("некорректный email")
+	}
+
+	if len(req.Password) < 8 {
+		s.log.Warn("Слишком короткий пароль при регистрации", "почта", req.Email)
+		return nil, fmt.Errorf("пароль должен содержать минимум 8 символов")
+	}
+
+	if _, err := s.userRepo.GetUserByEmail(req.Email); err == nil {
+		s.log.Warn("Email уже занят", "почта", req.Email)
+		return nil, ErrEmailTaken
+	}
+
+	passwordHash, err := hashPassword(req.Password)
+	if err != nil {
+		s.log.Error("Ошибка при хешировании пароля", "error", err.Error())
+		return nil, fmt.Errorf("ошибка при создании пользователя: %w", err)
+	}
+
 	newUser := &models.User{
-		Name:       req.Name,
-		Balance:    0,
-		Email:      req.Email,
-		CategoriesID: 2,
+		Name:         req.Name,
+		Email:        req.Email,
+		PasswordHash: passwordHash,
+		Role:         models.RoleClient,
 	}
 
 	if err := s.userRepo.Create(newUser); err != nil {
@@ -91,6 +137,12 @@ func (s *userService) GetAllUsers() ([]models.User, error) {
 		return nil, fmt.Errorf("ошибка при выводе пользователей: %w", err)
 	}
 
+	if err := s.ledger.FillBalances(result); err != nil {
+		s.log.Error("Ошибка при расчёте балансов",
+			"error", err.Error())
+		return nil, fmt.Errorf("ошибка при расчёте балансов: %w", err)
+	}
+
 	s.log.Info("Пользователи получены",
 		"количество пользователей", len(result))
 
@@ -113,6 +165,13 @@ func (s *userService) GetUserByID(id uint) (*models.User, error) {
 		return nil, fmt.Errorf("ошибка при выводе пользователя: %w", err)
 	}
 
+	if result.Balance, err = s.ledger.Balance(result.ID); err != nil {
+		s.log.Error("Ошибка при расчёте баланса",
+			"id", id,
+			"error", err.Error())
+		return nil, fmt.Errorf("ошибка при расчёте баланса: %w", err)
+	}
+
 	s.log.Info("Пользователь найден",
 		"id", result.ID,
 		"имя", result.Name,
@@ -123,32 +182,185 @@ func (s *userService) GetUserByID(id uint) (*models.User, error) {
 	return result, nil
 }
 
-func (s *userService) GetUserPlan(userID uint) (*models.Categories, error) {
+// GetUserPlan возвращает все программы пользователя вместе с планами тренировок и питания
+func (s *userService) GetUserPlan(userID uint) ([]models.OwnedProgramme, error) {
+	return s.programmes(userID, true)
+}
 
-	user, err := s.userRepo.GetUserByID(userID)
+// GetUserCategory возвращает все программы пользователя без содержимого
+func (s *userService) GetUserCategory(userID uint) ([]models.OwnedProgramme, error) {
+	return s.programmes(userID, false)
+}
+
+func (s *userService) SetActiveProgramme(userID, categoryID uint) (*models.User, error) {
+	owned, err := s.programmes(userID, false)
 	if err != nil {
-		s.log.Error("invalid user id")
 		return nil, err
 	}
-	category, err := s.categoryRepo.GetWithPlans(user.CategoriesID)
+
+	found := false
+	for _, programme := range owned {
+		if programme.CategoriesID == categoryID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		s.log.Warn("Попытка выбрать чужую программу",
+			"user_id", userID,
+			"category_id", categoryID)
+		return nil, ErrProgrammeNotOwned
+	}
+
+	user, err := s.GetUserByID(userID)
 	if err != nil {
-		s.log.Error("invalid user category id")
 		return nil, err
 	}
 
-	return category, nil
+	user.ActiveCategoriesID = &categoryID
+
+	if err := s.userRepo.Update(user); err != nil {
+		s.log.Error("Ошибка при смене активной программы",
+			"error", err.Error())
+		return nil, fmt.Errorf("ошибка при смене активной программы %w", err)
+	}
+
+	s.log.Info("Активная программа изменена",
+		"user_id", userID,
+		"category_id", categoryID)
+
+	return user, nil
 }
 
-func (s *userService) GetUserCategory(userID uint) (*models.User, error) {
-	user, err := s.userRepo.GeUserCategory(userID)
+func (s *userService) SetDietaryPreferences(userID uint, prefs models.DietaryPreferences) (*models.User, error) {
+	tags, ok := models.NormalizeTags(prefs.DietTags, models.DietTags)
+	if !ok {
+		return nil, fmt.Errorf("диеты должны быть из списка: %s", strings.Join(models.DietTags, ", "))
+	}
+	allergens, ok := models.NormalizeTags(prefs.Allergens, models.Allergens)
+	if !ok {
+		return nil, fmt.Errorf("аллергены должны быть из списка: %s", strings.Join(models.Allergens, ", "))
+	}
+
+	user, err := s.GetUserByID(userID)
 	if err != nil {
-		s.log.Error("error user not found")
 		return nil, err
 	}
 
+	user.DietTags = tags
+	user.Allergens = allergens
+
+	if err := s.userRepo.Update(user); err != nil {
+		s.log.Error("Ошибка при сохранении пищевых предпочтений",
+			"error", err.Error())
+		return nil, fmt.Errorf("ошибка при сохранении пищевых предпочтений %w", err)
+	}
+
+	s.log.Info("Пищевые предпочтения обновлены",
+		"user_id", userID,
+		"diet_tags", tags,
+		"allergens", allergens)
+
 	return user, nil
 }
 
+func (s *userService) programmes(userID uint, withContent bool) ([]models.OwnedProgramme, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		s.log.Error("invalid user id")
+		return nil, err
+	}
+
+	plans, err := s.userRepo.GetUserPlans(userID, withContent)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении программ пользователя: %w", err)
+	}
+
+	subs, err := s.userRepo.GetActiveSubscriptions(userID, time.Now(), withContent)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении подписок пользователя: %w", err)
+	}
+
+	isActive := func(categoryID uint) bool {
+		return user.ActiveCategoriesID != nil && *user.ActiveCategoriesID == categoryID
+	}
+
+	result := make([]models.OwnedProgramme, 0, len(plans)+len(subs))
+	for _, plan := range plans {
+		source := plan.Source
+		if source == "" {
+			source = models.ProgrammeSourcePurchase
+		}
+
+		result = append(result, models.OwnedProgramme{
+			CategoriesID: plan.CategoriesID,
+			Source:       source,
+			PurchasedAt:  plan.CreatedAt,
+			Active:       isActive(plan.CategoriesID),
+			Categories:   plan.Categories,
+		})
+	}
+
+	for _, userSub := range subs {
+		if userSub.Subscription == nil {
+			continue
+		}
+
+		endDate := userSub.EndDate
+		result = append(result, models.OwnedProgramme{
+			CategoriesID: userSub.Subscription.CategoriesID,
+			Source:       models.ProgrammeSourceSubscription,
+			PurchasedAt:  userSub.StartDate,
+			ExpiresAt:    &endDate,
+			Active:       isActive(userSub.Subscription.CategoriesID),
+			Categories:   userSub.Subscription.Categories,
+		})
+	}
+
+	// планы показываются в версии, закреплённой за покупкой, а не черновиком автора
+	if withContent {
+		for _, programme := range result {
+			if programme.Categories == nil {
+				continue
+			}
+			if err := s.versions.ResolveCategory(user, programme.Categories); err != nil {
+				return nil, fmt.Errorf("ошибка при получении версий планов: %w", err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// ImportLegacyActiveProgrammes переносит единственную категорию из users.categories_id
+// в активную программу и удаляет старую колонку
+func (s *userService) ImportLegacyActiveProgrammes() error {
+	if !s.db.Migrator().HasColumn("users", "categories_id") {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// активной становится только категория, которую пользователь действительно купил
+		if err := tx.Exec(`UPDATE users SET active_categories_id = categories_id
+			WHERE active_categories_id IS NULL AND EXISTS (
+				SELECT 1 FROM user_plans
+				WHERE user_plans.user_id = users.id
+				AND user_plans.categories_id = users.categories_id
+				AND user_plans.deleted_at IS NULL)`).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`UPDATE user_plans SET source = ?
+			WHERE journal_entry_id IN (SELECT id FROM journal_entries WHERE kind = ?)`,
+			models.ProgrammeSourceGift, models.EntryKindGift).Error; err != nil {
+			return err
+		}
+
+		s.log.Info("Активные программы перенесены из профиля пользователя")
+		return tx.Migrator().DropColumn("users", "categories_id")
+	})
+}
+
 func (s *userService) GetUserSub(userID uint) (*models.User, error) {
 	user, err := s.userRepo.GetUserSub(userID)
 	if err != nil {
@@ -161,7 +373,7 @@ func (s *userService) GetUserSub(userID uint) (*models.User, error) {
 
 func (s *userService) UpdateUser(id uint, req models.UpdateUserRequest) (*models.User, error) {
 
-	if req.Name == nil && req.Balance == nil {
+	if req.Name == nil && req.Email == nil {
 		s.log.Warn("Нет полей для обновления", "id", id)
 		return nil, fmt.Errorf("не указаны поля для обновления")
 	}
@@ -175,24 +387,18 @@ func (s *userService) UpdateUser(id uint, req models.UpdateUserRequest) (*models
 		}
 	}
 
-	if req.Balance != nil {
-		balance := *req.Balance
-		if balance < 0 {
-			s.log.Warn("Отрицательный баланс при обновлении",
-				"id", id,
-				"balance", balance,
-			)
-			return nil, fmt.Errorf("баланс не может быть отрицательным")
-		}
-	}
-
 	if req.Email != nil {
-		if len(*req.Email) < 2 {
+		if len(*req.Email) < 3 || !strings.Contains(*req.Email, "@") {
 			s.log.Warn("Некорректный email",
 				"id", id,
 				"name", *req.Email)
 			return nil, fmt.Errorf("email должен содержать минимум 3 символа и '@'")
 		}
+
+		if existing, err := s.userRepo.GetUserByEmail(*req.Email); err == nil && existing.ID != id {
+			s.log.Warn("Email уже занят", "id", id)
+			return nil, ErrEmailTaken
+		}
 	}
 
 	user, err := s.GetUserByID(id)
@@ -206,9 +412,6 @@ func (s *userService) UpdateUser(id uint, req models.UpdateUserRequest) (*models
 	if req.Name != nil {
 		user.Name = *req.Name
 	}
-	if req.Balance != nil {
-		user.Balance = *req.Balance
-	}
 	if req.Email != nil {
 		user.Email = *req.Email
 	}
@@ -222,12 +425,33 @@ func (s *userService) UpdateUser(id uint, req models.UpdateUserRequest) (*models
 	s.log.Info("Пользователь обновлен",
 		"id", id,
 		"имя", req.Name,
-		"баланс", req.Balance,
 		"почта", req.Email,
 	)
 	return user, nil
 }
 
+func (s *userService) SetRole(id uint, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		s.log.Warn("Неизвестная роль", "id", id, "role", role)
+		return nil, fmt.Errorf("неизвестная роль: %s", role)
+	}
+
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+
+	if err := s.userRepo.Update(user); err != nil {
+		s.log.Error("Ошибка при изменении роли",
+			"error", err.Error())
+		return nil, fmt.Errorf("ошибка при изменении роли %w", err)
+	}
+
+	return user, nil
+}
+
 func (s *userService) Delete(id uint) error {
 
 	if err := s.userRepo.Delete(id); err != nil {
@@ -240,12 +464,23 @@ func (s *userService) Delete(id uint) error {
 	return nil
 }
 
+// PaymentToAnother отправляет категорию в подарок; получатель должен его принять
 func (s *userService) PaymentToAnother(userID uint, categoryID uint, secondUserID uint) error {
+	_, err := s.gifts.Send(userID, models.CreateGiftRequest{
+		RecipientID: secondUserID,
+		CategoryID:  categoryID,
+	})
+	return err
+}
+
+func (s *userService) Payment(userID uint, categoryID uint, promoCode string) error {
 	err := s.db.Transaction(func(tx *gorm.DB) error {
+
 		var user models.User
-		var userSec models.User
 
-		if err := tx.First(&user, userID).Error; err != nil {
+		// блокировка строки покупателя выстраивает его параллельные покупки в очередь,
+		// иначе обе прошли бы проверку владения ниже и списали деньги дважды
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
 			s.log.Error("Ошибка при поиске пользователя",
 				"error", err.Error())
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -254,15 +489,6 @@ func (s *userService) PaymentToAnother(userID uint, categoryID uint, secondUserI
 			return fmt.Errorf("ошибка при поиске пользователя %w", err)
 		}
 
-		if err := tx.First(&userSec, secondUserID).Error; err != nil {
-			s.log.Error("Ошибка при поиске второго пользователя",
-				"error", err.Error())
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return fmt.Errorf("второй пользователь не найден")
-			}
-			return fmt.Errorf("ошибка при поиске второго пользователя %w", err)
-		}
-
 		var category models.Categories
 
 		if err := tx.First(&category, categoryID).Error; err != nil {
@@ -274,83 +500,56 @@ func (s *userService) PaymentToAnother(userID uint, categoryID uint, secondUserI
 			return fmt.Errorf("ошибка при поиске категории %w", err)
 		}
 
-		if user.Balance < category.Price {
-			s.log.Warn("Недостаточно средств на счету")
-			return fmt.Errorf("недостаточно средств на счету")
-		}
-
-		user.Balance -= category.Price
-		userSec.CategoriesID = categoryID
-
-		userPlan := &models.UserPlan{
-			UserID:     secondUserID,
-			CategoriesID: categoryID,
+		// повторная покупка списала бы деньги за уже открытую программу
+		var owned int64
+		if err := tx.Model(&models.UserPlan{}).
+			Where("user_id = ? AND categories_id = ?", user.ID, category.ID).
+			Count(&owned).Error; err != nil {
+			return err
 		}
-
-		if err := tx.Create(&userPlan).Error; err != nil {
-			s.log.Error("Ошибка при записи покупки пользователя",
-				"error", err.Error())
-			return fmt.Errorf("ошибка при записи покупки пользователя %w", err)
+		if owned > 0 {
+			return ErrProgrammeOwned
 		}
 
-		if err := tx.Save(&user).Error; err != nil {
-			s.log.Error("Ошибка при сохранении пользователя",
-				"error", err.Error())
-			return fmt.Errorf("ошибка при сохранении пользователя %w", err)
-		}
-		if err := tx.Save(&userSec).Error; err != nil {
-			s.log.Error("Ошибка при сохранении пользователя",
-				"error", err.Error())
-			return fmt.Errorf("ошибка при сохранении пользователя %w", err)
+		price := category.Price
+		var promo *PromoApplication
+		if promoCode != "" {
+			applied, err := s.promos.Apply(tx, user.ID, promoCode, models.PromoTargetCategory, categoryID, price)
+			if err != nil {
+				return err
+			}
+			promo = applied
+			price = promo.FinalPrice
 		}
 
-		s.log.Info("Оплата прошла успешно")
-
-		if err := s.notifier.SendPaymentSuccess(&user, &category); err != nil {
-			s.log.Error("не удалось отправить уведомление", "err", err)
+		share, err := s.trainers.RevenueShare(tx, categoryID, price)
+		if err != nil {
+			return err
 		}
 
-		return nil
-	})
-	return err
-}
-
-func (s *userService) Payment(userID uint, categoryID uint) error {
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-
-		var user models.User
-
-		if err := tx.First(&user, userID).Error; err != nil {
-			s.log.Error("Ошибка при поиске пользователя",
-				"error", err.Error())
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return fmt.Errorf("пользователь не найден")
-			}
-			return fmt.Errorf("ошибка при поиске пользователя %w", err)
+		entry, err := s.ledger.Charge(tx, user.ID, price, share, models.EntryKindPurchase,
+			fmt.Sprintf("Покупка категории «%s»", category.Name),
+			fmt.Sprintf("category:%d", categoryID))
+		if err != nil {
+			return err
 		}
 
-		var category models.Categories
-
-		if err := tx.First(&category, categoryID).Error; err != nil {
-			s.log.Error("Ошибка при поиске категории",
-				"error", err.Error())
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return fmt.Errorf("категория не найдена")
+		if promo != nil {
+			if err := s.promos.Redeem(tx, user.ID, promo, entry.ID); err != nil {
+				return err
 			}
-			return fmt.Errorf("ошибка при поиске категории %w", err)
 		}
 
-		if user.Balance < category.Price {
-			s.log.Warn("Недостаточно средств на счету")
-			return fmt.Errorf("недостаточно средств на счету")
+		// первая купленная программа сразу становится активной
+		if user.ActiveCategoriesID == nil {
+			user.ActiveCategoriesID = &categoryID
 		}
 
-		user.Balance -= category.Price
-		user.CategoriesID = categoryID
-
 		userPlan := &models.UserPlan{
 			UserID:     user.ID,
-			CategoriesID: user.CategoriesID,
+			CategoriesID: categoryID,
+			JournalEntryID: &entry.ID,
+			Source:     models.ProgrammeSourcePurchase,
 		}
 
 		if err := tx.Create(&userPlan).Error; err != nil {
@@ -375,44 +574,7 @@ func (s *userService) Payment(userID uint, categoryID uint) error {
 	return err
 }
 
-func (s *userService) SubPayment(userID, subID uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
-
-		var user models.User
-		if err := tx.First(&user, userID).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return fmt.Errorf("пользователь не найден")
-			}
-			return fmt.Errorf("user not found: %w", err)
-		}
-
-		sub, err := s.sub.GetSubByID(subID)
-		if err != nil {
-			return fmt.Errorf("subscription not found: %w", err)
-		}
-
-		if user.Balance < sub.Price {
-			return fmt.Errorf("недостаточно средств")
-		}
-
-		user.Balance -= sub.Price
-
-		if err := tx.Save(&user).Error; err != nil {
-			return fmt.Errorf("cannot update user balance: %w", err)
-		}
-
-		userSub := &models.UserSubscription{
-			UserID:         userID,
-			SubscriptionID: subID,
-			StartDate:      time.Now(),
-			EndDate:        time.Now().Add(time.Hour * 24 * time.Duration(sub.DurationDays)),
-			IsActive:       true,
-		}
-
-		if err := tx.Create(&userSub).Error; err != nil {
-			return fmt.Errorf("cannot create user subscription: %w", err)
-		}
-
-		return nil
-	})
+func (s *userService) SubPayment(userID, subID uint, promoCode string) error {
+	_, err := s.subscriptions.Purchase(userID, subID, promoCode)
+	return err
 }
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"healthy_body/internal/models"
+	"healthy_body/internal/repository"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWorkoutNotFound     = errors.New("тренировка не найдена")
+	ErrWorkoutInProgress   = errors.New("есть незавершённая тренировка")
+	ErrWorkoutAccessDenied = errors.New("тренировочный план доступен после покупки категории или оформления подписки")
+)
+
+type WorkoutService interface {
+	Start(user *models.User, req models.StartWorkoutRequest) (*models.WorkoutSession, error)
+	LogSet(userID, sessionID uint, req models.LogWorkoutSetRequest) (*models.WorkoutSet, error)
+	Finish(userID, sessionID uint, req models.FinishWorkoutRequest) (*models.WorkoutSession, error)
+	GetSession(userID, sessionID uint) (*models.WorkoutSession, error)
+	ListSessions(userID uint, planID *uint) ([]models.WorkoutSession, error)
+	// Progress возвращает историю упражнения по тренировкам и личные рекорды
+	Progress(userID, itemID uint) (*models.ExerciseProgress, error)
+	// Adherence сравнивает выполненные подходы с запланированными на неделю, в которую попадает week;
+	// план этой недели берётся с учётом прогрессии и разгрузки
+	Adherence(userID, planID uint, week time.Time) (*models.WorkoutAdherence, error)
+}
+
+type workoutService struct {
+	repo         repository.WorkoutRepository
+	plans        repository.ExercisePlanRepo
+	entitlements EntitlementService
+	log          *slog.Logger
+}
+
+func NewWorkoutService(
+	repo repository.WorkoutRepository,
+	plans repository.ExercisePlanRepo,
+	entitlements EntitlementService,
+	log *slog.Logger,
+) WorkoutService {
+	return &workoutService{
+		repo:         repo,
+		plans:        plans,
+		entitlements: entitlements,
+		log:          log,
+	}
+}
+
+func (s *workoutService) Start(user *models.User, req models.StartWorkoutRequest) (*models.WorkoutSession, error) {
+	plan, err := s.plans.GetByIDExercisePlanForNotPreload(req.ExercisePlanID)
+	if err != nil {
+		return nil, fmt.Errorf("тренировочный план не найден")
+	}
+
+	allowed, err := s.entitlements.CanViewExercisePlan(user, plan)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		s.log.Warn("Попытка начать тренировку без доступа к плану",
+			"user_id", user.ID,
+			"plan_id", plan.ID)
+		return nil, ErrWorkoutAccessDenied
+	}
+
+	if _, err := s.repo.GetOpenSession(user.ID); err == nil {
+		return nil, ErrWorkoutInProgress
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	session := &models.WorkoutSession{
+		UserID:         user.ID,
+		ExercisePlanID: plan.ID,
+		StartedAt:      time.Now(),
+		Notes:          strings.TrimSpace(req.Notes),
+	}
+
+	if err := s.repo.CreateSession(session); err != nil {
+		return nil, fmt.Errorf("ошибка при создании тренировки: %w", err)
+	}
+
+	s.log.Info("Тренировка начата",
+		"id", session.ID,
+		"user_id", user.ID,
+		"plan_id", plan.ID)
+
+	return session, nil
+}
+
+func (s *workoutService) LogSet(userID, sessionID uint, req models.LogWorkoutSetRequest) (*models.WorkoutSet, error) {
+	if req.Reps < 0 || req.WeightKg < 0 || req.DurationSeconds < 0 {
+		return nil, errors.New("повторы, вес и длительность не могут быть отрицательными")
+	}
+	if req.Reps == 0 && req.DurationSeconds == 0 {
+		return nil, errors.New("укажите количество повторов или длительность подхода")
+	}
+
+	session, err := s.GetSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.FinishedAt != nil {
+		return nil, errors.New("тренировка уже завершена")
+	}
+
+	item, err := s.plans.GetByIDExercisePlanItem(req.ExercisePlanItemID)
+	if err != nil || item.ExercisePlanID != session.ExercisePlanID {
+		return nil, errors.New("упражнение не относится к плану тренировки")
+	}
+
+	done, err := s.repo.CountSets(session.ID, item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &models.WorkoutSet{
+		WorkoutSessionID:   session.ID,
+		ExercisePlanItemID: item.ID,
+		SetNumber:          int(done) + 1,
+		Reps:               req.Reps,
+		WeightKg:           req.WeightKg,
+		DurationSeconds:    req.DurationSeconds,
+	}
+
+	if err := s.repo.CreateSet(set); err != nil {
+		return nil, fmt.Errorf("ошибка при записи подхода: %w", err)
+	}
+
+	return set, nil
+}
+
+func (s *workoutService) Finish(userID, sessionID uint, req models.FinishWorkoutRequest) (*models.WorkoutSession, error) {
+	session, err := s.GetSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.FinishedAt != nil {
+		return nil, errors.New("тренировка уже завершена")
+	}
+
+	now := time.Now()
+	session.FinishedAt = &now
+	if req.Notes != nil {
+		session.Notes = strings.TrimSpace(*req.Notes)
+	}
+
+	if err := s.repo.UpdateSession(session); err != nil {
+		return nil, fmt.Errorf("ошибка при завершении тренировки: %w", err)
+	}
+
+	s.log.Info("Тренировка завершена",
+		"id", session.ID,
+		"user_id", userID,
+		"sets", len(session.Sets))
+
+	return session, nil
+}
+
+func (s *workoutService) GetSession(userID, sessionID uint) (*models.WorkoutSession, error) {
+	session, err := s.repo.GetSession(sessionID)
+	if err != nil || session.UserID != userID {
+		return nil, ErrWorkoutNotFound
+	}
+
+	return session, nil
+}
+
+func (s *workoutService) ListSessions(userID uint, planID *uint) ([]models.WorkoutSession, error) {
+	return s.repo.ListSessions(userID, planID)
+}
+
+func (s *workoutService) Progress(userID, itemID uint) (*models.ExerciseProgress, error) {
+	item, err := s.plans.GetByIDExercisePlanItem(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("упражнение не найдено")
+	}
+
+	sets, err := s.repo.ListSets(models.WorkoutSetFilter{
+		UserID:             userID,
+		ExercisePlanItemID: &itemID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &models.ExerciseProgress{
+		ExercisePlanItemID: item.ID,
+		Name:               item.Name,
+		History:            []models.ExerciseProgressPoint{},
+	}
+
+	// подходы отсортированы по времени, поэтому тренировки идут подряд
+	points := map[uint]int{}
+	for _, set := range sets {
+		index, ok := points[set.WorkoutSessionID]
+		if !ok {
+			date := set.CreatedAt
+			if set.WorkoutSession != nil {
+				date = set.WorkoutSession.StartedAt
+			}
+			progress.History = append(progress.History, models.ExerciseProgressPoint{
+				WorkoutSessionID: set.WorkoutSessionID,
+				Date:             date,
+			})
+			index = len(progress.History) - 1
+			points[set.WorkoutSessionID] = index
+		}
+
+		volume := float64(set.Reps) * set.WeightKg
+		point := &progress.History[index]
+		point.Sets++
+		point.Reps += set.Reps
+		point.Volume += volume
+		point.DurationSeconds += set.DurationSeconds
+		point.MaxWeightKg = math.Max(point.MaxWeightKg, set.WeightKg)
+
+		progress.TotalVolume += volume
+		records := &progress.Records
+		records.MaxWeightKg = math.Max(records.MaxWeightKg, set.WeightKg)
+		if set.Reps > records.MaxReps {
+			records.MaxReps = set.Reps
+		}
+		records.EstimatedOneRepMax = math.Max(records.EstimatedOneRepMax, oneRepMax(set.WeightKg, set.Reps))
+	}
+
+	for _, point := range progress.History {
+		progress.Records.MaxSessionVolume = math.Max(progress.Records.MaxSessionVolume, point.Volume)
+	}
+
+	return progress, nil
+}
+
+func (s *workoutService) Adherence(userID, planID uint, week time.Time) (*models.WorkoutAdherence, error) {
+	plan, err := s.plans.GetByIDExercisePlan(planID)
+	if err != nil {
+		return nil, fmt.Errorf("тренировочный план не найден")
+	}
+
+	from := weekStart(week)
+	to := from.AddDate(0, 0, 7)
+
+	sets, err := s.repo.ListSets(models.WorkoutSetFilter{
+		UserID:         userID,
+		ExercisePlanID: &planID,
+		From:           &from,
+		To:             &to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	done := map[uint]int{}
+	sessions := map[uint]bool{}
+	for _, set := range sets {
+		done[set.ExercisePlanItemID]++
+		sessions[set.WorkoutSessionID] = true
+	}
+
+	started, err := s.planStart(userID, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	adherence := &models.WorkoutAdherence{
+		ExercisePlanID: plan.ID,
+		PlanWeek:       planWeek(plan, started, from),
+		WeekStart:      from,
+		WeekEnd:        to,
+		Sessions:       len(sessions),
+	}
+
+	// лишние подходы сверх плана не компенсируют пропущенные упражнения
+	for _, day := range prescribeWeek(plan, adherence.PlanWeek).Days {
+		for _, exercise := range day.Exercises {
+			adherence.PlannedSets += exercise.Sets
+			adherence.CompletedSets += min(done[exercise.ExercisePlanItemID], exercise.Sets)
+		}
+	}
+
+	if adherence.PlannedSets > 0 {
+		adherence.Percent = math.Round(float64(adherence.CompletedSets)/float64(adherence.PlannedSets)*1000) / 10
+	}
+
+	return adherence, nil
+}
+
+// planStart возвращает время первой тренировки пользователя по плану; nil, если он ещё не начинал
+func (s *workoutService) planStart(userID, planID uint) (*time.Time, error) {
+	sessions, err := s.repo.ListSessions(userID, &planID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+
+	// тренировки отсортированы от новых к старым
+	return &sessions[len(sessions)-1].StartedAt, nil
+}
+
+// planWeek переводит календарную неделю from в неделю плана: первой считается неделя
+// первой тренировки по плану. До начала плана это первая неделя, после окончания — последняя
+func planWeek(plan *models.ExercisePlan, started *time.Time, from time.Time) int {
+	if started == nil {
+		return 1
+	}
+
+	days := from.Sub(weekStart(*started)).Hours() / 24
+	week := int(math.Round(days/7)) + 1
+	return min(max(week, 1), max(plan.DurationWeeks, 1))
+}
+
+// oneRepMax оценивает разовый максимум по формуле Эпли
+func oneRepMax(weight float64, reps int) float64 {
+	if weight <= 0 || reps <= 0 {
+		return 0
+	}
+	if reps == 1 {
+		return weight
+	}
+
+	return math.Round(weight*(1+float64(reps)/30)*10) / 10
+}
+
+// weekStart возвращает начало понедельника недели, в которую попадает t
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	day := t.AddDate(0, 0, -offset)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, t.Location())
+}
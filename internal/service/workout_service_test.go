@@ -0,0 +1,34 @@
+package service
+
+import (
+	"healthy_body/internal/models"
+	"testing"
+	"time"
+)
+
+func TestPlanWeek(t *testing.T) {
+	plan := &models.ExercisePlan{DurationWeeks: 4}
+	// среда; неделя плана начинается с понедельника 2 июня
+	started := time.Date(2025, time.June, 4, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		started *time.Time
+		from    time.Time
+		want    int
+	}{
+		{name: "not started", from: time.Date(2025, time.June, 9, 0, 0, 0, 0, time.UTC), want: 1},
+		{name: "first week", started: &started, from: time.Date(2025, time.June, 2, 0, 0, 0, 0, time.UTC), want: 1},
+		{name: "third week", started: &started, from: time.Date(2025, time.June, 16, 0, 0, 0, 0, time.UTC), want: 3},
+		{name: "before start", started: &started, from: time.Date(2025, time.May, 26, 0, 0, 0, 0, time.UTC), want: 1},
+		{name: "after plan end", started: &started, from: time.Date(2025, time.July, 14, 0, 0, 0, 0, time.UTC), want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planWeek(plan, tt.started, tt.from); got != tt.want {
+				t.Errorf("planWeek() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthHandler struct {
+	auth service.AuthService
+	log  *slog.Logger
+}
+
+func NewAuthHandler(auth service.AuthService, log *slog.Logger) *AuthHandler {
+	return &AuthHandler{auth: auth, log: log}
+}
+
+func (h *AuthHandler) RegisterRoutes(r *gin.Engine) {
+	authGroup := r.Group("/auth")
+	{
+		authGroup.POST("/register", h.Register)
+		authGroup.POST("/login", h.Login)
+		authGroup.POST("/refresh", h.Refresh)
+		authGroup.POST("/logout", h.Logout)
+	}
+}
+
+// Register godoc
+// @Summary Регистрация пользователя
+// @Description Создает пользователя с паролем и возвращает пару токенов
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param user body models.CreateUserRequest true "Данные пользователя"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req models.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	user, tokens, err := h.auth.Register(req)
+	if err != nil {
+		h.log.Warn("Ошибка при регистрации", "error", err.Error())
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrEmailTaken) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error(), "message": "ошибка при регистрации"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"user": user, "tokens": tokens})
+}
+
+// Login godoc
+// @Summary Вход по email и паролю
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.LoginRequest true "Email и пароль"
+// @Success 200 {object} models.TokenPair
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	tokens, err := h.auth.Login(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Refresh godoc
+// @Summary Обновить пару токенов
+// @Description Обменивает refresh-токен на новую пару, старый токен отзывается
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshRequest true "Refresh-токен"
+// @Success 200 {object} models.TokenPair
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		h.log.Warn("Не передан refresh-токен")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "refresh_token обязателен"})
+		return
+	}
+
+	tokens, err := h.auth.Refresh(req.RefreshToken)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidToken) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout godoc
+// @Summary Выход
+// @Description Отзывает переданный refresh-токен
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshRequest true "Refresh-токен"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		h.log.Warn("Не передан refresh-токен")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "refresh_token обязателен"})
+		return
+	}
+
+	if err := h.auth.Logout(req.RefreshToken); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidToken) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "выход выполнен"})
+}
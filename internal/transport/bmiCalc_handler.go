@@ -1,8 +1,8 @@
 package transport
 
 import (
+	"healthy_body/internal/calculator"
 	"log/slog"
-	"math"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -49,7 +49,7 @@ func (h *BmiHandler) BmiInput(r *gin.Context) {
 		return
 	}
 
-	category, result := BmiCalc(input.Weigth, input.Heigth)
+	category, result := calculator.BmiCalc(input.Weigth, input.Heigth)
 
 	h.log.Info("operation succes")
 
@@ -58,17 +58,3 @@ func (h *BmiHandler) BmiInput(r *gin.Context) {
 		"result":   result,
 	})
 }
-
-func BmiCalc(weight, height float64) (string, float64) {
-	hMeters := height / 100
-	bmi := weight / (hMeters * hMeters)
-	rounded := math.Round(bmi*100) / 100
-	switch {
-	case rounded < 18.5:
-		return "Недостаточный вес", rounded
-	case rounded >= 18.5 && rounded <= 25:
-		return "Нормальный вес", rounded
-	default:
-		return "Избыточный вес или ожирение", rounded
-	}
-}
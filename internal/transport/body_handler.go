@@ -0,0 +1,243 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BodyHandler struct {
+	body service.BodyMetricsService
+	auth *AuthMiddleware
+	log  *slog.Logger
+}
+
+func NewBodyHandler(body service.BodyMetricsService, auth *AuthMiddleware, log *slog.Logger) *BodyHandler {
+	return &BodyHandler{body: body, auth: auth, log: log}
+}
+
+func (h *BodyHandler) RegisterRoutes(r *gin.Engine) {
+	body := r.Group("/body", h.auth.RequireAuth())
+	{
+		body.POST("/measurements", h.AddMeasurement)
+		body.GET("/measurements", h.ListMeasurements)
+		body.DELETE("/measurements/:id", h.DeleteMeasurement)
+		body.GET("/trend", h.Trend)
+		body.PUT("/goal", h.SetGoal)
+		body.GET("/goal", h.GetGoal)
+		body.DELETE("/goal", h.DeleteGoal)
+	}
+}
+
+// AddMeasurement godoc
+// @Summary Добавить замер тела
+// @Description Сохраняет вес, рост, обхват талии и процент жира; BMI рассчитывается автоматически. Если рост не указан, берётся из последнего замера.
+// @Tags Body
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param measurement body models.CreateBodyMeasurementRequest true "Замер"
+// @Success 201 {object} models.BodyMeasurement
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /body/measurements [post]
+func (h *BodyHandler) AddMeasurement(c *gin.Context) {
+	var req models.CreateBodyMeasurementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	measurement, err := h.body.AddMeasurement(currentUserID(c), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, measurement)
+}
+
+// ListMeasurements godoc
+// @Summary История замеров
+// @Tags Body
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "С даты, 2006-01-02"
+// @Param to query string false "По дату включительно, 2006-01-02"
+// @Success 200 {array} models.BodyMeasurement
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /body/measurements [get]
+func (h *BodyHandler) ListMeasurements(c *gin.Context) {
+	from, ok := h.dateQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := h.dateQuery(c, "to")
+	if !ok {
+		return
+	}
+	if to != nil {
+		next := to.AddDate(0, 0, 1)
+		to = &next
+	}
+
+	measurements, err := h.body.ListMeasurements(currentUserID(c), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, measurements)
+}
+
+// DeleteMeasurement godoc
+// @Summary Удалить замер
+// @Tags Body
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID замера"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /body/measurements/{id} [delete]
+func (h *BodyHandler) DeleteMeasurement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return
+	}
+
+	if err := h.body.DeleteMeasurement(currentUserID(c), uint(id)); err != nil {
+		h.bodyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "замер удалён"})
+}
+
+// Trend godoc
+// @Summary Динамика веса
+// @Description Скользящее среднее веса, изменение за неделю по последним четырём неделям и прогресс к целевому весу
+// @Tags Body
+// @Produce json
+// @Security BearerAuth
+// @Param window query int false "Окно скользящего среднего в днях, по умолчанию 7"
+// @Success 200 {object} models.BodyTrend
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /body/trend [get]
+func (h *BodyHandler) Trend(c *gin.Context) {
+	window := service.DefaultTrendWindowDays
+	if value := c.Query("window"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "некорректное окно усреднения"})
+			return
+		}
+		window = parsed
+	}
+
+	trend, err := h.body.Trend(currentUserID(c), window)
+	if err != nil {
+		h.bodyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, trend)
+}
+
+// SetGoal godoc
+// @Summary Задать целевой вес
+// @Description Начальным весом цели становится последний замер. В ответе — расстояние до цели и прогноз даты её достижения при текущем темпе.
+// @Tags Body
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param goal body models.SetBodyGoalRequest true "Целевой вес"
+// @Success 200 {object} models.BodyGoalProgress
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /body/goal [put]
+func (h *BodyHandler) SetGoal(c *gin.Context) {
+	var req models.SetBodyGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	progress, err := h.body.SetGoal(currentUserID(c), req)
+	if err != nil {
+		h.bodyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// GetGoal godoc
+// @Summary Прогресс к целевому весу
+// @Tags Body
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.BodyGoalProgress
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /body/goal [get]
+func (h *BodyHandler) GetGoal(c *gin.Context) {
+	progress, err := h.body.GetGoal(currentUserID(c))
+	if err != nil {
+		h.bodyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// DeleteGoal godoc
+// @Summary Удалить целевой вес
+// @Tags Body
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /body/goal [delete]
+func (h *BodyHandler) DeleteGoal(c *gin.Context) {
+	if err := h.body.DeleteGoal(currentUserID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "цель удалена"})
+}
+
+func (h *BodyHandler) dateQuery(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+
+	date, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректная дата " + name})
+		return nil, false
+	}
+
+	return &date, true
+}
+
+func (h *BodyHandler) bodyError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, service.ErrMeasurementNotFound) || errors.Is(err, service.ErrBodyGoalNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,220 @@
+package transport
+
+import (
+	"healthy_body/internal/calculator"
+	"healthy_body/internal/models"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalculatorHandler struct {
+	log *slog.Logger
+}
+
+func NewCalculatorHandler(log *slog.Logger) *CalculatorHandler {
+	return &CalculatorHandler{log: log}
+}
+
+func (h *CalculatorHandler) RegisterRoutes(r *gin.Engine) {
+	calc := r.Group("/calc")
+	{
+		calc.POST("/bmi", h.Bmi)
+		calc.GET("/bmi/classes", h.BmiClasses)
+		calc.POST("/bmr", h.Bmr)
+		calc.POST("/tdee", h.Tdee)
+		calc.POST("/ideal-weight", h.IdealWeight)
+		calc.POST("/macros", h.Macros)
+	}
+}
+
+// Bmi godoc
+// @Summary BMI с классом ВОЗ
+// @Description Нужны weight_kg и height_cm
+// @Tags Calculators
+// @Accept json
+// @Produce json
+// @Param profile body models.HealthProfileRequest true "Вес и рост"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /calc/bmi [post]
+func (h *CalculatorHandler) Bmi(c *gin.Context) {
+	req, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	if req.WeightKg <= 0 || req.HeightCm <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "укажите вес и рост"})
+		return
+	}
+
+	bmi := calculator.Bmi(req.WeightKg, req.HeightCm)
+	c.JSON(http.StatusOK, gin.H{
+		"bmi":   bmi,
+		"class": calculator.ClassifyBmi(bmi),
+	})
+}
+
+// BmiClasses godoc
+// @Summary Классы BMI по ВОЗ
+// @Tags Calculators
+// @Produce json
+// @Success 200 {array} calculator.BmiClass
+// @Router /calc/bmi/classes [get]
+func (h *CalculatorHandler) BmiClasses(c *gin.Context) {
+	c.JSON(http.StatusOK, calculator.BmiClasses())
+}
+
+// Bmr godoc
+// @Summary Базовый обмен веществ
+// @Description Формулы Миффлина — Сан Жеора (по умолчанию) и Харриса — Бенедикта. Нужны sex, age, weight_kg и height_cm.
+// @Tags Calculators
+// @Accept json
+// @Produce json
+// @Param profile body models.HealthProfileRequest true "Параметры"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /calc/bmr [post]
+func (h *CalculatorHandler) Bmr(c *gin.Context) {
+	req, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	bmr, err := calculator.BMR(healthProfile(req), calculator.BmrFormula(req.Formula))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"formula": bmrFormula(req),
+		"bmr":     bmr,
+	})
+}
+
+// Tdee godoc
+// @Summary Суточный расход энергии
+// @Description BMR, умноженный на коэффициент активности. Нужны sex, age, weight_kg, height_cm и activity.
+// @Tags Calculators
+// @Accept json
+// @Produce json
+// @Param profile body models.HealthProfileRequest true "Параметры"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /calc/tdee [post]
+func (h *CalculatorHandler) Tdee(c *gin.Context) {
+	req, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	factor, err := calculator.ActivityFactor(calculator.ActivityLevel(req.Activity))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bmr, err := calculator.BMR(healthProfile(req), calculator.BmrFormula(req.Formula))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"formula":         bmrFormula(req),
+		"bmr":             bmr,
+		"activity_factor": factor,
+		"tdee":            calculator.Round(bmr * factor),
+	})
+}
+
+// IdealWeight godoc
+// @Summary Идеальный вес
+// @Description Диапазон веса с нормальным BMI и вес по формуле Девина. Нужны sex и height_cm.
+// @Tags Calculators
+// @Accept json
+// @Produce json
+// @Param profile body models.HealthProfileRequest true "Пол и рост"
+// @Success 200 {object} calculator.IdealWeight
+// @Failure 400 {object} map[string]string
+// @Router /calc/ideal-weight [post]
+func (h *CalculatorHandler) IdealWeight(c *gin.Context) {
+	req, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	ideal, err := calculator.IdealWeightRange(calculator.Sex(req.Sex), req.HeightCm)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ideal)
+}
+
+// Macros godoc
+// @Summary Цели по КБЖУ
+// @Description Калории, белки, жиры и углеводы для цели cut, maintain или bulk. Нужны все поля, кроме formula.
+// @Tags Calculators
+// @Accept json
+// @Produce json
+// @Param profile body models.HealthProfileRequest true "Параметры и цель"
+// @Success 200 {object} calculator.MacroTargets
+// @Failure 400 {object} map[string]string
+// @Router /calc/macros [post]
+func (h *CalculatorHandler) Macros(c *gin.Context) {
+	req, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	targets, err := macroTargets(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, targets)
+}
+
+func (h *CalculatorHandler) bind(c *gin.Context) (models.HealthProfileRequest, bool) {
+	var req models.HealthProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return req, false
+	}
+
+	return req, true
+}
+
+func healthProfile(req models.HealthProfileRequest) calculator.Profile {
+	return calculator.Profile{
+		Sex:      calculator.Sex(req.Sex),
+		AgeYears: req.Age,
+		WeightKg: req.WeightKg,
+		HeightCm: req.HeightCm,
+	}
+}
+
+func bmrFormula(req models.HealthProfileRequest) calculator.BmrFormula {
+	if req.Formula == "" {
+		return calculator.FormulaMifflinStJeor
+	}
+
+	return calculator.BmrFormula(req.Formula)
+}
+
+// macroTargets считает цели по КБЖУ; используется и калькулятором, и сравнением планов питания
+func macroTargets(req models.HealthProfileRequest) (*calculator.MacroTargets, error) {
+	tdee, err := calculator.TDEE(healthProfile(req), calculator.BmrFormula(req.Formula), calculator.ActivityLevel(req.Activity))
+	if err != nil {
+		return nil, err
+	}
+
+	return calculator.Macros(tdee, req.WeightKg, calculator.Goal(req.Goal))
+}
@@ -18,12 +18,20 @@ type CategoryResponse struct {
 
 type CategoryHandler struct {
 	category service.CategoryServices
+	versions service.PlanVersionService
+	auth     *AuthMiddleware
+	gate     *ContentGate
+	guard    *PlanGuard
 	log      *slog.Logger
 }
 
-func NewCategoryHandler(category service.CategoryServices, log *slog.Logger) *CategoryHandler {
+func NewCategoryHandler(category service.CategoryServices, versions service.PlanVersionService, auth *AuthMiddleware, gate *ContentGate, guard *PlanGuard, log *slog.Logger) *CategoryHandler {
 	return &CategoryHandler{
 		category: category,
+		versions: versions,
+		auth:     auth,
+		gate:     gate,
+		guard:    guard,
 		log:      log,
 	}
 }
@@ -31,23 +39,25 @@ func NewCategoryHandler(category service.CategoryServices, log *slog.Logger) *Ca
 func (h *CategoryHandler) RegisterRoutes(r *gin.Engine) {
 	group := r.Group("/category")
 	{
-		group.POST("/", h.CreateCategory)
-		group.GET("/", h.GetList)
-		group.GET("/:id", h.GetByID)
-		group.PATCH("/:id", h.UpdateCategory)
-		group.DELETE("/:id", h.DeleteCategory)
+		group.POST("/", h.auth.Require(service.PermManageCatalog, service.PermEditPricing), h.CreateCategory)
+		group.GET("/", h.auth.OptionalAuth(), h.GetList)
+		group.GET("/:id", h.auth.OptionalAuth(), h.GetByID)
+		group.PATCH("/:id", h.auth.Require(service.PermEditCatalog), h.UpdateCategory)
+		group.DELETE("/:id", h.auth.Require(service.PermManageCatalog), h.DeleteCategory)
 	}
 }
 
 // CreateCategory godoc
 // @Summary Создать категорию
-// @Description Создает новую категорию
+// @Description Создает новую категорию. Доступно администраторам.
 // @Tags Categories
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param category body models.CreateCategoryRequest true "Данные категории"
 // @Success 201 {object} CategoryResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /category/ [post]
 func (h *CategoryHandler) CreateCategory(c *gin.Context) {
@@ -70,9 +80,10 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 
 // GetByID godoc
 // @Summary Получить категорию по ID
-// @Description Возвращает категорию по ID
+// @Description Возвращает категорию с опубликованными планами: купившим — в версии их покупки, остальным — в последней. Без покупки или активной подписки планы отдаются превью (CategoryPreview)
 // @Tags Categories
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID категории"
 // @Success 200 {object} CategoryResponse
 // @Failure 400 {object} map[string]string
@@ -93,14 +104,26 @@ func (h *CategoryHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	if err := h.versions.ResolveCategory(currentUser(c), cat); err != nil {
+		h.log.Error("failed to resolve category plan versions", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get category"})
+		return
+	}
+
+	if !h.gate.CanViewCategory(c, cat.ID) {
+		c.JSON(http.StatusOK, categoryPreview(cat))
+		return
+	}
+
 	c.JSON(http.StatusOK, cat)
 }
 
 // GetList godoc
 // @Summary Получить список категорий
-// @Description Возвращает все категории
+// @Description Возвращает все категории с опубликованными планами; категории без покупки или активной подписки отдаются превью (CategoryPreview)
 // @Tags Categories
 // @Produce json
+// @Security BearerAuth
 // @Success 200 {array} CategoryResponse
 // @Failure 500 {object} map[string]string
 // @Router /category/ [get]
@@ -112,19 +135,35 @@ func (h *CategoryHandler) GetList(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, list)
+	result := make([]interface{}, 0, len(list))
+	for i := range list {
+		if err := h.versions.ResolveCategory(currentUser(c), &list[i]); err != nil {
+			h.log.Error("failed to resolve category plan versions", "id", list[i].ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get categories"})
+			return
+		}
+		if h.gate.CanViewCategory(c, list[i].ID) {
+			result = append(result, list[i])
+		} else {
+			result = append(result, categoryPreview(&list[i]))
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
 }
 
 // UpdateCategory godoc
 // @Summary Обновить категорию
-// @Description Обновляет категорию по ID
+// @Description Обновляет категорию по ID. Тренер может изменять только свои категории; цену и тренера-владельца меняют только администраторы.
 // @Tags Categories
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID категории"
 // @Param category body models.UpdateCategoryRequest true "Данные обновления"
 // @Success 200 {object} CategoryResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /category/{id} [patch]
 func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
@@ -142,6 +181,20 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 		return
 	}
 
+	if input.Price != nil && !h.auth.Can(c, service.PermEditPricing) {
+		h.auth.forbid(c, service.PermEditPricing)
+		return
+	}
+
+	if input.TrainerID != nil && !h.auth.Can(c, service.PermManageCatalog) {
+		h.auth.forbid(c, service.PermManageCatalog)
+		return
+	}
+
+	if !h.guard.Category(c, uint(id)) {
+		return
+	}
+
 	cat, err := h.category.UpdateCategory(uint(id), input)
 	if err != nil {
 		h.log.Error("failed to update category", "error", err)
@@ -154,12 +207,14 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 
 // DeleteCategory godoc
 // @Summary Удалить категорию
-// @Description Удаляет категорию по ID
+// @Description Удаляет категорию по ID. Доступно администраторам.
 // @Tags Categories
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID категории"
 // @Success 200 {object} map[string]bool
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /category/{id} [delete]
 func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
@@ -0,0 +1,430 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CoachingHandler struct {
+	coaching service.CoachingService
+	auth     *AuthMiddleware
+	log      *slog.Logger
+}
+
+func NewCoachingHandler(coaching service.CoachingService, auth *AuthMiddleware, log *slog.Logger) *CoachingHandler {
+	return &CoachingHandler{coaching: coaching, auth: auth, log: log}
+}
+
+func (h *CoachingHandler) RegisterRoutes(r *gin.Engine) {
+	signedIn := h.auth.RequireAuth()
+	author := h.auth.Require(service.PermAuthorPlans)
+
+	trainers := r.Group("/trainers", signedIn)
+	{
+		trainers.POST("/:id/requests", h.Request)
+		trainers.GET("/:id/clients", h.Clients)
+	}
+
+	coaching := r.Group("/coaching", signedIn)
+	{
+		coaching.GET("/trainers", h.Trainers)
+		coaching.POST("/:id/accept", h.Accept)
+		coaching.POST("/:id/decline", h.Decline)
+		coaching.POST("/:id/end", h.End)
+		coaching.POST("/:id/exercisePlans", author, h.AssignExercisePlan)
+		coaching.POST("/:id/mealPlans", author, h.AssignMealPlan)
+		coaching.GET("/:id/plans", h.AssignedPlans)
+		coaching.GET("/:id/workouts", h.ClientWorkouts)
+	}
+
+	workouts := r.Group("/workouts", signedIn)
+	{
+		workouts.POST("/:id/comments", h.Comment)
+		workouts.GET("/:id/comments", h.Comments)
+	}
+}
+
+// Request godoc
+// @Summary Заявка тренеру
+// @Description Текущий пользователь просит тренера взять его в клиенты. Пока тренер не ответил, заявку можно отозвать через /coaching/{id}/end
+// @Tags Coaching
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя-тренера"
+// @Param request body models.CoachingRequest false "Сообщение тренеру"
+// @Success 201 {object} models.TrainerClient
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /trainers/{id}/requests [post]
+func (h *CoachingHandler) Request(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CoachingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.log.Warn("Введены неверные данные", "err", err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+			return
+		}
+	}
+
+	link, err := h.coaching.Request(currentUserID(c), id, req)
+	if err != nil {
+		h.coachingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// Clients godoc
+// @Summary Клиенты тренера
+// @Description Заявки и клиенты тренера. Свой список видит сам тренер, чужой — администратор
+// @Tags Coaching
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя-тренера"
+// @Param status query string false "pending, active, declined или ended"
+// @Success 200 {array} models.TrainerClient
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /trainers/{id}/clients [get]
+func (h *CoachingHandler) Clients(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	if id != currentUserID(c) && !h.auth.Can(c, service.PermManageUsers) {
+		h.auth.forbid(c, service.PermManageUsers)
+		return
+	}
+
+	links, err := h.coaching.Clients(id, c.Query("status"))
+	if err != nil {
+		h.coachingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// Trainers godoc
+// @Summary Мои тренеры
+// @Description Заявки и связи текущего пользователя с тренерами
+// @Tags Coaching
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.TrainerClient
+// @Failure 401 {object} map[string]string
+// @Router /coaching/trainers [get]
+func (h *CoachingHandler) Trainers(c *gin.Context) {
+	links, err := h.coaching.Trainers(currentUserID(c))
+	if err != nil {
+		h.coachingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// Accept godoc
+// @Summary Принять заявку клиента
+// @Tags Coaching
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID связи"
+// @Success 200 {object} models.TrainerClient
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /coaching/{id}/accept [post]
+func (h *CoachingHandler) Accept(c *gin.Context) {
+	h.respond(c, h.coaching.Accept)
+}
+
+// Decline godoc
+// @Summary Отклонить заявку клиента
+// @Tags Coaching
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID связи"
+// @Success 200 {object} models.TrainerClient
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /coaching/{id}/decline [post]
+func (h *CoachingHandler) Decline(c *gin.Context) {
+	h.respond(c, h.coaching.Decline)
+}
+
+// End godoc
+// @Summary Завершить связь с тренером
+// @Description Завершить связь или отозвать заявку может и тренер, и клиент. Личные планы остаются у клиента, но тренер больше не видит его тренировки
+// @Tags Coaching
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID связи"
+// @Success 200 {object} models.TrainerClient
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /coaching/{id}/end [post]
+func (h *CoachingHandler) End(c *gin.Context) {
+	h.respond(c, h.coaching.End)
+}
+
+func (h *CoachingHandler) respond(c *gin.Context, action func(userID, linkID uint) (*models.TrainerClient, error)) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	link, err := action(currentUserID(c), id)
+	if err != nil {
+		h.coachingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+// AssignExercisePlan godoc
+// @Summary Личный тренировочный план клиенту
+// @Description Копирует план из своей категории в последней опубликованной версии или свой личный план вместе с упражнениями в новый план, который видят только клиент и тренер. Копию можно менять через /plan/{id} и /plan/planItem, исходный план при этом не меняется. Неопубликованный план скопировать нельзя (409)
+// @Tags Coaching
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID связи"
+// @Param plan body models.AssignPlanRequest true "Исходный план"
+// @Success 201 {object} ExercisePlanResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /coaching/{id}/exercisePlans [post]
+func (h *CoachingHandler) AssignExercisePlan(c *gin.Context) {
+	id, req, ok := h.assignRequest(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.coaching.AssignExercisePlan(currentUserID(c), id, req)
+	if err != nil {
+		h.coachingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// AssignMealPlan godoc
+// @Summary Личный план питания клиенту
+// @Description Копирует план питания из своей категории в последней опубликованной версии или свой личный план вместе с блюдами в новый план, который видят только клиент и тренер. Неопубликованный план скопировать нельзя (409)
+// @Tags Coaching
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID связи"
+// @Param plan body models.AssignPlanRequest true "Исходный план"
+// @Success 201 {object} MealPlanResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /coaching/{id}/mealPlans [post]
+func (h *CoachingHandler) AssignMealPlan(c *gin.Context) {
+	id, req, ok := h.assignRequest(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.coaching.AssignMealPlan(currentUserID(c), id, req)
+	if err != nil {
+		h.coachingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+func (h *CoachingHandler) assignRequest(c *gin.Context) (uint, models.AssignPlanRequest, bool) {
+	var req models.AssignPlanRequest
+
+	id, ok := h.pathID(c)
+	if !ok {
+		return 0, req, false
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return 0, req, false
+	}
+
+	return id, req, true
+}
+
+// AssignedPlans godoc
+// @Summary Личные планы клиента
+// @Description Тренировочные планы и планы питания, которые тренер составил клиенту в рамках связи. Доступно тренеру и клиенту
+// @Tags Coaching
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID связи"
+// @Success 200 {object} models.AssignedPlans
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /coaching/{id}/plans [get]
+func (h *CoachingHandler) AssignedPlans(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	plans, err := h.coaching.AssignedPlans(currentUserID(c), id)
+	if err != nil {
+		h.coachingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// ClientWorkouts godoc
+// @Summary Тренировки клиента
+// @Description Журнал тренировок клиента для его тренера, пока связь активна
+// @Tags Coaching
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID связи"
+// @Success 200 {array} models.WorkoutSession
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /coaching/{id}/workouts [get]
+func (h *CoachingHandler) ClientWorkouts(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.coaching.ClientWorkouts(currentUserID(c), id)
+	if err != nil {
+		h.coachingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// Comment godoc
+// @Summary Комментарий к тренировке
+// @Description Комментировать тренировку может её владелец и тренер владельца, пока связь активна
+// @Tags Coaching
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID тренировки"
+// @Param comment body models.CreateWorkoutCommentRequest true "Комментарий"
+// @Success 201 {object} models.WorkoutComment
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /workouts/{id}/comments [post]
+func (h *CoachingHandler) Comment(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateWorkoutCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	comment, err := h.coaching.Comment(currentUserID(c), id, req)
+	if err != nil {
+		h.coachingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// Comments godoc
+// @Summary Комментарии к тренировке
+// @Tags Coaching
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID тренировки"
+// @Success 200 {array} models.WorkoutComment
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /workouts/{id}/comments [get]
+func (h *CoachingHandler) Comments(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	comments, err := h.coaching.Comments(currentUserID(c), id)
+	if err != nil {
+		h.coachingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+func (h *CoachingHandler) pathID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *CoachingHandler) coachingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrCoachingNotFound),
+		errors.Is(err, service.ErrTrainerNotFound),
+		errors.Is(err, service.ErrPlanNotFound),
+		errors.Is(err, service.ErrWorkoutNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCoachingExists),
+		errors.Is(err, service.ErrCoachingNotActive),
+		errors.Is(err, service.ErrPlanNotPublished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWorkoutCommentDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
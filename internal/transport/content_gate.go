@@ -0,0 +1,173 @@
+package transport
+
+import (
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const entitlementKey = "entitlement"
+
+// ContentGate решает, отдавать ли пользователю полный контент плана или только превью
+type ContentGate struct {
+	entitlements service.EntitlementService
+	log          *slog.Logger
+}
+
+func NewContentGate(entitlements service.EntitlementService, log *slog.Logger) *ContentGate {
+	return &ContentGate{entitlements: entitlements, log: log}
+}
+
+// resolve загружает доступные пользователю категории один раз за запрос.
+// При ошибке считаем, что доступа нет, чтобы не раскрыть платный контент
+func (g *ContentGate) resolve(c *gin.Context) *service.Entitlement {
+	if value, ok := c.Get(entitlementKey); ok {
+		return value.(*service.Entitlement)
+	}
+
+	entitlement, err := g.entitlements.For(currentUser(c))
+	if err != nil {
+		g.log.Error("Ошибка при проверке доступа к контенту",
+			"user_id", currentUserID(c),
+			"error", err.Error())
+		entitlement, _ = g.entitlements.For(nil)
+	}
+
+	c.Set(entitlementKey, entitlement)
+	return entitlement
+}
+
+func (g *ContentGate) CanViewCategory(c *gin.Context, categoryID uint) bool {
+	return g.resolve(c).CanViewCategory(categoryID)
+}
+
+func (g *ContentGate) CanViewExercisePlan(c *gin.Context, plan *models.ExercisePlan) bool {
+	return g.resolve(c).CanViewExercisePlan(plan)
+}
+
+func (g *ContentGate) CanViewMealPlan(c *gin.Context, plan *models.MealPlan) bool {
+	return g.resolve(c).CanViewMealPlan(plan)
+}
+
+// Listed решает, попадает ли план в общий список: каталожные планы видны всем,
+// личные — только клиенту, для которого они составлены, и его тренеру
+func (g *ContentGate) Listed(c *gin.Context, clientID, trainerID *uint) bool {
+	return clientID == nil || g.resolve(c).Assigned(clientID, trainerID)
+}
+
+func (g *ContentGate) deny(c *gin.Context, categoryID uint) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":       "purchase_required",
+		"message":     "контент доступен после покупки категории или оформления подписки",
+		"category_id": categoryID,
+	})
+}
+
+// hidePrivate отвечает на запрос чужого личного плана так же, как на несуществующий план
+func (g *ContentGate) hidePrivate(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{"error": "план не найден"})
+}
+
+func (g *ContentGate) denyExercisePlan(c *gin.Context, plan *models.ExercisePlan) {
+	if plan.ClientID != nil {
+		g.hidePrivate(c)
+		return
+	}
+	g.deny(c, plan.CategoriesID)
+}
+
+func (g *ContentGate) denyMealPlan(c *gin.Context, plan *models.MealPlan) {
+	if plan.ClientID != nil {
+		g.hidePrivate(c)
+		return
+	}
+	g.deny(c, *plan.CategoriesID)
+}
+
+// ExercisePlanPreview — то, что видно о тренировочном плане без покупки
+type ExercisePlanPreview struct {
+	ID            uint   `json:"id"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	DurationWeeks int    `json:"duration_weeks"`
+	CategoriesID  uint   `json:"categories_id"`
+	ExerciseCount int    `json:"exercise_count"`
+	Locked        bool   `json:"locked"`
+}
+
+// MealPlanPreview — то, что видно о плане питания без покупки
+type MealPlanPreview struct {
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	TotalDays    int    `json:"total_days"`
+	CategoriesID *uint  `json:"categories_id"`
+	MealCount    int    `json:"meal_count"`
+	Locked       bool   `json:"locked"`
+	// теги и аллергены видны до покупки, чтобы план можно было подобрать под диету
+	DietTags  []string `json:"diet_tags,omitempty"`
+	Allergens []string `json:"allergens,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// CategoryPreview — категория с превью планов вместо их содержимого
+type CategoryPreview struct {
+	ID            uint                  `json:"id"`
+	Name          string                `json:"name"`
+	Description   string                `json:"description"`
+	Price         int                   `json:"price"`
+	ExercisePlans []ExercisePlanPreview `json:"exercise_plans"`
+	MealPlans     []MealPlanPreview     `json:"meal_plans"`
+	Locked        bool                  `json:"locked"`
+}
+
+func exercisePlanPreview(plan *models.ExercisePlan) ExercisePlanPreview {
+	return ExercisePlanPreview{
+		ID:            plan.ID,
+		Name:          plan.Name,
+		Description:   plan.Description,
+		DurationWeeks: plan.DurationWeeks,
+		CategoriesID:  plan.CategoriesID,
+		ExerciseCount: len(plan.Exercises),
+		Locked:        true,
+	}
+}
+
+func mealPlanPreview(plan *models.MealPlan) MealPlanPreview {
+	return MealPlanPreview{
+		ID:           plan.ID,
+		Name:         plan.Name,
+		Description:  plan.Description,
+		TotalDays:    plan.TotalDays,
+		CategoriesID: plan.CategoriesID,
+		MealCount:    len(plan.Meals),
+		Locked:       true,
+		DietTags:     plan.DietTags,
+		Allergens:    plan.Allergens,
+		Warnings:     plan.Warnings,
+	}
+}
+
+func categoryPreview(category *models.Categories) CategoryPreview {
+	preview := CategoryPreview{
+		ID:            category.ID,
+		Name:          category.Name,
+		Description:   category.Description,
+		Price:         category.Price,
+		ExercisePlans: make([]ExercisePlanPreview, 0, len(category.ExercisePlans)),
+		MealPlans:     make([]MealPlanPreview, 0, len(category.MealPlans)),
+		Locked:        true,
+	}
+
+	for i := range category.ExercisePlans {
+		preview.ExercisePlans = append(preview.ExercisePlans, exercisePlanPreview(&category.ExercisePlans[i]))
+	}
+	for i := range category.MealPlans {
+		preview.MealPlans = append(preview.MealPlans, mealPlanPreview(&category.MealPlans[i]))
+	}
+
+	return preview
+}
@@ -1,10 +1,12 @@
 package transport
 
 import (
+	"errors"
 	"healthy_body/internal/models"
 	"healthy_body/internal/service"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -17,47 +19,66 @@ type ExercisePlanResponse struct {
 	Description   string `json:"description"`
 	DurationWeeks int    `json:"duration_weeks"`
 	CategoriesID  uint   `json:"categories_id"`
+	ClientID      *uint  `json:"client_id,omitempty"`
+	TrainerID     *uint  `json:"trainer_id,omitempty"`
 }
 
 type ExercisePlanHandler struct {
-	exer service.ExercisePlanServices
-	log  *slog.Logger
+	exer     service.ExercisePlanServices
+	versions service.PlanVersionService
+	auth     *AuthMiddleware
+	gate     *ContentGate
+	guard    *PlanGuard
+	log      *slog.Logger
 }
 
-func NewExercisePlanHandler(exer service.ExercisePlanServices, log *slog.Logger) *ExercisePlanHandler {
+func NewExercisePlanHandler(exer service.ExercisePlanServices, versions service.PlanVersionService, auth *AuthMiddleware, gate *ContentGate, guard *PlanGuard, log *slog.Logger) *ExercisePlanHandler {
 	return &ExercisePlanHandler{
-		exer: exer,
-		log:  log,
+		exer:     exer,
+		versions: versions,
+		auth:     auth,
+		gate:     gate,
+		guard:    guard,
+		log:      log,
 	}
 }
 
 func (h *ExercisePlanHandler) RegisterRoutes(r *gin.Engine) {
+	author := h.auth.Require(service.PermAuthorPlans)
+	viewer := h.auth.OptionalAuth()
+
 	planGroup := r.Group("/plan")
 	{
-		planGroup.POST("/", h.CreatePlan)
-		planGroup.GET("/:id", h.GetByID)
-		planGroup.GET("/", h.GetAllPlan)
-		planGroup.PATCH("/:id", h.UpdatePlan)
-		planGroup.DELETE("/:id", h.DeletePlan)
-
-		planGroup.POST("/planItem", h.CreatePlanItem)
-		planGroup.GET("/planItem/:id", h.GetPlanItemByID)
-		planGroup.GET("/planItem/", h.GetListPlanItem)
-		planGroup.PATCH("/planItem/:id", h.UpdatePlanItem)
-		planGroup.DELETE("/planItem/:id", h.DeletePlanItem)
+		planGroup.POST("/", author, h.CreatePlan)
+		planGroup.GET("/:id", viewer, h.GetByID)
+		planGroup.GET("/:id/schedule", viewer, h.Schedule)
+		planGroup.GET("/:id/weeks/:week", viewer, h.WeekPrescription)
+		planGroup.GET("/", viewer, h.GetAllPlan)
+		planGroup.PATCH("/:id", author, h.UpdatePlan)
+		planGroup.DELETE("/:id", author, h.DeletePlan)
+
+		planGroup.POST("/planItem", author, h.CreatePlanItem)
+		planGroup.GET("/planItem/:id", viewer, h.GetPlanItemByID)
+		planGroup.GET("/planItem/", viewer, h.GetListPlanItem)
+		planGroup.PATCH("/planItem/:id", author, h.UpdatePlanItem)
+		planGroup.DELETE("/planItem/:id", author, h.DeletePlanItem)
 	}
 }
 
 // CreatePlan godoc
 // @Summary Создание тренировочного плана
-// @Description Создаёт новый тренировочный план
+// @Description Создаёт новый тренировочный план. Тренер может создавать планы только в своих категориях
 // @Tags ExercisePlan
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param plan body models.CreateExercesicePlanRequest true "Данные тренировочного плана"
 // @Success 200 {object} ExercisePlanResponse
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /plan/ [post]
 func (h *ExercisePlanHandler) CreatePlan(c *gin.Context) {
 	var inputPlan models.CreateExercesicePlanRequest
@@ -68,6 +89,10 @@ func (h *ExercisePlanHandler) CreatePlan(c *gin.Context) {
 		return
 	}
 
+	if !h.guard.Category(c, inputPlan.CategoryID) {
+		return
+	}
+
 	plan, err := h.exer.CreatePlan(inputPlan)
 	if err != nil {
 		h.log.Error("error in db")
@@ -81,11 +106,15 @@ func (h *ExercisePlanHandler) CreatePlan(c *gin.Context) {
 
 // GetByID godoc
 // @Summary Получить тренировочный план по ID
+// @Description Упражнения видны только купившим категорию или имеющим активную подписку, остальным возвращается превью (ExercisePlanPreview)
 // @Tags ExercisePlan
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID плана"
+// @Param version query int false "Опубликованная версия, только для авторов; покупатели видят версию своей покупки"
 // @Success 200 {object} ExercisePlanResponse
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /plan/{id} [get]
 func (h *ExercisePlanHandler) GetByID(c *gin.Context) {
 	idStr := c.Param("id")
@@ -103,14 +132,154 @@ func (h *ExercisePlanHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	if !h.gate.CanViewExercisePlan(c, plan) {
+		if plan.ClientID != nil {
+			h.gate.hidePrivate(c)
+			return
+		}
+		// превью строится по последней опубликованной версии, а не по черновику
+		if plan, ok := h.versionedPlan(c, plan.ID); ok {
+			c.IndentedJSON(http.StatusOK, exercisePlanPreview(plan))
+		}
+		return
+	}
+
+	plan, ok := h.versionedPlan(c, plan.ID)
+	if !ok {
+		return
+	}
+
 	h.log.Info("success plan found", "plan_id", plan.ID)
 	c.IndentedJSON(http.StatusOK, plan)
 }
 
+// Schedule godoc
+// @Summary Расписание тренировочного плана
+// @Description Упражнения по неделям (от 1 до duration_weeks) и дням недели с monday по sunday с подходами, повторами и нагрузкой по правилам прогрессии; дни без упражнений отмечены как дни отдыха. Доступно после покупки категории или с активной подпиской
+// @Tags ExercisePlan
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID плана"
+// @Param version query int false "Опубликованная версия, только для авторов; покупатели видят версию своей покупки"
+// @Success 200 {object} models.ExercisePlanSchedule
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /plan/{id}/schedule [get]
+func (h *ExercisePlanHandler) Schedule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("error parse id")
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	plan, err := h.exer.GetPlanByIDNotPreloads(uint(id))
+	if err != nil {
+		h.log.Error("error found plan in db")
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if !h.gate.CanViewExercisePlan(c, plan) {
+		h.gate.denyExercisePlan(c, plan)
+		return
+	}
+
+	plan, ok := h.versionedPlan(c, plan.ID)
+	if !ok {
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, h.exer.Schedule(plan))
+}
+
+// WeekPrescription godoc
+// @Summary Упражнения недели тренировочного плана
+// @Description Подходы, повторы и нагрузка на неделю week по правилам прогрессии пунктов; в разгрузочную неделю у пункта deload = true. Доступно после покупки категории или с активной подпиской
+// @Tags ExercisePlan
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID плана"
+// @Param week path int true "Номер недели, от 1 до duration_weeks"
+// @Param version query int false "Опубликованная версия, только для авторов; покупатели видят версию своей покупки"
+// @Success 200 {object} models.ScheduleWeek
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /plan/{id}/weeks/{week} [get]
+func (h *ExercisePlanHandler) WeekPrescription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("error parse id")
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	week, err := strconv.Atoi(c.Param("week"))
+	if err != nil {
+		h.log.Warn("error parse week")
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "invalid week"})
+		return
+	}
+
+	plan, err := h.exer.GetPlanByIDNotPreloads(uint(id))
+	if err != nil {
+		h.log.Error("error found plan in db")
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if !h.gate.CanViewExercisePlan(c, plan) {
+		h.gate.denyExercisePlan(c, plan)
+		return
+	}
+
+	plan, ok := h.versionedPlan(c, plan.ID)
+	if !ok {
+		return
+	}
+
+	prescription, err := h.exer.WeekPrescription(plan, week)
+	if err != nil {
+		h.log.Warn("error build week prescription", "plan_id", plan.ID, "week", week)
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, prescription)
+}
+
+// versionedPlan возвращает опубликованную версию плана, которую должен видеть пользователь;
+// авторам — черновик или версию из параметра version. При ошибке ответ уже записан
+func (h *ExercisePlanHandler) versionedPlan(c *gin.Context, id uint) (*models.ExercisePlan, bool) {
+	version, err := strconv.Atoi(c.DefaultQuery("version", "0"))
+	if err != nil || version < 0 {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return nil, false
+	}
+
+	plan, err := h.versions.ExercisePlanFor(currentUser(c), id, version)
+	switch {
+	case errors.Is(err, service.ErrPlanNotFound), errors.Is(err, service.ErrPlanNotPublished),
+		errors.Is(err, service.ErrPlanVersionNotFound):
+		c.IndentedJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	case err != nil:
+		h.log.Error("error found plan version", "plan_id", id, "version", version, "err", err)
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return plan, true
+}
+
 // GetAllPlan godoc
 // @Summary Получить список тренировочных планов
+// @Description Каталог вместе с личными планами, которые тренер составил для текущего пользователя
 // @Tags ExercisePlan
 // @Produce json
+// @Security BearerAuth
 // @Success 200 {array} ExercisePlanResponse
 // @Failure 400 {object} map[string]string
 // @Router /plan/ [get]
@@ -122,8 +291,15 @@ func (h *ExercisePlanHandler) GetAllPlan(c *gin.Context) {
 		return
 	}
 
+	visible := make([]models.ExercisePlan, 0, len(list))
+	for _, plan := range list {
+		if h.gate.Listed(c, plan.ClientID, plan.TrainerID) {
+			visible = append(visible, plan)
+		}
+	}
+
 	h.log.Info("list found success")
-	c.IndentedJSON(http.StatusOK, list)
+	c.IndentedJSON(http.StatusOK, visible)
 }
 
 // UpdatePlan godoc
@@ -131,10 +307,14 @@ func (h *ExercisePlanHandler) GetAllPlan(c *gin.Context) {
 // @Tags ExercisePlan
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID плана"
 // @Param plan body models.UpdateExercesicePlanRequest true "Обновлённые данные"
 // @Success 200 {object} ExercisePlanResponse
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /plan/{id} [patch]
 func (h *ExercisePlanHandler) UpdatePlan(c *gin.Context) {
 	idStr := c.Param("id")
@@ -153,6 +333,10 @@ func (h *ExercisePlanHandler) UpdatePlan(c *gin.Context) {
 		return
 	}
 
+	if !h.guard.ExercisePlan(c, uint(id)) {
+		return
+	}
+
 	plan, err := h.exer.UpdatePlan(uint(id), updatePlan)
 	if err != nil {
 		h.log.Error("error update plan in db")
@@ -168,9 +352,13 @@ func (h *ExercisePlanHandler) UpdatePlan(c *gin.Context) {
 // @Summary Удалить тренировочный план
 // @Tags ExercisePlan
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID плана"
 // @Success 200 {object} map[string]bool
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /plan/{id} [delete]
 func (h *ExercisePlanHandler) DeletePlan(c *gin.Context) {
 	idStr := c.Param("id")
@@ -181,6 +369,10 @@ func (h *ExercisePlanHandler) DeletePlan(c *gin.Context) {
 		return
 	}
 
+	if !h.guard.ExercisePlan(c, uint(id)) {
+		return
+	}
+
 	if err := h.exer.DeletePlan(uint(id)); err != nil {
 		h.log.Error("error delete plan in db")
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "invalid id or update"})
@@ -196,10 +388,14 @@ func (h *ExercisePlanHandler) DeletePlan(c *gin.Context) {
 // @Tags ExercisePlanItem
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param item body models.CreateExercisePlanItemRequest true "Данные элемента плана"
 // @Success 200 {object} models.ExercisePlanItem
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /plan/planItem [post]
 func (h *ExercisePlanHandler) CreatePlanItem(c *gin.Context) {
 	var inputPlanItem models.CreateExercisePlanItemRequest
@@ -210,6 +406,10 @@ func (h *ExercisePlanHandler) CreatePlanItem(c *gin.Context) {
 		return
 	}
 
+	if !h.guard.ExercisePlan(c, inputPlanItem.ExercisePlanID) {
+		return
+	}
+
 	plan, err := h.exer.CreatePlanItem(inputPlanItem)
 	if err != nil {
 		h.log.Error("error in db")
@@ -223,11 +423,15 @@ func (h *ExercisePlanHandler) CreatePlanItem(c *gin.Context) {
 
 // GetPlanItemByID godoc
 // @Summary Получить элемент плана по ID
+// @Description Доступно купившим категорию плана или имеющим активную подписку; покупатели видят упражнение в версии своей покупки
 // @Tags ExercisePlanItem
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID элемента плана"
 // @Success 200 {object} models.ExercisePlanItem
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
 // @Router /plan/planItem/{id} [get]
 func (h *ExercisePlanHandler) GetPlanItemByID(c *gin.Context) {
 	idStr := c.Param("id")
@@ -245,14 +449,43 @@ func (h *ExercisePlanHandler) GetPlanItemByID(c *gin.Context) {
 		return
 	}
 
+	parent, err := h.exer.GetPlanByIDNotPreloads(plan.ExercisePlanID)
+	if err != nil {
+		h.log.Error("error found parent plan in db", "plan_id", plan.ExercisePlanID)
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if !h.gate.CanViewExercisePlan(c, parent) {
+		h.gate.denyExercisePlan(c, parent)
+		return
+	}
+
+	version, ok := h.versionedPlan(c, parent.ID)
+	if !ok {
+		return
+	}
+	// покупатель видит упражнение таким, каким оно было в его версии плана;
+	// упражнения, которых в ней нет, для него не существуют
+	if version.Version != 0 {
+		index := slices.IndexFunc(version.Exercises, func(item models.ExercisePlanItem) bool { return item.ID == plan.ID })
+		if index < 0 {
+			c.IndentedJSON(http.StatusNotFound, gin.H{"error": "элемент плана не найден"})
+			return
+		}
+		plan = &version.Exercises[index]
+	}
+
 	h.log.Info("success planItem found", "planItem_id", plan.ID)
 	c.IndentedJSON(http.StatusOK, plan)
 }
 
 // GetListPlanItem godoc
 // @Summary Получить список элементов плана
+// @Description Возвращает только упражнения из доступных пользователю категорий в версиях, которые пользователь видит
 // @Tags ExercisePlanItem
 // @Produce json
+// @Security BearerAuth
 // @Success 200 {array} models.ExercisePlanItem
 // @Failure 400 {object} map[string]string
 // @Router /plan/planItem/ [get]
@@ -264,8 +497,44 @@ func (h *ExercisePlanHandler) GetListPlanItem(c *gin.Context) {
 		return
 	}
 
+	plans, err := h.exer.GetListPlans()
+	if err != nil {
+		h.log.Error("error found plan list in db")
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "invalid list"})
+		return
+	}
+
+	drafts := make(map[uint][]models.ExercisePlanItem, len(plans))
+	for _, item := range list {
+		drafts[item.ExercisePlanID] = append(drafts[item.ExercisePlanID], item)
+	}
+
+	visible := make([]models.ExercisePlanItem, 0, len(list))
+	for i := range plans {
+		if !h.gate.CanViewExercisePlan(c, &plans[i]) {
+			continue
+		}
+
+		plan, err := h.versions.ResolveExercisePlan(currentUser(c), &plans[i])
+		if errors.Is(err, service.ErrPlanNotPublished) {
+			continue
+		}
+		if err != nil {
+			h.log.Error("error found plan version", "plan_id", plans[i].ID, "err", err)
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// черновик видят авторы и клиент личного плана, остальные — упражнения своей версии
+		if plan.Version == 0 {
+			visible = append(visible, drafts[plan.ID]...)
+		} else {
+			visible = append(visible, plan.Exercises...)
+		}
+	}
+
 	h.log.Info("success list found")
-	c.IndentedJSON(http.StatusOK, list)
+	c.IndentedJSON(http.StatusOK, visible)
 }
 
 // UpdatePlanItem godoc
@@ -273,10 +542,14 @@ func (h *ExercisePlanHandler) GetListPlanItem(c *gin.Context) {
 // @Tags ExercisePlanItem
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID элемента"
 // @Param item body models.UpdateExercisePlanItemRequest true "Обновление"
 // @Success 200 {object} models.ExercisePlanItem
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /plan/planItem/{id} [patch]
 func (h *ExercisePlanHandler) UpdatePlanItem(c *gin.Context) {
 	idStr := c.Param("id")
@@ -295,6 +568,14 @@ func (h *ExercisePlanHandler) UpdatePlanItem(c *gin.Context) {
 		return
 	}
 
+	if !h.guard.ExercisePlanItem(c, uint(id)) {
+		return
+	}
+	// перенести упражнение можно только в план, который автор тоже вправе менять
+	if updatePlan.ExercisePlanID != nil && !h.guard.ExercisePlan(c, *updatePlan.ExercisePlanID) {
+		return
+	}
+
 	plan, err := h.exer.UpdatePlanItem(uint(id), updatePlan)
 	if err != nil {
 		h.log.Error("error update planItem in db")
@@ -310,9 +591,13 @@ func (h *ExercisePlanHandler) UpdatePlanItem(c *gin.Context) {
 // @Summary Удалить элемент плана
 // @Tags ExercisePlanItem
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID элемента"
 // @Success 200 {object} map[string]bool
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /plan/planItem/{id} [delete]
 func (h *ExercisePlanHandler) DeletePlanItem(c *gin.Context) {
 	idStr := c.Param("id")
@@ -323,6 +608,10 @@ func (h *ExercisePlanHandler) DeletePlanItem(c *gin.Context) {
 		return
 	}
 
+	if !h.guard.ExercisePlanItem(c, uint(id)) {
+		return
+	}
+
 	if err := h.exer.DeletePlanItem(uint(id)); err != nil {
 		h.log.Error("error delete planItem in db")
 		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "invalid id or update"})
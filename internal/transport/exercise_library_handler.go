@@ -0,0 +1,195 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExerciseLibraryHandler struct {
+	exercises service.ExerciseLibraryService
+	auth      *AuthMiddleware
+	log       *slog.Logger
+}
+
+func NewExerciseLibraryHandler(exercises service.ExerciseLibraryService, auth *AuthMiddleware, log *slog.Logger) *ExerciseLibraryHandler {
+	return &ExerciseLibraryHandler{exercises: exercises, auth: auth, log: log}
+}
+
+func (h *ExerciseLibraryHandler) RegisterRoutes(r *gin.Engine) {
+	author := h.auth.Require(service.PermAuthorPlans)
+
+	exercises := r.Group("/exercises")
+	{
+		exercises.POST("/", author, h.CreateExercise)
+		exercises.GET("/", h.SearchExercises)
+		exercises.GET("/:id", h.GetExercise)
+		exercises.PATCH("/:id", author, h.UpdateExercise)
+		exercises.DELETE("/:id", author, h.DeleteExercise)
+	}
+}
+
+// CreateExercise godoc
+// @Summary Добавить упражнение в библиотеку
+// @Description Пункты тренировочных планов с тем же названием привязываются к новому упражнению
+// @Tags Exercises
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param exercise body models.CreateExerciseRequest true "Упражнение"
+// @Success 201 {object} models.Exercise
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /exercises/ [post]
+func (h *ExerciseLibraryHandler) CreateExercise(c *gin.Context) {
+	var req models.CreateExerciseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	exercise, err := h.exercises.CreateExercise(req)
+	if err != nil {
+		h.exerciseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, exercise)
+}
+
+// SearchExercises godoc
+// @Summary Поиск по библиотеке упражнений
+// @Tags Exercises
+// @Produce json
+// @Param q query string false "Часть названия"
+// @Param muscle query string false "Группа мышц, например chest"
+// @Param equipment query string false "Инвентарь, например dumbbell"
+// @Param difficulty query string false "beginner, intermediate или advanced"
+// @Success 200 {array} models.Exercise
+// @Failure 400 {object} map[string]string
+// @Router /exercises/ [get]
+func (h *ExerciseLibraryHandler) SearchExercises(c *gin.Context) {
+	exercises, err := h.exercises.SearchExercises(models.ExerciseFilter{
+		Query:       c.Query("q"),
+		MuscleGroup: c.Query("muscle"),
+		Equipment:   c.Query("equipment"),
+		Difficulty:  c.Query("difficulty"),
+	})
+	if err != nil {
+		h.exerciseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exercises)
+}
+
+// GetExercise godoc
+// @Summary Упражнение по ID
+// @Tags Exercises
+// @Produce json
+// @Param id path int true "ID упражнения"
+// @Success 200 {object} models.Exercise
+// @Failure 404 {object} map[string]string
+// @Router /exercises/{id} [get]
+func (h *ExerciseLibraryHandler) GetExercise(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	exercise, err := h.exercises.GetExercise(id)
+	if err != nil {
+		h.exerciseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exercise)
+}
+
+// UpdateExercise godoc
+// @Summary Изменить упражнение
+// @Description Название и инвентарь переносятся в привязанные пункты тренировочных планов
+// @Tags Exercises
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID упражнения"
+// @Param exercise body models.UpdateExerciseRequest true "Изменяемые поля"
+// @Success 200 {object} models.Exercise
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /exercises/{id} [patch]
+func (h *ExerciseLibraryHandler) UpdateExercise(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateExerciseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	exercise, err := h.exercises.UpdateExercise(id, req)
+	if err != nil {
+		h.exerciseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exercise)
+}
+
+// DeleteExercise godoc
+// @Summary Удалить упражнение
+// @Description Упражнение, на которое ссылаются тренировочные планы, удалить нельзя
+// @Tags Exercises
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID упражнения"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /exercises/{id} [delete]
+func (h *ExerciseLibraryHandler) DeleteExercise(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.exercises.DeleteExercise(id); err != nil {
+		h.exerciseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "упражнение удалено"})
+}
+
+func (h *ExerciseLibraryHandler) pathID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *ExerciseLibraryHandler) exerciseError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrExerciseNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrExerciseInUse):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
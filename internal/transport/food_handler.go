@@ -0,0 +1,323 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FoodHandler struct {
+	foods service.FoodService
+	auth  *AuthMiddleware
+	log   *slog.Logger
+}
+
+func NewFoodHandler(foods service.FoodService, auth *AuthMiddleware, log *slog.Logger) *FoodHandler {
+	return &FoodHandler{foods: foods, auth: auth, log: log}
+}
+
+func (h *FoodHandler) RegisterRoutes(r *gin.Engine) {
+	author := h.auth.Require(service.PermAuthorPlans)
+
+	foods := r.Group("/foods")
+	{
+		foods.POST("/", author, h.CreateFood)
+		foods.GET("/", h.ListFoods)
+		foods.GET("/:id", h.GetFood)
+		foods.PATCH("/:id", author, h.UpdateFood)
+		foods.DELETE("/:id", author, h.DeleteFood)
+	}
+
+	recipes := r.Group("/recipes")
+	{
+		recipes.POST("/", author, h.CreateRecipe)
+		recipes.GET("/", h.ListRecipes)
+		recipes.GET("/:id", h.GetRecipe)
+		recipes.PATCH("/:id", author, h.UpdateRecipe)
+		recipes.DELETE("/:id", author, h.DeleteRecipe)
+	}
+}
+
+// CreateFood godoc
+// @Summary Добавить продукт в справочник
+// @Description Калории и нутриенты указываются на 100 г; piece_grams — вес одной штуки для единицы pcs
+// @Tags Foods
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param food body models.CreateFoodRequest true "Продукт"
+// @Success 201 {object} models.Food
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /foods/ [post]
+func (h *FoodHandler) CreateFood(c *gin.Context) {
+	var req models.CreateFoodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	food, err := h.foods.CreateFood(req)
+	if err != nil {
+		h.foodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, food)
+}
+
+// ListFoods godoc
+// @Summary Справочник продуктов
+// @Tags Foods
+// @Produce json
+// @Param q query string false "Часть названия"
+// @Success 200 {array} models.Food
+// @Router /foods/ [get]
+func (h *FoodHandler) ListFoods(c *gin.Context) {
+	foods, err := h.foods.ListFoods(c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, foods)
+}
+
+// GetFood godoc
+// @Summary Продукт по ID
+// @Tags Foods
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Success 200 {object} models.Food
+// @Failure 404 {object} map[string]string
+// @Router /foods/{id} [get]
+func (h *FoodHandler) GetFood(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	food, err := h.foods.GetFood(id)
+	if err != nil {
+		h.foodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, food)
+}
+
+// UpdateFood godoc
+// @Summary Изменить продукт
+// @Description Рецепты с этим продуктом и блюда планов питания по ним пересчитываются
+// @Tags Foods
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID продукта"
+// @Param food body models.UpdateFoodRequest true "Изменяемые поля"
+// @Success 200 {object} models.Food
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /foods/{id} [patch]
+func (h *FoodHandler) UpdateFood(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateFoodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	food, err := h.foods.UpdateFood(id, req)
+	if err != nil {
+		h.foodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, food)
+}
+
+// DeleteFood godoc
+// @Summary Удалить продукт
+// @Description Продукт, который входит в рецепты, удалить нельзя
+// @Tags Foods
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID продукта"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /foods/{id} [delete]
+func (h *FoodHandler) DeleteFood(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.foods.DeleteFood(id); err != nil {
+		h.foodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "продукт удалён"})
+}
+
+// CreateRecipe godoc
+// @Summary Создать рецепт
+// @Description Рецепт из продуктов справочника с количеством в g, kg, ml, l, tsp, tbsp или pcs. Пищевая ценность считается автоматически.
+// @Tags Recipes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param recipe body models.CreateRecipeRequest true "Рецепт"
+// @Success 201 {object} models.Recipe
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /recipes/ [post]
+func (h *FoodHandler) CreateRecipe(c *gin.Context) {
+	var req models.CreateRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	recipe, err := h.foods.CreateRecipe(req)
+	if err != nil {
+		h.foodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, recipe)
+}
+
+// ListRecipes godoc
+// @Summary Рецепты
+// @Tags Recipes
+// @Produce json
+// @Success 200 {array} models.Recipe
+// @Router /recipes/ [get]
+func (h *FoodHandler) ListRecipes(c *gin.Context) {
+	recipes, err := h.foods.ListRecipes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recipes)
+}
+
+// GetRecipe godoc
+// @Summary Рецепт с составом и пищевой ценностью
+// @Tags Recipes
+// @Produce json
+// @Param id path int true "ID рецепта"
+// @Success 200 {object} models.Recipe
+// @Failure 404 {object} map[string]string
+// @Router /recipes/{id} [get]
+func (h *FoodHandler) GetRecipe(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	recipe, err := h.foods.GetRecipe(id)
+	if err != nil {
+		h.foodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recipe)
+}
+
+// UpdateRecipe godoc
+// @Summary Изменить рецепт
+// @Description Переданный список ingredients заменяет состав целиком. Блюда планов питания по рецепту пересчитываются.
+// @Tags Recipes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID рецепта"
+// @Param recipe body models.UpdateRecipeRequest true "Изменяемые поля"
+// @Success 200 {object} models.Recipe
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /recipes/{id} [patch]
+func (h *FoodHandler) UpdateRecipe(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	recipe, err := h.foods.UpdateRecipe(id, req)
+	if err != nil {
+		h.foodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recipe)
+}
+
+// DeleteRecipe godoc
+// @Summary Удалить рецепт
+// @Description Рецепт, который используется в планах питания, удалить нельзя
+// @Tags Recipes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID рецепта"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /recipes/{id} [delete]
+func (h *FoodHandler) DeleteRecipe(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.foods.DeleteRecipe(id); err != nil {
+		h.foodError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "рецепт удалён"})
+}
+
+func (h *FoodHandler) pathID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *FoodHandler) foodError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrFoodNotFound), errors.Is(err, service.ErrRecipeNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrFoodInUse), errors.Is(err, service.ErrRecipeInUse):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,202 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GiftHandler struct {
+	gifts       service.GiftService
+	auth        *AuthMiddleware
+	idempotency *IdempotencyMiddleware
+	log         *slog.Logger
+}
+
+func NewGiftHandler(gifts service.GiftService, auth *AuthMiddleware, idempotency *IdempotencyMiddleware, log *slog.Logger) *GiftHandler {
+	return &GiftHandler{gifts: gifts, auth: auth, idempotency: idempotency, log: log}
+}
+
+func (h *GiftHandler) RegisterRoutes(r *gin.Engine) {
+	gifts := r.Group("/gifts", h.auth.RequireAuth())
+	{
+		gifts.POST("/", h.idempotency.Handle(), h.Send)
+		gifts.GET("/sent", h.ListSent)
+		gifts.GET("/received", h.ListReceived)
+		gifts.GET("/:id", h.GetByID)
+		gifts.POST("/:id/accept", h.Accept)
+		gifts.POST("/:id/decline", h.Decline)
+	}
+}
+
+// Send godoc
+// @Summary Подарить категорию
+// @Description Списывает стоимость категории с отправителя. Получатель получает уведомление и должен принять подарок до истечения GIFT_EXPIRY_DAYS дней, иначе деньги вернутся отправителю.
+// @Tags Gifts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param gift body models.CreateGiftRequest true "Получатель, категория и сообщение"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернёт сохранённый ответ"
+// @Success 201 {object} models.Gift
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /gifts/ [post]
+func (h *GiftHandler) Send(c *gin.Context) {
+	var req models.CreateGiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	gift, err := h.gifts.Send(currentUserID(c), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gift)
+}
+
+// ListSent godoc
+// @Summary Отправленные подарки
+// @Tags Gifts
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, accepted, declined или expired"
+// @Success 200 {array} models.Gift
+// @Failure 401 {object} map[string]string
+// @Router /gifts/sent [get]
+func (h *GiftHandler) ListSent(c *gin.Context) {
+	gifts, err := h.gifts.ListSent(currentUserID(c), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gifts)
+}
+
+// ListReceived godoc
+// @Summary Полученные подарки
+// @Tags Gifts
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, accepted, declined или expired"
+// @Success 200 {array} models.Gift
+// @Failure 401 {object} map[string]string
+// @Router /gifts/received [get]
+func (h *GiftHandler) ListReceived(c *gin.Context) {
+	gifts, err := h.gifts.ListReceived(currentUserID(c), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gifts)
+}
+
+// GetByID godoc
+// @Summary Подарок
+// @Description Доступен отправителю и получателю
+// @Tags Gifts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подарка"
+// @Success 200 {object} models.Gift
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /gifts/{id} [get]
+func (h *GiftHandler) GetByID(c *gin.Context) {
+	id, ok := h.giftID(c)
+	if !ok {
+		return
+	}
+
+	gift, err := h.gifts.GetByID(currentUserID(c), id)
+	if err != nil {
+		h.giftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gift)
+}
+
+// Accept godoc
+// @Summary Принять подарок
+// @Description Открывает получателю доступ к подаренной категории. Если категория у получателя уже есть, подарок отклоняется (status declined) и деньги возвращаются отправителю
+// @Tags Gifts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подарка"
+// @Success 200 {object} models.Gift
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /gifts/{id}/accept [post]
+func (h *GiftHandler) Accept(c *gin.Context) {
+	id, ok := h.giftID(c)
+	if !ok {
+		return
+	}
+
+	gift, err := h.gifts.Accept(currentUserID(c), id)
+	if err != nil {
+		h.giftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gift)
+}
+
+// Decline godoc
+// @Summary Отклонить подарок
+// @Description Возвращает стоимость подарка отправителю
+// @Tags Gifts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подарка"
+// @Success 200 {object} models.Gift
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /gifts/{id}/decline [post]
+func (h *GiftHandler) Decline(c *gin.Context) {
+	id, ok := h.giftID(c)
+	if !ok {
+		return
+	}
+
+	gift, err := h.gifts.Decline(currentUserID(c), id)
+	if err != nil {
+		h.giftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gift)
+}
+
+func (h *GiftHandler) giftID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *GiftHandler) giftError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, service.ErrGiftNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,102 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"healthy_body/internal/service"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+)
+
+type IdempotencyMiddleware struct {
+	keys service.IdempotencyService
+	log  *slog.Logger
+}
+
+func NewIdempotencyMiddleware(keys service.IdempotencyService, log *slog.Logger) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{keys: keys, log: log}
+}
+
+// responseRecorder дублирует тело ответа, чтобы сохранить его вместе с ключом
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Handle выполняет запрос с заголовком Idempotency-Key не более одного раза:
+// повтор с тем же ключом и телом получает сохранённый ответ, с другим телом — 422.
+// Запросы без заголовка обрабатываются как обычно. Должен стоять после RequireAuth
+func (m *IdempotencyMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "не удалось прочитать тело запроса"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := service.RequestFingerprint(c.Request.Method, c.Request.URL.Path, body)
+		record, err := m.keys.Begin(currentUserID(c), key, fingerprint)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyInvalid):
+				status = http.StatusBadRequest
+			case errors.Is(err, service.ErrIdempotencyKeyMismatch):
+				status = http.StatusUnprocessableEntity
+			case errors.Is(err, service.ErrIdempotencyInProgress):
+				status = http.StatusConflict
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		if record.CompletedAt != nil {
+			c.Header(idempotencyReplayedHeader, "true")
+			c.Data(record.ResponseStatus, record.ContentType, []byte(record.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := m.keys.Release(record); err != nil {
+				m.log.Error("Не удалось освободить Idempotency-Key", "error", err.Error())
+			}
+			return
+		}
+
+		if err := m.keys.Complete(record, status, recorder.Header().Get("Content-Type"), recorder.body.String()); err != nil {
+			m.log.Error("Не удалось сохранить ответ для Idempotency-Key", "error", err.Error())
+		}
+	}
+}
@@ -1,11 +1,16 @@
 package transport
 
 import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"healthy_body/internal/models"
 	"healthy_body/internal/service"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,38 +22,59 @@ type MealPlanResponse struct {
 	Description  string `json:"description"`
 	CategoriesID *uint  `json:"categories_id"`
 	TotalDays    int    `json:"total_days"`
+	ClientID     *uint  `json:"client_id,omitempty"`
+	TrainerID    *uint  `json:"trainer_id,omitempty"`
 }
 
 type MealPlanHandler struct {
 	mealPlans service.MealPlanService
+	versions  service.PlanVersionService
+	auth      *AuthMiddleware
+	gate      *ContentGate
+	guard     *PlanGuard
 	logger    *slog.Logger
 }
 
-func NewMealPlanHandler(mealPlans service.MealPlanService, logger *slog.Logger) *MealPlanHandler {
+func NewMealPlanHandler(mealPlans service.MealPlanService, versions service.PlanVersionService, auth *AuthMiddleware, gate *ContentGate, guard *PlanGuard, logger *slog.Logger) *MealPlanHandler {
 	return &MealPlanHandler{
 		mealPlans: mealPlans,
+		versions:  versions,
+		auth:      auth,
+		gate:      gate,
+		guard:     guard,
 		logger:    logger,
 	}
 }
 
 func (h *MealPlanHandler) RegisterRoutes(r *gin.Engine) {
+	author := h.auth.Require(service.PermAuthorPlans)
+	viewer := h.auth.OptionalAuth()
+
 	mealPlans := r.Group("/mealPlans")
 	{
-		mealPlans.POST("/", h.Create)
-		mealPlans.GET("/", h.GetAllMealPlans)
-		mealPlans.GET("/:id", h.GetMealPlanByID)
-		mealPlans.PATCH("/:id", h.Update)
-		mealPlans.DELETE("/:id", h.Delete)
+		mealPlans.POST("/", author, h.Create)
+		mealPlans.GET("/", viewer, h.GetAllMealPlans)
+		mealPlans.GET("/:id", viewer, h.GetMealPlanByID)
+		mealPlans.GET("/:id/nutrition", viewer, h.Nutrition)
+		mealPlans.GET("/:id/shopping-list", viewer, h.ShoppingList)
+		mealPlans.POST("/:id/compare", viewer, h.CompareWithNeeds)
+		mealPlans.PATCH("/:id", author, h.Update)
+		mealPlans.DELETE("/:id", author, h.Delete)
 	}
 }
 
 // @Summary Create Meal Plan
+// @Description Trainers may create meal plans only in categories they own
 // @Tags MealPlans
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param mealPlan body models.CreateMealPlanRequest true "Meal Plan Data"
 // @Success 200 {object} MealPlanResponse
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /mealPlans/ [post]
 func (h *MealPlanHandler) Create(c *gin.Context) {
 	var req models.CreateMealPlanRequest
@@ -57,6 +83,14 @@ func (h *MealPlanHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.CategoriesID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category id is required"})
+		return
+	}
+	if !h.guard.Category(c, *req.CategoriesID) {
+		return
+	}
+
 	mealPlan, err := h.mealPlans.CreateMealPlan(req)
 	if err != nil {
 		h.logger.Error("handler: failed to create meal plan", "err", err)
@@ -68,31 +102,78 @@ func (h *MealPlanHandler) Create(c *gin.Context) {
 }
 
 // @Summary Get All Meal Plans
+// @Description Meals are included only for plans the user is entitled to; other plans are returned as MealPlanPreview. Buyers see the version pinned to their purchase, everyone else the latest published version; unpublished plans are listed for authors only. Private plans a trainer wrote for the current user are listed alongside the catalog. A plan has a diet tag when every meal has it, and an allergen when any meal has it.
 // @Tags MealPlans
 // @Produce json
+// @Security BearerAuth
+// @Param tags query string false "Comma-separated diet tags the plan must have: vegetarian, vegan, halal, gluten_free, lactose_free"
+// @Param exclude_allergens query string false "Comma-separated allergens the plan must not contain"
 // @Success 200 {array} MealPlanResponse
 // @Failure 400 {object} map[string]string
 // @Router /mealPlans/ [get]
 func (h *MealPlanHandler) GetAllMealPlans(c *gin.Context) {
-	mealPlans, err := h.mealPlans.ListMealPlan()
+	var filter models.MealPlanFilter
+	var ok bool
+	if filter.DietTags, ok = models.NormalizeTags(splitQuery(c.Query("tags")), models.DietTags); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("tags must be from %v", models.DietTags)})
+		return
+	}
+	if filter.ExcludeAllergens, ok = models.NormalizeTags(splitQuery(c.Query("exclude_allergens")), models.Allergens); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("exclude_allergens must be from %v", models.Allergens)})
+		return
+	}
+
+	// фильтр применяется к той версии плана, которую увидит пользователь, а не к черновику
+	mealPlans, err := h.mealPlans.ListMealPlan(models.MealPlanFilter{})
 	if err != nil {
 		h.logger.Error("failed to fetch meal plans")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	h.logger.Info("fetch to meal plans successfully", "count", len(mealPlans))
-	c.JSON(http.StatusOK, mealPlans)
+	result := make([]interface{}, 0, len(mealPlans))
+	for i := range mealPlans {
+		if !h.gate.Listed(c, mealPlans[i].ClientID, mealPlans[i].TrainerID) {
+			continue
+		}
+
+		mealPlan, err := h.versions.ResolveMealPlan(currentUser(c), &mealPlans[i])
+		if errors.Is(err, service.ErrPlanNotPublished) {
+			continue
+		}
+		if err != nil {
+			h.logger.Error("failed to resolve meal plan version", "id", mealPlans[i].ID, "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !filter.Matches(mealPlan) {
+			continue
+		}
+
+		if h.gate.CanViewMealPlan(c, mealPlan) {
+			result = append(result, mealPlan)
+		} else {
+			result = append(result, mealPlanPreview(mealPlan))
+		}
+	}
+
+	h.logger.Info("fetch to meal plans successfully", "count", len(result))
+	c.JSON(http.StatusOK, result)
 }
 
 // @Summary Update Meal Plan
+// @Description Trainers may edit only plans in categories they own and move them only into such categories
 // @Tags MealPlans
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Meal Plan ID"
 // @Param mealPlan body models.UpdateMealPlanRequest true "Update data"
 // @Success 200 {object} MealPlanResponse
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /mealPlans/{id} [patch]
 func (h *MealPlanHandler) Update(c *gin.Context) {
 	idStr := c.Param("id")
@@ -112,6 +193,13 @@ func (h *MealPlanHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if !h.guard.MealPlan(c, uint(id)) {
+		return
+	}
+	if req.CategoriesID != nil && !h.guard.Category(c, *req.CategoriesID) {
+		return
+	}
+
 	mealPlan, err := h.mealPlans.UpdateMealPlan(uint(id), &req)
 	if err != nil {
 		h.logger.Error("handler: failed to update meal plan")
@@ -125,9 +213,13 @@ func (h *MealPlanHandler) Update(c *gin.Context) {
 
 // @Summary Delete Meal Plan
 // @Tags MealPlans
+// @Security BearerAuth
 // @Param id path int true "Meal Plan ID"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /mealPlans/{id} [delete]
 func (h *MealPlanHandler) Delete(c *gin.Context) {
 	idStr := c.Param("id")
@@ -139,6 +231,10 @@ func (h *MealPlanHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	if !h.guard.MealPlan(c, uint(id)) {
+		return
+	}
+
 	if err := h.mealPlans.DeleteMealPlan(uint(id)); err != nil {
 		h.logger.Error("handler: failed to delete meal plan", "id", id)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ошибка при удалении плана"})
@@ -149,11 +245,15 @@ func (h *MealPlanHandler) Delete(c *gin.Context) {
 }
 
 // @Summary Get Meal Plan By ID
+// @Description Returns a MealPlanPreview without meals unless the user bought the category or has an active subscription. For a signed-in user, warnings list conflicts with their dietary preferences.
 // @Tags MealPlans
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Meal Plan ID"
+// @Param version query int false "Published version, authors only; buyers see the version of their purchase"
 // @Success 200 {object} MealPlanResponse
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /mealPlans/{id} [get]
 func (h *MealPlanHandler) GetMealPlanByID(c *gin.Context) {
 	idStr := c.Param("id")
@@ -169,6 +269,289 @@ func (h *MealPlanHandler) GetMealPlanByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+
+	if !h.gate.CanViewMealPlan(c, mealPlan) {
+		if mealPlan.ClientID != nil {
+			h.gate.hidePrivate(c)
+			return
+		}
+		// превью строится по последней опубликованной версии, а не по черновику
+		if mealPlan, ok := h.versionedPlan(c, mealPlan.ID); ok {
+			c.JSON(http.StatusOK, mealPlanPreview(mealPlan))
+		}
+		return
+	}
+
+	mealPlan, ok := h.versionedPlan(c, mealPlan.ID)
+	if !ok {
+		return
+	}
+
+	if user := currentUser(c); user != nil {
+		mealPlan.Warnings = h.mealPlans.DietWarnings(mealPlan, models.DietaryPreferences{
+			DietTags:  user.DietTags,
+			Allergens: user.Allergens,
+		})
+	}
+
 	h.logger.Info("handler: fetch to meal plan successfully")
 	c.JSON(http.StatusOK, mealPlan)
 }
+
+// @Summary Meal Plan Nutrition
+// @Description Calories, protein, fat, carbs and fibre per day, per meal slot and for the whole plan, with macro percentages by energy. Available after buying the category or with an active subscription.
+// @Tags MealPlans
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Meal Plan ID"
+// @Param version query int false "Published version, authors only; buyers see the version of their purchase"
+// @Success 200 {object} models.MealPlanNutrition
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /mealPlans/{id}/nutrition [get]
+func (h *MealPlanHandler) Nutrition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("handler: invalid meal plan id", "id", id)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mealPlan, err := h.mealPlans.GetMealPlanByID(uint(id))
+	if err != nil {
+		h.logger.Error("handler: failed to fetch meal plan", "id", id)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.gate.CanViewMealPlan(c, mealPlan) {
+		h.gate.denyMealPlan(c, mealPlan)
+		return
+	}
+
+	mealPlan, ok := h.versionedPlan(c, mealPlan.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mealPlans.Nutrition(mealPlan))
+}
+
+// @Summary Meal Plan Shopping List
+// @Description Ingredients of recipe-based items for the selected days, merged per food with units normalised to g/kg, ml/l or pcs and grouped by aisle. Available after buying the category or with an active subscription.
+// @Tags MealPlans
+// @Produce json
+// @Produce plain
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path int true "Meal Plan ID"
+// @Param version query int false "Published version, authors only; buyers see the version of their purchase"
+// @Param days query string false "Day or range of days, e.g. 3 or 1-7; whole plan by default"
+// @Param format query string false "json (default), text or csv"
+// @Success 200 {object} models.ShoppingList
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /mealPlans/{id}/shopping-list [get]
+func (h *MealPlanHandler) ShoppingList(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("handler: invalid meal plan id", "id", id)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fromDay, toDay, err := parseDayRange(c.Query("days"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "text" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, text or csv"})
+		return
+	}
+
+	mealPlan, err := h.mealPlans.GetMealPlanByID(uint(id))
+	if err != nil {
+		h.logger.Error("handler: failed to fetch meal plan", "id", id)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.gate.CanViewMealPlan(c, mealPlan) {
+		h.gate.denyMealPlan(c, mealPlan)
+		return
+	}
+
+	mealPlan, ok := h.versionedPlan(c, mealPlan.ID)
+	if !ok {
+		return
+	}
+
+	list, err := h.mealPlans.ShoppingList(mealPlan, fromDay, toDay)
+	if err != nil {
+		h.logger.Error("handler: failed to build shopping list", "id", id, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch format {
+	case "text":
+		c.String(http.StatusOK, shoppingListText(list))
+	case "csv":
+		data, err := shoppingListCSV(list)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="shopping-list-%d.csv"`, list.MealPlanID))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+	default:
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+// @Summary Compare Meal Plan With Needs
+// @Description Compares the plan's average daily calories, protein, fat, carbs and fibre with macro targets calculated from the user's profile. Available after buying the category or with an active subscription.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Meal Plan ID"
+// @Param version query int false "Published version, authors only; buyers see the version of their purchase"
+// @Param profile body models.HealthProfileRequest true "sex, age, weight_kg, height_cm, activity and goal"
+// @Success 200 {object} models.MealPlanComparison
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /mealPlans/{id}/compare [post]
+func (h *MealPlanHandler) CompareWithNeeds(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.logger.Error("handler: invalid meal plan id", "id", id)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.HealthProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("handler: invalid profile", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	targets, err := macroTargets(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mealPlan, err := h.mealPlans.GetMealPlanByID(uint(id))
+	if err != nil {
+		h.logger.Error("handler: failed to fetch meal plan", "id", id)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.gate.CanViewMealPlan(c, mealPlan) {
+		h.gate.denyMealPlan(c, mealPlan)
+		return
+	}
+
+	mealPlan, ok := h.versionedPlan(c, mealPlan.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mealPlans.CompareWithNeeds(mealPlan, *targets))
+}
+
+// versionedPlan returns the published version of the plan the user should see; authors get the
+// draft or the version from the version query parameter. On failure the response is already written
+func (h *MealPlanHandler) versionedPlan(c *gin.Context, id uint) (*models.MealPlan, bool) {
+	version, err := strconv.Atoi(c.DefaultQuery("version", "0"))
+	if err != nil || version < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return nil, false
+	}
+
+	mealPlan, err := h.versions.MealPlanFor(currentUser(c), id, version)
+	switch {
+	case errors.Is(err, service.ErrPlanNotFound), errors.Is(err, service.ErrPlanNotPublished),
+		errors.Is(err, service.ErrPlanVersionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	case err != nil:
+		h.logger.Error("handler: failed to fetch meal plan version", "id", id, "version", version, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return mealPlan, true
+}
+
+// parseDayRange reads "3" or "1-7"; an empty value means the whole plan
+func parseDayRange(value string) (int, int, error) {
+	if value == "" {
+		return 0, 0, nil
+	}
+
+	from, to, isRange := strings.Cut(value, "-")
+	fromDay, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || fromDay < 1 {
+		return 0, 0, fmt.Errorf("invalid days %q", value)
+	}
+	if !isRange {
+		return fromDay, fromDay, nil
+	}
+
+	toDay, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil || toDay < fromDay {
+		return 0, 0, fmt.Errorf("invalid days %q", value)
+	}
+	return fromDay, toDay, nil
+}
+
+func shoppingListText(list *models.ShoppingList) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Shopping list: %s, days %d-%d\n", list.MealPlanName, list.FromDay, list.ToDay)
+	for _, aisle := range list.Aisles {
+		fmt.Fprintf(&b, "\n%s\n", aisle.Aisle)
+		for _, item := range aisle.Items {
+			fmt.Fprintf(&b, "- %s: %s %s\n", item.Name, strconv.FormatFloat(item.Quantity, 'f', -1, 64), item.Unit)
+		}
+	}
+	if len(list.WithoutRecipe) > 0 {
+		fmt.Fprintf(&b, "\nMeals without a recipe\n")
+		for _, name := range list.WithoutRecipe {
+			fmt.Fprintf(&b, "- %s\n", name)
+		}
+	}
+	return b.String()
+}
+
+func shoppingListCSV(list *models.ShoppingList) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	_ = w.Write([]string{"aisle", "name", "quantity", "unit"})
+	for _, aisle := range list.Aisles {
+		for _, item := range aisle.Items {
+			_ = w.Write([]string{aisle.Aisle, item.Name, strconv.FormatFloat(item.Quantity, 'f', -1, 64), item.Unit})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func splitQuery(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -1,10 +1,12 @@
 package transport
 
 import (
+	"errors"
 	"healthy_body/internal/models"
 	"healthy_body/internal/service"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -12,48 +14,81 @@ import (
 
 // MealPlanItemResponse используется для Swagger без gorm.Model
 type MealPlanItemResponse struct {
-	ID          uint    `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Calories    float64 `json:"calories"`
-	Protein     float64 `json:"protein"`
-	Carbs       float64 `json:"carbs"`
-	MealPlanId  uint    `json:"meal_plan_id"`
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Calories    float64  `json:"calories"`
+	Protein     float64  `json:"protein"`
+	Carbs       float64  `json:"carbs"`
+	Fat         float64  `json:"fat"`
+	Fibre       float64  `json:"fibre"`
+	Day         int      `json:"day"`
+	MealSlot    string   `json:"meal_slot"`
+	RecipeID    *uint    `json:"recipe_id"`
+	Servings    float64  `json:"servings"`
+	DietTags    []string `json:"diet_tags"`
+	Allergens   []string `json:"allergens"`
+	MealPlanId  uint     `json:"meal_plan_id"`
 }
 
 type MealPlanItemHandler struct {
 	mealPlanItems service.MealPlanItemsService
+	mealPlans     service.MealPlanService
+	versions      service.PlanVersionService
+	auth          *AuthMiddleware
+	gate          *ContentGate
+	guard         *PlanGuard
 	logger        *slog.Logger
 }
 
-func NewMealPlanItemHandler(mealPlanItems service.MealPlanItemsService, logger *slog.Logger) *MealPlanItemHandler {
+func NewMealPlanItemHandler(
+	mealPlanItems service.MealPlanItemsService,
+	mealPlans service.MealPlanService,
+	versions service.PlanVersionService,
+	auth *AuthMiddleware,
+	gate *ContentGate,
+	guard *PlanGuard,
+	logger *slog.Logger,
+) *MealPlanItemHandler {
 	return &MealPlanItemHandler{
 		mealPlanItems: mealPlanItems,
+		mealPlans:     mealPlans,
+		versions:      versions,
+		auth:          auth,
+		gate:          gate,
+		guard:         guard,
 		logger:        logger,
 	}
 }
 
 // RegisterRoutes регистрирует маршруты
 func (h *MealPlanItemHandler) RegisterRoutes(r *gin.Engine) {
+	author := h.auth.Require(service.PermAuthorPlans)
+	viewer := h.auth.OptionalAuth()
+
 	mealPlanItems := r.Group("/mealPlanItems")
 	{
-		mealPlanItems.POST("/", h.Create)
-		mealPlanItems.GET("/", h.ListMealPlanItems)
-		mealPlanItems.PATCH("/:id", h.Update)
-		mealPlanItems.GET("/:id", h.GetMealPlanItemById)
-		mealPlanItems.DELETE("/:id", h.DeleteMealPlanItem)
+		mealPlanItems.POST("/", author, h.Create)
+		mealPlanItems.GET("/", viewer, h.ListMealPlanItems)
+		mealPlanItems.PATCH("/:id", author, h.Update)
+		mealPlanItems.GET("/:id", viewer, h.GetMealPlanItemById)
+		mealPlanItems.DELETE("/:id", author, h.DeleteMealPlanItem)
 	}
 }
 
 // Create godoc
 // @Summary Создать элемент плана питания
-// @Description Создает новый MealPlanItem
+// @Description Создает новый MealPlanItem. Если указан recipe_id, калории и нутриенты считаются по рецепту с учётом servings
 // @Tags MealPlanItems
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param mealPlanItem body models.CreateMealPlanItemRequest true "Данные для создания"
 // @Success 200 {object} MealPlanItemResponse
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /mealPlanItems/ [post]
 func (h *MealPlanItemHandler) Create(c *gin.Context) {
 	var req models.CreateMealPlanItemRequest
@@ -62,6 +97,10 @@ func (h *MealPlanItemHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !h.guard.MealPlan(c, req.MealPlanId) {
+		return
+	}
+
 	mealPlanItem, err := h.mealPlanItems.CreateMealPlanItem(req)
 	if err != nil {
 		h.logger.Error("handler: failed to create meal plan item", "err", err)
@@ -74,22 +113,41 @@ func (h *MealPlanItemHandler) Create(c *gin.Context) {
 
 // ListMealPlanItems godoc
 // @Summary Получить список всех элементов плана питания
-// @Description Возвращает массив MealPlanItem
+// @Description Возвращает MealPlanItem из планов, доступных пользователю, в версиях, которые пользователь видит
 // @Tags MealPlanItems
 // @Produce json
+// @Security BearerAuth
 // @Success 200 {array} MealPlanItemResponse
 // @Failure 400 {object} map[string]string
 // @Router /mealPlanItems/ [get]
 func (h *MealPlanItemHandler) ListMealPlanItems(c *gin.Context) {
-	mealPlanItems, err := h.mealPlanItems.GetAllMealPlanItems()
+	plans, err := h.mealPlans.ListMealPlan(models.MealPlanFilter{})
 	if err != nil {
-		h.logger.Error("failed to fetch meal plan items")
+		h.logger.Error("failed to fetch meal plans")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	h.logger.Info("fetch to meal plan items successfully", "count", len(mealPlanItems))
-	c.JSON(http.StatusOK, mealPlanItems)
+	visible := []models.MealPlanItem{}
+	for i := range plans {
+		if !h.gate.CanViewMealPlan(c, &plans[i]) {
+			continue
+		}
+
+		plan, err := h.versions.ResolveMealPlan(currentUser(c), &plans[i])
+		if errors.Is(err, service.ErrPlanNotPublished) {
+			continue
+		}
+		if err != nil {
+			h.logger.Error("failed to resolve meal plan version", "id", plans[i].ID, "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		visible = append(visible, plan.Meals...)
+	}
+
+	h.logger.Info("fetch to meal plan items successfully", "count", len(visible))
+	c.JSON(http.StatusOK, visible)
 }
 
 // Update godoc
@@ -98,10 +156,14 @@ func (h *MealPlanItemHandler) ListMealPlanItems(c *gin.Context) {
 // @Tags MealPlanItems
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID элемента"
 // @Param mealPlanItem body models.UpdateMealPlanItemRequest true "Данные для обновления"
 // @Success 200 {object} MealPlanItemResponse
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /mealPlanItems/{id} [patch]
 func (h *MealPlanItemHandler) Update(c *gin.Context) {
 	idStr := c.Param("id")
@@ -121,6 +183,14 @@ func (h *MealPlanItemHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if !h.guard.MealPlanItem(c, uint(id)) {
+		return
+	}
+	// перенести блюдо можно только в план, который автор тоже вправе менять
+	if req.MealPlanId != nil && !h.guard.MealPlan(c, *req.MealPlanId) {
+		return
+	}
+
 	mealPlanItem, err := h.mealPlanItems.UpdateMealPlanItem(uint(id), &req)
 	if err != nil {
 		h.logger.Error("handler: failed to update meal plan item")
@@ -134,12 +204,15 @@ func (h *MealPlanItemHandler) Update(c *gin.Context) {
 
 // GetMealPlanItemById godoc
 // @Summary Получить элемент плана питания по ID
-// @Description Возвращает MealPlanItem по ID
+// @Description Возвращает MealPlanItem по ID. Доступно купившим категорию плана или имеющим активную подписку; покупатели видят блюдо в версии своей покупки
 // @Tags MealPlanItems
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID элемента"
 // @Success 200 {object} MealPlanItemResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
 // @Router /mealPlanItems/{id} [get]
 func (h *MealPlanItemHandler) GetMealPlanItemById(c *gin.Context) {
 	idStr := c.Param("id")
@@ -157,8 +230,37 @@ func (h *MealPlanItemHandler) GetMealPlanItemById(c *gin.Context) {
 		return
 	}
 
+	mealPlan, err := h.mealPlans.GetMealPlanByID(mealPlanItem.MealPlanId)
+	if err != nil {
+		h.logger.Error("handler: failed to fetch meal plan", "id", mealPlanItem.MealPlanId)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.gate.CanViewMealPlan(c, mealPlan) {
+		h.gate.denyMealPlan(c, mealPlan)
+		return
+	}
+
+	// покупатель видит блюдо таким, каким оно было в его версии плана
+	mealPlan, err = h.versions.ResolveMealPlan(currentUser(c), mealPlan)
+	if errors.Is(err, service.ErrPlanNotPublished) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("handler: failed to resolve meal plan version", "id", mealPlanItem.MealPlanId, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	index := slices.IndexFunc(mealPlan.Meals, func(meal models.MealPlanItem) bool { return meal.ID == mealPlanItem.ID })
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "элемент плана не найден"})
+		return
+	}
+
 	h.logger.Info("handler: meal plan item fetch to successfully", "id", id)
-	c.JSON(http.StatusOK, mealPlanItem)
+	c.JSON(http.StatusOK, mealPlan.Meals[index])
 }
 
 // DeleteMealPlanItem godoc
@@ -166,9 +268,13 @@ func (h *MealPlanItemHandler) GetMealPlanItemById(c *gin.Context) {
 // @Description Удаляет MealPlanItem по ID
 // @Tags mealPlanItems
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID элемента"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /mealPlanItems/{id} [delete]
 func (h *MealPlanItemHandler) DeleteMealPlanItem(c *gin.Context) {
 	idStr := c.Param("id")
@@ -179,6 +285,10 @@ func (h *MealPlanItemHandler) DeleteMealPlanItem(c *gin.Context) {
 		return
 	}
 
+	if !h.guard.MealPlanItem(c, uint(id)) {
+		return
+	}
+
 	if err := h.mealPlanItems.DeleteMealPlanItem(uint(id)); err != nil {
 		h.logger.Error("handler: failed to delete meal plan item", "id", id)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ошибка при удалении"})
@@ -0,0 +1,144 @@
+package transport
+
+import (
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const currentUserKey = "currentUser"
+
+type AuthMiddleware struct {
+	auth   service.AuthService
+	policy service.AccessPolicy
+	log    *slog.Logger
+}
+
+func NewAuthMiddleware(auth service.AuthService, policy service.AccessPolicy, log *slog.Logger) *AuthMiddleware {
+	return &AuthMiddleware{auth: auth, policy: policy, log: log}
+}
+
+// RequireAuth пропускает запрос только с валидным access-токеном
+// и кладёт найденного пользователя в контекст запроса
+func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.authenticate(c) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// OptionalAuth авторизует запрос, если передан access-токен; без токена
+// запрос проходит анонимно. Недействительный токен по-прежнему даёт 401
+func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if bearerToken(c) != "" && !m.authenticate(c) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Require авторизует запрос и проверяет, что роль пользователя
+// имеет все перечисленные разрешения
+func (m *AuthMiddleware) Require(perms ...service.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if currentUser(c) == nil && !m.authenticate(c) {
+			return
+		}
+
+		for _, perm := range perms {
+			if !m.Can(c, perm) {
+				m.forbid(c, perm)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// Can проверяет разрешение у пользователя из контекста запроса
+func (m *AuthMiddleware) Can(c *gin.Context, perm service.Permission) bool {
+	user := currentUser(c)
+	if user == nil {
+		return false
+	}
+
+	return m.policy.Can(user.Role, perm)
+}
+
+func (m *AuthMiddleware) forbid(c *gin.Context, perm service.Permission) {
+	m.log.Warn("Недостаточно прав",
+		"user_id", currentUserID(c),
+		"path", c.FullPath(),
+		"permission", perm)
+	c.AbortWithStatusJSON(http.StatusForbidden, forbiddenResponse(perm))
+}
+
+func forbiddenResponse(perm service.Permission) gin.H {
+	return gin.H{
+		"error":      "forbidden",
+		"message":    "недостаточно прав для выполнения операции",
+		"permission": perm,
+	}
+}
+
+func (m *AuthMiddleware) authenticate(c *gin.Context) bool {
+	token := bearerToken(c)
+	if token == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "требуется авторизация",
+		})
+		return false
+	}
+
+	user, err := m.auth.Authenticate(token)
+	if err != nil {
+		m.log.Warn("Не удалось авторизовать запрос", "path", c.FullPath(), "error", err.Error())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": err.Error(),
+		})
+		return false
+	}
+
+	c.Set(currentUserKey, user)
+	return true
+}
+
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return ""
+	}
+
+	return strings.TrimSpace(header[7:])
+}
+
+// currentUser возвращает пользователя, которого положил в контекст RequireAuth
+func currentUser(c *gin.Context) *models.User {
+	value, ok := c.Get(currentUserKey)
+	if !ok {
+		return nil
+	}
+
+	user, _ := value.(*models.User)
+	return user
+}
+
+func currentUserID(c *gin.Context) uint {
+	if user := currentUser(c); user != nil {
+		return user.ID
+	}
+
+	return 0
+}
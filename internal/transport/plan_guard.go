@@ -0,0 +1,144 @@
+package transport
+
+import (
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlanGuard решает, может ли автор менять категорию и её планы. Администратор каталога
+// правит всё, тренер — только категории, которыми владеет, и планы внутри них.
+// Личный план клиента меняет только составивший его тренер или управляющий пользователями.
+// Методы при отказе сами пишут ответ, обработчику остаётся только выйти
+type PlanGuard struct {
+	categories    service.CategoryServices
+	exercisePlans service.ExercisePlanServices
+	mealPlans     service.MealPlanService
+	mealPlanItems service.MealPlanItemsService
+	auth          *AuthMiddleware
+	gate          *ContentGate
+	log           *slog.Logger
+}
+
+func NewPlanGuard(
+	categories service.CategoryServices,
+	exercisePlans service.ExercisePlanServices,
+	mealPlans service.MealPlanService,
+	mealPlanItems service.MealPlanItemsService,
+	auth *AuthMiddleware,
+	gate *ContentGate,
+	log *slog.Logger,
+) *PlanGuard {
+	return &PlanGuard{
+		categories:    categories,
+		exercisePlans: exercisePlans,
+		mealPlans:     mealPlans,
+		mealPlanItems: mealPlanItems,
+		auth:          auth,
+		gate:          gate,
+		log:           log,
+	}
+}
+
+// Category разрешает изменение категории администратору каталога и тренеру-владельцу
+func (g *PlanGuard) Category(c *gin.Context, categoryID uint) bool {
+	if g.auth.Can(c, service.PermManageCatalog) {
+		return true
+	}
+
+	category, err := g.categories.GetCategoryByID(categoryID)
+	if err != nil {
+		g.log.Warn("category not found", "category_id", categoryID, "error", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return false
+	}
+
+	if category.TrainerID == nil || *category.TrainerID != currentUserID(c) {
+		g.auth.forbid(c, service.PermManageCatalog)
+		return false
+	}
+
+	return true
+}
+
+// ExercisePlan разрешает изменение тренировочного плана и его упражнений
+func (g *PlanGuard) ExercisePlan(c *gin.Context, planID uint) bool {
+	plan, err := g.exercisePlans.GetPlanByIDNotPreloads(planID)
+	if err != nil {
+		g.log.Warn("exercise plan not found", "plan_id", planID, "error", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "план не найден"})
+		return false
+	}
+
+	if plan.ClientID != nil {
+		return g.private(c, plan.TrainerID)
+	}
+
+	return g.Category(c, plan.CategoriesID)
+}
+
+// ExercisePlanItem разрешает изменение упражнения, если можно менять его план
+func (g *PlanGuard) ExercisePlanItem(c *gin.Context, itemID uint) bool {
+	item, err := g.exercisePlans.GetByIDPlanItem(itemID)
+	if err != nil {
+		g.log.Warn("exercise plan item not found", "item_id", itemID, "error", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "элемент плана не найден"})
+		return false
+	}
+
+	return g.ExercisePlan(c, item.ExercisePlanID)
+}
+
+// MealPlan разрешает изменение плана питания и его блюд. План вне категории
+// не принадлежит ни одному тренеру, поэтому его правит только администратор
+func (g *PlanGuard) MealPlan(c *gin.Context, planID uint) bool {
+	plan, err := g.mealPlans.GetMealPlanByID(planID)
+	if err != nil {
+		g.log.Warn("meal plan not found", "plan_id", planID, "error", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "план не найден"})
+		return false
+	}
+
+	if plan.ClientID != nil {
+		return g.private(c, plan.TrainerID)
+	}
+
+	if g.auth.Can(c, service.PermManageCatalog) {
+		return true
+	}
+
+	if plan.CategoriesID == nil {
+		g.auth.forbid(c, service.PermManageCatalog)
+		return false
+	}
+
+	return g.Category(c, *plan.CategoriesID)
+}
+
+// MealPlanItem разрешает изменение блюда, если можно менять его план
+func (g *PlanGuard) MealPlanItem(c *gin.Context, itemID uint) bool {
+	item, err := g.mealPlanItems.GetMealPlanItemById(itemID)
+	if err != nil {
+		g.log.Warn("meal plan item not found", "item_id", itemID, "error", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "элемент плана не найден"})
+		return false
+	}
+
+	return g.MealPlan(c, item.MealPlanId)
+}
+
+// private разрешает изменение личного плана клиента его тренеру и управляющему пользователями;
+// остальным план не показывается вовсе, как и при чтении
+func (g *PlanGuard) private(c *gin.Context, trainerID *uint) bool {
+	if trainerID != nil && *trainerID == currentUserID(c) {
+		return true
+	}
+	if g.auth.Can(c, service.PermManageUsers) {
+		return true
+	}
+
+	g.gate.hidePrivate(c)
+	return false
+}
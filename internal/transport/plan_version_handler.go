@@ -0,0 +1,242 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlanVersionHandler struct {
+	versions service.PlanVersionService
+	auth     *AuthMiddleware
+	guard    *PlanGuard
+	log      *slog.Logger
+}
+
+func NewPlanVersionHandler(versions service.PlanVersionService, auth *AuthMiddleware, guard *PlanGuard, log *slog.Logger) *PlanVersionHandler {
+	return &PlanVersionHandler{versions: versions, auth: auth, guard: guard, log: log}
+}
+
+func (h *PlanVersionHandler) RegisterRoutes(r *gin.Engine) {
+	author := h.auth.Require(service.PermAuthorPlans)
+
+	r.POST("/plan/:id/publish", author, h.PublishExercisePlan)
+	r.GET("/plan/:id/versions", author, h.ExercisePlanVersions)
+	r.POST("/mealPlans/:id/publish", author, h.PublishMealPlan)
+	r.GET("/mealPlans/:id/versions", author, h.MealPlanVersions)
+
+	programmes := r.Group("/user/:id/programmes/:categoryID", h.auth.RequireAuth())
+	{
+		programmes.GET("/versions", h.ProgrammeVersions)
+		programmes.POST("/upgrade", h.UpgradeProgramme)
+	}
+}
+
+// PublishExercisePlan godoc
+// @Summary Опубликовать тренировочный план
+// @Description Сохраняет текущий черновик плана с упражнениями как новую неизменяемую версию. Купившие программу раньше продолжают видеть свою версию, пока сами не обновятся. Тренер публикует только планы своих категорий
+// @Tags PlanVersions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID плана"
+// @Param publish body models.PublishPlanRequest false "Что изменилось в версии"
+// @Success 201 {object} models.PlanVersion
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /plan/{id}/publish [post]
+func (h *PlanVersionHandler) PublishExercisePlan(c *gin.Context) {
+	h.publish(c, h.guard.ExercisePlan, h.versions.PublishExercisePlan)
+}
+
+// PublishMealPlan godoc
+// @Summary Опубликовать план питания
+// @Description Сохраняет текущий черновик плана с блюдами как новую неизменяемую версию. Купившие программу раньше продолжают видеть свою версию, пока сами не обновятся. Тренер публикует только планы своих категорий
+// @Tags PlanVersions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID плана питания"
+// @Param publish body models.PublishPlanRequest false "Что изменилось в версии"
+// @Success 201 {object} models.PlanVersion
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /mealPlans/{id}/publish [post]
+func (h *PlanVersionHandler) PublishMealPlan(c *gin.Context) {
+	h.publish(c, h.guard.MealPlan, h.versions.PublishMealPlan)
+}
+
+func (h *PlanVersionHandler) publish(
+	c *gin.Context,
+	allowed func(c *gin.Context, planID uint) bool,
+	publish func(planID, userID uint, req models.PublishPlanRequest) (*models.PlanVersion, error),
+) {
+	id, ok := h.pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	if !allowed(c, id) {
+		return
+	}
+
+	var req models.PublishPlanRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.log.Warn("Введены неверные данные", "err", err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+			return
+		}
+	}
+
+	version, err := publish(id, currentUserID(c), req)
+	if err != nil {
+		h.versionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, version)
+}
+
+// ExercisePlanVersions godoc
+// @Summary История версий тренировочного плана
+// @Tags PlanVersions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID плана"
+// @Success 200 {array} models.PlanVersion
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /plan/{id}/versions [get]
+func (h *PlanVersionHandler) ExercisePlanVersions(c *gin.Context) {
+	h.list(c, models.PlanKindExercise)
+}
+
+// MealPlanVersions godoc
+// @Summary История версий плана питания
+// @Tags PlanVersions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID плана питания"
+// @Success 200 {array} models.PlanVersion
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /mealPlans/{id}/versions [get]
+func (h *PlanVersionHandler) MealPlanVersions(c *gin.Context) {
+	h.list(c, models.PlanKindMeal)
+}
+
+func (h *PlanVersionHandler) list(c *gin.Context, kind string) {
+	id, ok := h.pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	versions, err := h.versions.Versions(kind, id)
+	if err != nil {
+		h.versionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// ProgrammeVersions godoc
+// @Summary Версии планов купленной программы
+// @Description Какие опубликованные версии планов видит покупатель и есть ли более новые
+// @Tags PlanVersions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param categoryID path int true "ID категории"
+// @Success 200 {object} models.ProgrammeVersions
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /user/{id}/programmes/{categoryID}/versions [get]
+func (h *PlanVersionHandler) ProgrammeVersions(c *gin.Context) {
+	h.programme(c, h.versions.ProgrammeVersions)
+}
+
+// UpgradeProgramme godoc
+// @Summary Обновить программу до последних версий планов
+// @Description Покупатель переходит на последние опубликованные версии всех планов программы; вернуться к прежним версиям нельзя
+// @Tags PlanVersions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param categoryID path int true "ID категории"
+// @Success 200 {object} models.ProgrammeVersions
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /user/{id}/programmes/{categoryID}/upgrade [post]
+func (h *PlanVersionHandler) UpgradeProgramme(c *gin.Context) {
+	h.programme(c, h.versions.UpgradeProgramme)
+}
+
+func (h *PlanVersionHandler) programme(c *gin.Context, load func(userID, categoryID uint) (*models.ProgrammeVersions, error)) {
+	userID, ok := h.pathID(c, "id")
+	if !ok {
+		return
+	}
+	if userID != currentUserID(c) && !h.auth.Can(c, service.PermManageUsers) {
+		h.log.Warn("Попытка доступа к чужому профилю",
+			"user_id", currentUserID(c),
+			"target_id", userID)
+		c.JSON(http.StatusForbidden, forbiddenResponse(service.PermManageUsers))
+		return
+	}
+
+	categoryID, ok := h.pathID(c, "categoryID")
+	if !ok {
+		return
+	}
+
+	result, err := load(userID, categoryID)
+	if err != nil {
+		h.versionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *PlanVersionHandler) pathID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil || id == 0 {
+		h.log.Warn("Некорректный ID", "param", param)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *PlanVersionHandler) versionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPlanNotFound),
+		errors.Is(err, service.ErrProgrammeNotOwned),
+		errors.Is(err, service.ErrPlanVersionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPlanPrivate):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.log.Error("Ошибка при работе с версиями плана", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,183 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromoHandler struct {
+	promos service.PromoService
+	auth   *AuthMiddleware
+	log    *slog.Logger
+}
+
+func NewPromoHandler(promos service.PromoService, auth *AuthMiddleware, log *slog.Logger) *PromoHandler {
+	return &PromoHandler{promos: promos, auth: auth, log: log}
+}
+
+func (h *PromoHandler) RegisterRoutes(r *gin.Engine) {
+	manage := h.auth.Require(service.PermManagePromos)
+
+	promo := r.Group("/promo")
+	{
+		promo.POST("/", manage, h.Create)
+		promo.GET("/", manage, h.List)
+		promo.PATCH("/:id", manage, h.Update)
+		promo.GET("/:id/redemptions", manage, h.Redemptions)
+		promo.POST("/quote", h.auth.RequireAuth(), h.Quote)
+	}
+}
+
+// Create godoc
+// @Summary Создать промокод
+// @Description Процентная (percent) или фиксированная (fixed) скидка. Без category_ids и subscription_ids действует на любые покупки. Доступно администраторам.
+// @Tags Promo
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param promo body models.CreatePromoCodeRequest true "Параметры промокода"
+// @Success 201 {object} models.PromoCode
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /promo/ [post]
+func (h *PromoHandler) Create(c *gin.Context) {
+	var req models.CreatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	promo, err := h.promos.Create(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promo)
+}
+
+// List godoc
+// @Summary Список промокодов
+// @Tags Promo
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.PromoCode
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /promo/ [get]
+func (h *PromoHandler) List(c *gin.Context) {
+	list, err := h.promos.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// Update godoc
+// @Summary Обновить промокод
+// @Description Позволяет отключить промокод, изменить срок действия и лимиты
+// @Tags Promo
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID промокода"
+// @Param promo body models.UpdatePromoCodeRequest true "Изменения"
+// @Success 200 {object} models.PromoCode
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /promo/{id} [patch]
+func (h *PromoHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return
+	}
+
+	var req models.UpdatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	promo, err := h.promos.Update(uint(id), req)
+	if err != nil {
+		h.promoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+// Redemptions godoc
+// @Summary Применения промокода
+// @Tags Promo
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID промокода"
+// @Success 200 {array} models.PromoRedemption
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /promo/{id}/redemptions [get]
+func (h *PromoHandler) Redemptions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return
+	}
+
+	list, err := h.promos.Redemptions(uint(id))
+	if err != nil {
+		h.promoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// Quote godoc
+// @Summary Проверить промокод
+// @Description Показывает цену категории или подписки с промокодом, не применяя его
+// @Tags Promo
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param quote body models.PromoQuoteRequest true "Промокод и покупка (target_type: category или subscription)"
+// @Success 200 {object} models.PromoQuote
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /promo/quote [post]
+func (h *PromoHandler) Quote(c *gin.Context) {
+	var req models.PromoQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	quote, err := h.promos.Quote(currentUserID(c), req)
+	if err != nil {
+		h.promoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func (h *PromoHandler) promoError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, service.ErrPromoNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecommendationHandler struct {
+	recommendations service.RecommendationService
+	auth            *AuthMiddleware
+	log             *slog.Logger
+}
+
+func NewRecommendationHandler(recommendations service.RecommendationService, auth *AuthMiddleware, log *slog.Logger) *RecommendationHandler {
+	return &RecommendationHandler{recommendations: recommendations, auth: auth, log: log}
+}
+
+func (h *RecommendationHandler) RegisterRoutes(r *gin.Engine) {
+	r.POST("/recommendations", h.auth.OptionalAuth(), h.Recommend)
+}
+
+// Recommend godoc
+// @Summary Подбор программы по анкете
+// @Description Оценивает категории по цели, уровню подготовки, инвентарю, числу тренировок в неделю и диете и объясняет каждую оценку. Цель без указания определяется по BMI. Для авторизованного пользователя BMI берётся из последнего замера, а диета и аллергены — из профиля, если их нет в анкете
+// @Tags Recommendations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param questionnaire body models.RecommendationRequest true "Анкета"
+// @Success 200 {object} models.RecommendationResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /recommendations [post]
+func (h *RecommendationHandler) Recommend(c *gin.Context) {
+	var req models.RecommendationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	result, err := h.recommendations.Recommend(currentUser(c), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
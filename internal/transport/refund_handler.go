@@ -0,0 +1,231 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandler struct {
+	refunds service.RefundService
+	auth    *AuthMiddleware
+	log     *slog.Logger
+}
+
+func NewRefundHandler(refunds service.RefundService, auth *AuthMiddleware, log *slog.Logger) *RefundHandler {
+	return &RefundHandler{refunds: refunds, auth: auth, log: log}
+}
+
+func (h *RefundHandler) RegisterRoutes(r *gin.Engine) {
+	manage := h.auth.Require(service.PermManageRefunds)
+
+	refunds := r.Group("/refunds", h.auth.RequireAuth())
+	{
+		refunds.POST("/", h.Create)
+		refunds.GET("/", h.List)
+		refunds.GET("/:id", h.GetByID)
+		refunds.POST("/:id/approve", manage, h.Approve)
+		refunds.POST("/:id/reject", manage, h.Reject)
+	}
+}
+
+// Create godoc
+// @Summary Запросить возврат
+// @Description Создает заявку на возврат покупки категории. Возврат доступен в течение REFUND_WINDOW_DAYS дней после покупки.
+// @Tags Refunds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param refund body models.CreateRefundRequest true "Категория и причина"
+// @Success 201 {object} models.RefundRequest
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /refunds/ [post]
+func (h *RefundHandler) Create(c *gin.Context) {
+	var req models.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	refund, err := h.refunds.Request(currentUserID(c), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, refund)
+}
+
+// List godoc
+// @Summary Список заявок на возврат
+// @Description Пользователь видит свои заявки, администратор — все и может фильтровать по user_id
+// @Tags Refunds
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "ID пользователя (только для администраторов)"
+// @Param category_id query int false "ID категории"
+// @Param status query string false "pending, approved или rejected"
+// @Success 200 {array} models.RefundRequest
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /refunds/ [get]
+func (h *RefundHandler) List(c *gin.Context) {
+	filter := models.RefundFilter{Status: c.Query("status")}
+
+	if value := c.Query("category_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный category_id"})
+			return
+		}
+		categoryID := uint(id)
+		filter.CategoryID = &categoryID
+	}
+
+	// обычный пользователь видит только свои заявки
+	userID := currentUserID(c)
+	filter.UserID = &userID
+	if h.auth.Can(c, service.PermManageRefunds) {
+		filter.UserID = nil
+		if value := c.Query("user_id"); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный user_id"})
+				return
+			}
+			userID = uint(id)
+			filter.UserID = &userID
+		}
+	}
+
+	refunds, err := h.refunds.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+// GetByID godoc
+// @Summary Заявка на возврат
+// @Tags Refunds
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID заявки"
+// @Success 200 {object} models.RefundRequest
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /refunds/{id} [get]
+func (h *RefundHandler) GetByID(c *gin.Context) {
+	id, ok := h.refundID(c)
+	if !ok {
+		return
+	}
+
+	refund, err := h.refunds.GetByID(id)
+	if err != nil || (refund.UserID != currentUserID(c) && !h.auth.Can(c, service.PermManageRefunds)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrRefundNotFound.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+// Approve godoc
+// @Summary Одобрить возврат
+// @Description Возвращает деньги на баланс пользователя и отзывает доступ к категории. Если при покупке применялся промокод, применение возвращается в его лимит. Доступно администраторам.
+// @Tags Refunds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID заявки"
+// @Param decision body models.RefundDecisionRequest false "Комментарий"
+// @Success 200 {object} models.RefundRequest
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /refunds/{id}/approve [post]
+func (h *RefundHandler) Approve(c *gin.Context) {
+	id, ok := h.refundID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RefundDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+			return
+		}
+	}
+
+	refund, err := h.refunds.Approve(currentUserID(c), id, req)
+	if err != nil {
+		h.decisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+// Reject godoc
+// @Summary Отклонить возврат
+// @Description Отклоняет заявку с указанием причины. Доступно администраторам.
+// @Tags Refunds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID заявки"
+// @Param decision body models.RefundDecisionRequest true "Причина отказа"
+// @Success 200 {object} models.RefundRequest
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /refunds/{id}/reject [post]
+func (h *RefundHandler) Reject(c *gin.Context) {
+	id, ok := h.refundID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RefundDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	refund, err := h.refunds.Reject(currentUserID(c), id, req)
+	if err != nil {
+		h.decisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+func (h *RefundHandler) refundID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *RefundHandler) decisionError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, service.ErrRefundNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -21,10 +21,11 @@ func NewReviewsHandler(review service.ReviewsService, log *slog.Logger) *Reviews
 
 // CreateReview godoc
 // @Summary Создать отзыв
-// @Description Создает новый отзыв пользователя о категории
+// @Description Создает новый отзыв авторизованного пользователя о категории
 // @Tags Reviews
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param review body models.CreateReviewRequest true "Данные отзыва"
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
@@ -42,15 +43,9 @@ func (h *ReviewsHandler) CreateReview(c *gin.Context) {
 		return
 	}
 
-	if req.UserID == 0 {
-		h.log.Warn("UserID не указан")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "UserID обязателен для создания отзыва",
-		})
-		return
-	}
+	userID := currentUserID(c)
 
-	reviewID, err := h.review.CreateReview(req, req.UserID)
+	reviewID, err := h.review.CreateReview(req, userID)
 	if err != nil {
 		h.log.Error("Ошибка при создании отзыва",
 			"error", err.Error())
@@ -63,7 +58,7 @@ func (h *ReviewsHandler) CreateReview(c *gin.Context) {
 
 	h.log.Info("Отзыв создан",
 		"review_id", reviewID,
-		"user_id", req.UserID)
+		"user_id", userID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":   "отзыв создан",
@@ -204,12 +199,12 @@ func (h *ReviewsHandler) GetReviewsByCategory(c *gin.Context) {
 
 // UpdateReview godoc
 // @Summary Обновить отзыв
-// @Description Обновляет отзыв по ID. Требуется user_id владельца.
+// @Description Обновляет отзыв по ID. Обновить можно только свой отзыв.
 // @Tags Reviews
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID отзыва"
-// @Param user_id query int false "ID пользователя-владельца"
 // @Param review body models.UpdateReviewRequest true "Данные для обновления"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
@@ -239,35 +234,9 @@ func (h *ReviewsHandler) UpdateReview(c *gin.Context) {
 		return
 	}
 
-	userIDStr := c.Query("user_id")
-	if userIDStr == "" {
-		var body struct {
-			UserID uint `json:"user_id"`
-		}
-		if err := c.ShouldBindJSON(&body); err == nil {
-			userIDStr = strconv.FormatUint(uint64(body.UserID), 10)
-		}
-	}
+	userID := currentUserID(c)
 
-	if userIDStr == "" {
-		h.log.Warn("UserID не указан")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "UserID обязателен для обновления отзыва",
-		})
-		return
-	}
-
-	userID, err := strconv.ParseUint(userIDStr, 10, 64)
-	if err != nil {
-		h.log.Warn("Некорректный UserID",
-			"user_id", userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "некорректный UserID",
-		})
-		return
-	}
-
-	err = h.review.UpdateReview(uint(id), req, uint(userID))
+	err = h.review.UpdateReview(uint(id), req, userID)
 	if err != nil {
 		h.log.Error("Ошибка при обновлении отзыва",
 			"id", id,
@@ -297,11 +266,11 @@ func (h *ReviewsHandler) UpdateReview(c *gin.Context) {
 
 // DeleteReview godoc
 // @Summary Удалить отзыв
-// @Description Удаляет отзыв по ID. Требуется user_id владельца.
+// @Description Удаляет отзыв по ID. Удалить можно только свой отзыв.
 // @Tags Reviews
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID отзыва"
-// @Param user_id query int true "ID пользователя-владельца"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 403 {object} map[string]string
@@ -320,26 +289,9 @@ func (h *ReviewsHandler) DeleteReview(c *gin.Context) {
 		return
 	}
 
-	userIDStr := c.Query("user_id")
-	if userIDStr == "" {
-		h.log.Warn("UserID не указан")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "UserID обязателен для удаления отзыва",
-		})
-		return
-	}
-
-	userID, err := strconv.ParseUint(userIDStr, 10, 64)
-	if err != nil {
-		h.log.Warn("Некорректный UserID",
-			"user_id", userIDStr)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "некорректный UserID",
-		})
-		return
-	}
+	userID := currentUserID(c)
 
-	err = h.review.DeleteReview(uint(id), uint(userID))
+	err = h.review.DeleteReview(uint(id), userID)
 	if err != nil {
 		h.log.Error("Ошибка при удалении отзыва",
 			"id", id,
@@ -367,15 +319,15 @@ func (h *ReviewsHandler) DeleteReview(c *gin.Context) {
 	})
 }
 
-func (h *ReviewsHandler) RegisterRoutes(r *gin.Engine) {
+func (h *ReviewsHandler) RegisterRoutes(r *gin.Engine, auth *AuthMiddleware) {
 
 	reviews := r.Group("/reviews")
 	{
-		reviews.POST("", h.CreateReview)
+		reviews.POST("", auth.RequireAuth(), h.CreateReview)
 		reviews.GET("/:id", h.GetReview)
 		reviews.GET("/user/:userID", h.GetReviewsByUser)
 		reviews.GET("/category/:categoryID", h.GetReviewsByCategory)
-		reviews.PUT("/:id", h.UpdateReview)
-		reviews.DELETE("/:id", h.DeleteReview)
+		reviews.PUT("/:id", auth.RequireAuth(), h.UpdateReview)
+		reviews.DELETE("/:id", auth.RequireAuth(), h.DeleteReview)
 	}
 }
@@ -15,26 +15,78 @@ func RegisterRoutes(
 	mealPlan service.MealPlanService,
 	mealPlanItem service.MealPlanItemsService,
 	user service.UserService,
+	ledger service.LedgerService,
+	subscriptions service.SubscriptionLifecycle,
 	sub service.SubscriptionService,
 	reviews service.ReviewsService,
+	topUps service.TopUpService,
+	refunds service.RefundService,
+	promos service.PromoService,
+	gifts service.GiftService,
+	workouts service.WorkoutService,
+	body service.BodyMetricsService,
+	foods service.FoodService,
+	exercises service.ExerciseLibraryService,
+	recommendations service.RecommendationService,
+	planVersions service.PlanVersionService,
+	trainers service.TrainerService,
+	coaching service.CoachingService,
+	idempotency service.IdempotencyService,
+	fakePayments *service.FakePaymentProvider,
+	auth service.AuthService,
+	policy service.AccessPolicy,
+	entitlements service.EntitlementService,
 ) {
 
-	subHandler := NewSubscriptionHandler(sub, log)
-	categoryHandler := NewCategoryHandler(category, log)
-	planHandler := NewExercisePlanHandler(plan, log)
+	authMiddleware := NewAuthMiddleware(auth, policy, log)
+	idempotencyMiddleware := NewIdempotencyMiddleware(idempotency, log)
+	contentGate := NewContentGate(entitlements, log)
+	planGuard := NewPlanGuard(category, plan, mealPlan, mealPlanItem, authMiddleware, contentGate, log)
+
+	subHandler := NewSubscriptionHandler(sub, authMiddleware, log)
+	categoryHandler := NewCategoryHandler(category, planVersions, authMiddleware, contentGate, planGuard, log)
+	planHandler := NewExercisePlanHandler(plan, planVersions, authMiddleware, contentGate, planGuard, log)
 	bmiHand := NewBmiHandler(log)
-	userHandler := NewUserHandler(user, log)
-	mealPlanHandler := NewMealPlanHandler(mealPlan, log)
-	mealPlanItemHandler := NewMealPlanItemHandler(mealPlanItem, log)
+	calculatorHandler := NewCalculatorHandler(log)
+	userHandler := NewUserHandler(user, ledger, subscriptions, authMiddleware, idempotencyMiddleware, log)
+	mealPlanHandler := NewMealPlanHandler(mealPlan, planVersions, authMiddleware, contentGate, planGuard, log)
+	mealPlanItemHandler := NewMealPlanItemHandler(mealPlanItem, mealPlan, planVersions, authMiddleware, contentGate, planGuard, log)
 	reviewsHandler := NewReviewsHandler(reviews, log)
+	authHandler := NewAuthHandler(auth, log)
+	walletHandler := NewWalletHandler(topUps, fakePayments, authMiddleware, log)
+	refundHandler := NewRefundHandler(refunds, authMiddleware, log)
+	promoHandler := NewPromoHandler(promos, authMiddleware, log)
+	giftHandler := NewGiftHandler(gifts, authMiddleware, idempotencyMiddleware, log)
+	workoutHandler := NewWorkoutHandler(workouts, authMiddleware, log)
+	bodyHandler := NewBodyHandler(body, authMiddleware, log)
+	foodHandler := NewFoodHandler(foods, authMiddleware, log)
+	exerciseHandler := NewExerciseLibraryHandler(exercises, authMiddleware, log)
+	recommendationHandler := NewRecommendationHandler(recommendations, authMiddleware, log)
+	planVersionHandler := NewPlanVersionHandler(planVersions, authMiddleware, planGuard, log)
+	trainerHandler := NewTrainerHandler(trainers, authMiddleware, log)
+	coachingHandler := NewCoachingHandler(coaching, authMiddleware, log)
 
 	mealPlanHandler.RegisterRoutes(router)
 	mealPlanItemHandler.RegisterRoutes(router)
 	categoryHandler.RegisterRoutes(router)
 	planHandler.RegisterRoutes(router)
 	bmiHand.RegisterRoutes(router)
+	calculatorHandler.RegisterRoutes(router)
 	userHandler.UserRoutes(router)
 	subHandler.RegisterRoutes(router)
-	reviewsHandler.RegisterRoutes(router)
+	reviewsHandler.RegisterRoutes(router, authMiddleware)
+	authHandler.RegisterRoutes(router)
+	walletHandler.RegisterRoutes(router)
+	refundHandler.RegisterRoutes(router)
+	promoHandler.RegisterRoutes(router)
+	giftHandler.RegisterRoutes(router)
+	workoutHandler.RegisterRoutes(router)
+	bodyHandler.RegisterRoutes(router)
+	foodHandler.RegisterRoutes(router)
+	exerciseHandler.RegisterRoutes(router)
+	recommendationHandler.RegisterRoutes(router)
+	planVersionHandler.RegisterRoutes(router)
+	trainerHandler.RegisterRoutes(router)
+	coachingHandler.RegisterRoutes(router)
 
 }
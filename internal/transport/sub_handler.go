@@ -21,34 +21,37 @@ type SubscriptionResponse struct {
 }
 
 type SubscriptionHandler struct {
-	sub service.SubscriptionService
-	log *slog.Logger
+	sub  service.SubscriptionService
+	auth *AuthMiddleware
+	log  *slog.Logger
 }
 
-func NewSubscriptionHandler(sub service.SubscriptionService, log *slog.Logger) *SubscriptionHandler {
+func NewSubscriptionHandler(sub service.SubscriptionService, auth *AuthMiddleware, log *slog.Logger) *SubscriptionHandler {
 	return &SubscriptionHandler{
-		sub: sub,
-		log: log,
+		sub:  sub,
+		auth: auth,
+		log:  log,
 	}
 }
 
 func (h *SubscriptionHandler) RegisterRoutes(r *gin.Engine) {
 	subGroup := r.Group("/sub")
 	{
-		subGroup.POST("/", h.CreateSub)
+		subGroup.POST("/", h.auth.Require(service.PermManageCatalog, service.PermEditPricing), h.CreateSub)
 		subGroup.GET("/", h.GetListSub)
 		subGroup.GET("/:id", h.GetByID)
-		subGroup.PATCH("/:id", h.Update)
-		subGroup.DELETE("/:id", h.Delete)
+		subGroup.PATCH("/:id", h.auth.Require(service.PermEditCatalog), h.Update)
+		subGroup.DELETE("/:id", h.auth.Require(service.PermManageCatalog), h.Delete)
 	}
 }
 
 // CreateSub godoc
 // @Summary Создать подписку
-// @Description Создает новую подписку
+// @Description Создает новую подписку. Доступно администраторам.
 // @Tags Subscription
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param subscription body models.CreateSubscriptionRequest true "Данные для создания подписки"
 // @Success 200 {object} SubscriptionResponse
 // @Failure 400 {object} map[string]string
@@ -125,10 +128,11 @@ func (h *SubscriptionHandler) GetListSub(r *gin.Context) {
 
 // Update godoc
 // @Summary Обновить подписку
-// @Description Обновляет подписку по ID
+// @Description Обновляет подписку по ID. Изменять цену могут только администраторы.
 // @Tags Subscription
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID подписки"
 // @Param subscription body models.UpdateSubscriptionRequest true "Данные для обновления подписки"
 // @Success 200 {object} SubscriptionResponse
@@ -150,6 +154,11 @@ func (h *SubscriptionHandler) Update(r *gin.Context) {
 		return
 	}
 
+	if upSub.Price != nil && !h.auth.Can(r, service.PermEditPricing) {
+		h.auth.forbid(r, service.PermEditPricing)
+		return
+	}
+
 	sub, err := h.sub.UpdateSub(uint(id), upSub)
 	if err != nil {
 		h.log.Error("error type update values")
@@ -163,9 +172,10 @@ func (h *SubscriptionHandler) Update(r *gin.Context) {
 
 // Delete godoc
 // @Summary Удалить подписку
-// @Description Удаляет подписку по ID
+// @Description Удаляет подписку по ID. Доступно администраторам.
 // @Tags subscription
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID подписки"
 // @Success 200 {object} map[string]bool
 // @Failure 400 {object} map[string]string
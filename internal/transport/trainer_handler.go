@@ -0,0 +1,316 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrainerHandler struct {
+	trainers service.TrainerService
+	auth     *AuthMiddleware
+	log      *slog.Logger
+}
+
+func NewTrainerHandler(trainers service.TrainerService, auth *AuthMiddleware, log *slog.Logger) *TrainerHandler {
+	return &TrainerHandler{trainers: trainers, auth: auth, log: log}
+}
+
+func (h *TrainerHandler) RegisterRoutes(r *gin.Engine) {
+	signedIn := h.auth.RequireAuth()
+
+	trainers := r.Group("/trainers")
+	{
+		trainers.POST("/", h.auth.Require(service.PermAuthorPlans), h.CreateProfile)
+		trainers.GET("/", h.ListProfiles)
+		trainers.GET("/:id", h.GetProfile)
+		trainers.PATCH("/:id", signedIn, h.UpdateProfile)
+		trainers.PUT("/:id/commission", h.auth.Require(service.PermEditPricing), h.SetCommission)
+		trainers.GET("/:id/dashboard", signedIn, h.Dashboard)
+		trainers.GET("/:id/payouts", signedIn, h.Payouts)
+		trainers.POST("/:id/payouts", h.auth.Require(service.PermAdjustBalances), h.CreatePayout)
+	}
+}
+
+// CreateProfile godoc
+// @Summary Создать профиль тренера
+// @Description Создаёт публичный профиль текущего пользователя. Администратор может назначить тренера с профилем владельцем категории, и тогда тренер получает долю от её продаж
+// @Tags Trainers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param profile body models.CreateTrainerProfileRequest true "Профиль"
+// @Success 201 {object} models.TrainerProfile
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /trainers/ [post]
+func (h *TrainerHandler) CreateProfile(c *gin.Context) {
+	var req models.CreateTrainerProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	profile, err := h.trainers.CreateProfile(currentUserID(c), req)
+	if err != nil {
+		h.trainerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, profile)
+}
+
+// ListProfiles godoc
+// @Summary Список тренеров
+// @Tags Trainers
+// @Produce json
+// @Success 200 {array} models.TrainerProfile
+// @Failure 500 {object} map[string]string
+// @Router /trainers/ [get]
+func (h *TrainerHandler) ListProfiles(c *gin.Context) {
+	profiles, err := h.trainers.ListProfiles()
+	if err != nil {
+		h.trainerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profiles)
+}
+
+// GetProfile godoc
+// @Summary Профиль тренера
+// @Description Публичный профиль тренера вместе с его категориями
+// @Tags Trainers
+// @Produce json
+// @Param id path int true "ID пользователя-тренера"
+// @Success 200 {object} models.TrainerProfile
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /trainers/{id} [get]
+func (h *TrainerHandler) GetProfile(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	profile, err := h.trainers.GetProfile(id)
+	if err != nil {
+		h.trainerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateProfile godoc
+// @Summary Обновить профиль тренера
+// @Description Свой профиль может менять сам тренер, чужой — администратор
+// @Tags Trainers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя-тренера"
+// @Param profile body models.UpdateTrainerProfileRequest true "Изменяемые поля"
+// @Success 200 {object} models.TrainerProfile
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /trainers/{id} [patch]
+func (h *TrainerHandler) UpdateProfile(c *gin.Context) {
+	id, ok := h.ownTrainerID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateTrainerProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	profile, err := h.trainers.UpdateProfile(id, req)
+	if err != nil {
+		h.trainerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// SetCommission godoc
+// @Summary Индивидуальная комиссия тренера
+// @Description Комиссия платформы в процентах с продаж категорий тренера. commission_percent = null возвращает общую комиссию. Действует на продажи после изменения
+// @Tags Trainers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя-тренера"
+// @Param commission body models.SetCommissionRequest true "Комиссия"
+// @Success 200 {object} models.TrainerProfile
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /trainers/{id}/commission [put]
+func (h *TrainerHandler) SetCommission(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SetCommissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	profile, err := h.trainers.SetCommission(id, req)
+	if err != nil {
+		h.trainerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// Dashboard godoc
+// @Summary Кабинет тренера
+// @Description Продажи и подарки по категориям тренера, активные подписчики, заработок за вычетом комиссии и возвратов, выплаченное и долг платформы перед тренером
+// @Tags Trainers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя-тренера"
+// @Success 200 {object} models.TrainerDashboard
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /trainers/{id}/dashboard [get]
+func (h *TrainerHandler) Dashboard(c *gin.Context) {
+	id, ok := h.ownTrainerID(c)
+	if !ok {
+		return
+	}
+
+	dashboard, err := h.trainers.Dashboard(id)
+	if err != nil {
+		h.trainerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// Payouts godoc
+// @Summary Выплаты тренеру
+// @Tags Trainers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя-тренера"
+// @Success 200 {array} models.TrainerPayout
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /trainers/{id}/payouts [get]
+func (h *TrainerHandler) Payouts(c *gin.Context) {
+	id, ok := h.ownTrainerID(c)
+	if !ok {
+		return
+	}
+
+	payouts, err := h.trainers.Payouts(id)
+	if err != nil {
+		h.trainerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payouts)
+}
+
+// CreatePayout godoc
+// @Summary Провести выплату тренеру
+// @Description Отмечает, что платформа перевела тренеру деньги вне системы. Сумма не может превышать долг платформы перед тренером
+// @Tags Trainers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя-тренера"
+// @Param payout body models.CreatePayoutRequest true "Выплата"
+// @Success 201 {object} models.TrainerPayout
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /trainers/{id}/payouts [post]
+func (h *TrainerHandler) CreatePayout(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreatePayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	payout, err := h.trainers.CreatePayout(currentUserID(c), id, req)
+	if err != nil {
+		h.trainerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payout)
+}
+
+func (h *TrainerHandler) pathID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ownTrainerID разрешает тренеру доступ к своему профилю, а администратору — к любому
+func (h *TrainerHandler) ownTrainerID(c *gin.Context) (uint, bool) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return 0, false
+	}
+
+	if id != currentUserID(c) && !h.auth.Can(c, service.PermManageUsers) {
+		h.log.Warn("Попытка доступа к чужому профилю тренера",
+			"user_id", currentUserID(c),
+			"target_id", id)
+		c.JSON(http.StatusForbidden, forbiddenResponse(service.PermManageUsers))
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (h *TrainerHandler) trainerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTrainerNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTrainerProfileExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"errors"
 	"healthy_body/internal/models"
 	"healthy_body/internal/service"
 	"log/slog"
@@ -11,49 +12,38 @@ import (
 )
 
 type UserHandler struct {
-	user service.UserService
-	log  *slog.Logger
+	user          service.UserService
+	ledger        service.LedgerService
+	subscriptions service.SubscriptionLifecycle
+	auth          *AuthMiddleware
+	idempotency   *IdempotencyMiddleware
+	log           *slog.Logger
 }
 
-func NewUserHandler(user service.UserService, log *slog.Logger) *UserHandler {
-	return &UserHandler{user: user, log: log}
-}
-
-// Create godoc
-// @Summary Создать пользователя
-// @Description Создает нового пользователя
-// @Tags User
-// @Accept json
-// @Produce json
-// @Param user body models.CreateUserRequest true "Данные пользователя"
-// @Success 201 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /user/ [post]
-func (h *UserHandler) Create(c *gin.Context) {
-	var user models.CreateUserRequest
-	if err := c.ShouldBindJSON(&user); err != nil {
-		h.log.Warn("Введены неверные данные", "err", err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
-		return
-	}
-
-	result, err := h.user.CreateUser(user)
-	if err != nil {
-		h.log.Error("Ошибка при создании пользователя", "error", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "message": "ошибка при создании пользователя"})
-		return
+func NewUserHandler(
+	user service.UserService,
+	ledger service.LedgerService,
+	subscriptions service.SubscriptionLifecycle,
+	auth *AuthMiddleware,
+	idempotency *IdempotencyMiddleware,
+	log *slog.Logger,
+) *UserHandler {
+	return &UserHandler{
+		user:          user,
+		ledger:        ledger,
+		subscriptions: subscriptions,
+		auth:          auth,
+		idempotency:   idempotency,
+		log:           log,
 	}
-
-	h.log.Info("Пользователь создан", "имя", user.Name)
-	c.JSON(http.StatusCreated, gin.H{"message": "пользователь создан", "users": result})
 }
 
 // GetAllUser godoc
 // @Summary Получить всех пользователей
-// @Description Возвращает список всех пользователей
+// @Description Возвращает список всех пользователей. Доступно администраторам.
 // @Tags User
 // @Produce json
+// @Security BearerAuth
 // @Success 200 {array} map[string]interface{}
 // @Failure 500 {object} map[string]string
 // @Router /user/ [get]
@@ -74,21 +64,19 @@ func (h *UserHandler) GetAllUser(c *gin.Context) {
 // @Description Возвращает пользователя по указанному ID
 // @Tags User
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID пользователя"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /user/{id} [get]
 func (h *UserHandler) GetUserByID(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		h.log.Warn("Некорректный ID")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+	id, ok := h.ownUserID(c, "id")
+	if !ok {
 		return
 	}
 
-	result, err := h.user.GetUserByID(uint(id))
+	result, err := h.user.GetUserByID(id)
 	if err != nil {
 		h.log.Error("Ошибка при поиске пользователя по ID")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "message": "Ошибка при поиске пользователя по ID"})
@@ -105,6 +93,7 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 // @Tags User
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID пользователя"
 // @Param user body models.UpdateUserRequest true "Данные для обновления"
 // @Success 200 {object} map[string]interface{}
@@ -118,15 +107,12 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		h.log.Warn("Некорректный ID")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+	id, ok := h.ownUserID(c, "id")
+	if !ok {
 		return
 	}
 
-	result, err := h.user.UpdateUser(uint(id), req)
+	result, err := h.user.UpdateUser(id, req)
 	if err != nil {
 		h.log.Error("ошибка при обновлении пользователя", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -142,20 +128,18 @@ func (h *UserHandler) Update(c *gin.Context) {
 // @Description Удаляет пользователя по ID
 // @Tags User
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID пользователя"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Router /user/{id} [delete]
 func (h *UserHandler) Delete(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		h.log.Warn("Некорректный ID")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+	id, ok := h.ownUserID(c, "id")
+	if !ok {
 		return
 	}
 
-	if err := h.user.Delete(uint(id)); err != nil {
+	if err := h.user.Delete(id); err != nil {
 		h.log.Error("Ошибка при удалении пользователя", "error", err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -167,43 +151,46 @@ func (h *UserHandler) Delete(c *gin.Context) {
 
 // Payment godoc
 // @Summary Оплата пользователем
-// @Description Пользователь оплачивает выбранную категорию
+// @Description Авторизованный пользователь оплачивает выбранную категорию, при необходимости с промокодом
 // @Tags User
 // @Produce json
-// @Param userID path int true "ID пользователя"
+// @Security BearerAuth
 // @Param categoryID path int true "ID категории"
+// @Param purchase body models.PurchaseRequest false "Промокод"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернёт сохранённый ответ"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
-// @Router /user/payment/{userID}/{categoryID} [post]
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /user/payment/{categoryID} [post]
 func (h *UserHandler) Payment(c *gin.Context) {
-	userIDstr := c.Param("userID")
 	categoryIDstr := c.Param("categoryID")
 
-	userID, err := strconv.ParseUint(userIDstr, 10, 64)
+	categoryID, err := strconv.ParseUint(categoryIDstr, 10, 64)
 
 	if err != nil {
-		h.log.Error("Ошибка при получении ID пользователя")
+		h.log.Error("Ошибка при получении ID категории")
 		c.JSON(http.StatusBadRequest, gin.H{
-			"err": err,
+			"err": err.Error(),
 		})
 		return
 	}
 
-	categoryID, err := strconv.ParseUint(categoryIDstr, 10, 64)
-
-	if err != nil {
-		h.log.Error("Ошибка при получении ID категории")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"err": err,
-		})
+	req, ok := h.purchaseRequest(c)
+	if !ok {
 		return
 	}
 
-	if err := h.user.Payment(uint(userID), uint(categoryID)); err != nil {
+	if err := h.user.Payment(currentUserID(c), uint(categoryID), req.PromoCode); err != nil {
 		h.log.Error("Ошибка при оплате",
 			"error", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err,
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrProgrammeOwned) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
@@ -216,36 +203,29 @@ func (h *UserHandler) Payment(c *gin.Context) {
 
 // PaymentToAnother godoc
 // @Summary Оплата другому пользователю
-// @Description Пользователь оплачивает категорию другому пользователю
+// @Description Авторизованный пользователь дарит категорию другому пользователю. Доступ откроется, когда получатель примет подарок; см. /gifts
 // @Tags User
 // @Produce json
-// @Param userID path int true "ID пользователя"
+// @Security BearerAuth
 // @Param categoryID path int true "ID категории"
 // @Param secondUserID path int true "ID второго пользователя"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернёт сохранённый ответ"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
-// @Router /user/present/{userID}/{categoryID}/{secondUserID} [post]
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /user/present/{categoryID}/{secondUserID} [post]
 func (h *UserHandler) PaymentToAnother(c *gin.Context) {
-	userIDstr := c.Param("userID")
 	categoryIDstr := c.Param("categoryID")
 	secondUserIDstr := c.Param("secondUserID")
 
-	userID, err := strconv.ParseUint(userIDstr, 10, 64)
-
-	if err != nil {
-		h.log.Error("Ошибка при получении ID второго пользователя")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"err": err,
-		})
-		return
-	}
-
 	categoryID, err := strconv.ParseUint(categoryIDstr, 10, 64)
 
 	if err != nil {
 		h.log.Error("Ошибка при получении ID категории")
 		c.JSON(http.StatusBadRequest, gin.H{
-			"err": err,
+			"err": err.Error(),
 		})
 		return
 	}
@@ -253,51 +233,45 @@ func (h *UserHandler) PaymentToAnother(c *gin.Context) {
 	secondUserID, err := strconv.ParseUint(secondUserIDstr, 10, 64)
 
 	if err != nil {
-		h.log.Error("Ошибка при получении ID пользователя")
+		h.log.Error("Ошибка при получении ID второго пользователя")
 		c.JSON(http.StatusBadRequest, gin.H{
-			"err": err,
+			"err": err.Error(),
 		})
 		return
 	}
 
-	if err := h.user.PaymentToAnother(uint(userID), uint(categoryID), uint(secondUserID)); err != nil {
+	if err := h.user.PaymentToAnother(currentUserID(c), uint(categoryID), uint(secondUserID)); err != nil {
 		h.log.Error("Ошибка при оплате",
 			"error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err,
+			"error": err.Error(),
 		})
 		return
 	}
 
-	h.log.Info("Оплата прошла успешно")
+	h.log.Info("Подарок отправлен")
 	c.JSON(http.StatusOK, gin.H{
-		"message": "оплата прошла успешно",
+		"message": "подарок отправлен, получатель должен его принять",
 	})
 }
 
 // GetUserWithPlan godoc
-// @Summary Получить пользователя с планом питания
-// @Description Возвращает пользователя вместе с его планом питания
+// @Summary Программы пользователя с планами
+// @Description Возвращает все купленные, подаренные и доступные по подписке программы с планами тренировок и питания
 // @Tags User
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID пользователя"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {array} models.OwnedProgramme
 // @Failure 400 {object} map[string]string
 // @Router /user/plan/{id} [get]
 func (h *UserHandler) GetUserWithPlan(c *gin.Context) {
-	idStr := c.Param("id")
-
-	id, err := strconv.ParseUint(idStr, 10, 64)
-
-	if err != nil {
-		h.log.Warn("Некорректный ID")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "некорректный ID",
-		})
+	id, ok := h.ownUserID(c, "id")
+	if !ok {
 		return
 	}
 
-	user, err := h.user.GetUserPlan(uint(id))
+	user, err := h.user.GetUserPlan(id)
 	if err != nil {
 		h.log.Error("Ошибка при удалении пользователя",
 			"error", err.Error())
@@ -311,27 +285,22 @@ func (h *UserHandler) GetUserWithPlan(c *gin.Context) {
 }
 
 // GetUserCategory godoc
-// @Summary Получить категорию/планы пользователя
-// @Description Возвращает пользователя с предзагруженными планами и категориями
+// @Summary Программы пользователя
+// @Description Возвращает все программы пользователя с датой покупки и источником (purchase, gift, subscription); active отмечает активную программу
 // @Tags User
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID пользователя"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {array} models.OwnedProgramme
 // @Failure 400 {object} map[string]string
 // @Router /user/userplans/{id} [get]
 func (h *UserHandler) GetUserCategory(c *gin.Context) {
-	idStr := c.Param("id")
-	userID, err := strconv.ParseUint(idStr, 10, 64)
-
-	if err != nil {
-		h.log.Warn("Ошибка при вводе ID")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"err": err,
-		})
+	userID, ok := h.ownUserID(c, "id")
+	if !ok {
 		return
 	}
 
-	user, err := h.user.GetUserCategory(uint(userID))
+	user, err := h.user.GetUserCategory(userID)
 	if err != nil {
 		h.log.Error("Ошибка при получении ID пользователя")
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -343,28 +312,98 @@ func (h *UserHandler) GetUserCategory(c *gin.Context) {
 	c.IndentedJSON(http.StatusOK, user)
 }
 
+// SetActiveProgramme godoc
+// @Summary Сменить активную программу
+// @Description Делает активной одну из программ, которыми владеет пользователь
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param programme body models.SetActiveProgrammeRequest true "ID категории"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /user/{id}/active-programme [patch]
+func (h *UserHandler) SetActiveProgramme(c *gin.Context) {
+	userID, ok := h.ownUserID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.SetActiveProgrammeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	user, err := h.user.SetActiveProgramme(userID, req.CategoriesID)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrProgrammeNotOwned) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetDietaryPreferences godoc
+// @Summary Задать пищевые предпочтения
+// @Description Диеты (vegetarian, vegan, halal, gluten_free, lactose_free) и аллергены, которых пользователь избегает. Планы питания, которые им не подходят, получают предупреждения.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param preferences body models.DietaryPreferences true "Диеты и аллергены"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /user/{id}/dietary-preferences [put]
+func (h *UserHandler) SetDietaryPreferences(c *gin.Context) {
+	userID, ok := h.ownUserID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.DietaryPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	user, err := h.user.SetDietaryPreferences(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
 // GetUserSubs godoc
 // @Summary Получить подписки пользователя
 // @Description Возвращает все подписки пользователя
 // @Tags User
 // @Produce json
+// @Security BearerAuth
 // @Param userID path int true "ID пользователя"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Router /user/usersub/{userID} [get]
 func (h *UserHandler) GetUserSubs(c *gin.Context) {
-	userIDstr := c.Param("userID")
-	userID, err := strconv.ParseUint(userIDstr, 10, 64)
-
-	if err != nil {
-		h.log.Warn("Ошибка при вводе ID")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"err": err,
-		})
+	userID, ok := h.ownUserID(c, "userID")
+	if !ok {
 		return
 	}
 
-	user, err := h.user.GetUserSub(uint(userID))
+	user, err := h.user.GetUserSub(userID)
 	if err != nil {
 		h.log.Error("Ошибка при получении ID пользователя")
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -378,38 +417,38 @@ func (h *UserHandler) GetUserSubs(c *gin.Context) {
 
 // SubPayment godoc
 // @Summary Оплата подписки пользователем
-// @Description Пользователь оплачивает выбранную подписку
+// @Description Авторизованный пользователь оплачивает выбранную подписку, при необходимости с промокодом
 // @Tags User
 // @Produce json
-// @Param userID path int true "ID пользователя"
-// @Param subID path int true "ID подписки"
+// @Security BearerAuth
+// @Param id path int true "ID подписки из каталога"
+// @Param purchase body models.PurchaseRequest false "Промокод"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернёт сохранённый ответ"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
-// @Router /user/sub/{userID}/{subID} [post]
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /user/sub/{id} [post]
 func (h *UserHandler) SubPayment(c *gin.Context) {
-	userIDstr := c.Param("userID")
-	userID, err := strconv.ParseUint(userIDstr, 10, 64)
-	if err != nil {
-		h.log.Warn("Ошибка при вводе ID")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"err": err,
-		})
-		return
-	}
-
-	subIDstr := c.Param("subID")
+	subIDstr := c.Param("id")
 	subID, err := strconv.ParseUint(subIDstr, 10, 64)
 	if err != nil {
 		h.log.Warn("Ошибка при вводе ID подписки")
 		c.JSON(http.StatusBadRequest, gin.H{
-			"err": err,
+			"err": err.Error(),
 		})
 		return
 	}
-	if err := h.user.SubPayment(uint(userID), uint(subID)); err != nil {
+	req, ok := h.purchaseRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.user.SubPayment(currentUserID(c), uint(subID), req.PromoCode); err != nil {
 		h.log.Error("Ошибка при оплате подписки")
 		c.JSON(http.StatusBadRequest, gin.H{
-			"err": err,
+			"err": err.Error(),
 		})
 		return
 	}
@@ -419,19 +458,350 @@ func (h *UserHandler) SubPayment(c *gin.Context) {
 	})
 }
 
+// CancelSub godoc
+// @Summary Отменить подписку
+// @Description Отключает продление: подписка остаётся активной до конца оплаченного периода
+// @Tags User
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подписки пользователя"
+// @Success 200 {object} models.UserSubscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /user/sub/{id}/cancel [post]
+// @Router /user/subscriptions/{userSubscriptionID}/cancel [post]
+func (h *UserHandler) CancelSub(c *gin.Context) {
+	id, ok := h.userSubID(c)
+	if !ok {
+		return
+	}
+
+	userSub, err := h.subscriptions.Cancel(currentUserID(c), id)
+	if err != nil {
+		h.subscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, userSub)
+}
+
+// RenewSub godoc
+// @Summary Продлить подписку
+// @Description Списывает стоимость ещё одного периода и продлевает подписку; истёкшая подписка возобновляется с текущего момента
+// @Tags User
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подписки пользователя"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернёт сохранённый ответ"
+// @Success 200 {object} models.UserSubscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /user/sub/{id}/renew [post]
+// @Router /user/subscriptions/{userSubscriptionID}/renew [post]
+func (h *UserHandler) RenewSub(c *gin.Context) {
+	id, ok := h.userSubID(c)
+	if !ok {
+		return
+	}
+
+	userSub, err := h.subscriptions.Renew(currentUserID(c), id)
+	if err != nil {
+		h.subscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, userSub)
+}
+
+// SetAutoRenew godoc
+// @Summary Включить или выключить автопродление
+// @Description При включённом автопродлении подписка продлевается за счёт баланса в момент окончания периода
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подписки пользователя"
+// @Param body body models.AutoRenewRequest true "Автопродление"
+// @Success 200 {object} models.UserSubscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /user/sub/{id}/auto-renew [patch]
+// @Router /user/subscriptions/{userSubscriptionID}/auto-renew [patch]
+func (h *UserHandler) SetAutoRenew(c *gin.Context) {
+	id, ok := h.userSubID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AutoRenewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	userSub, err := h.subscriptions.SetAutoRenew(currentUserID(c), id, req.AutoRenew)
+	if err != nil {
+		h.subscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, userSub)
+}
+
+// SubEvents godoc
+// @Summary История подписки
+// @Description Возвращает подписку пользователя со всеми переходами состояний
+// @Tags User
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подписки пользователя"
+// @Success 200 {object} models.UserSubscription
+// @Failure 404 {object} map[string]string
+// @Router /user/sub/{id}/events [get]
+// @Router /user/subscriptions/{userSubscriptionID}/events [get]
+func (h *UserHandler) SubEvents(c *gin.Context) {
+	id, ok := h.userSubID(c)
+	if !ok {
+		return
+	}
+
+	userSub, err := h.subscriptions.GetWithEvents(currentUserID(c), id)
+	if err != nil {
+		h.subscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, userSub)
+}
+
+// userSubID читает ID подписки пользователя из /user/sub/:id/... или из
+// старых маршрутов /user/subscriptions/:userSubscriptionID/...
+func (h *UserHandler) userSubID(c *gin.Context) (uint, bool) {
+	param := c.Param("id")
+	if param == "" {
+		param = c.Param("userSubscriptionID")
+	}
+	id, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		h.log.Warn("Ошибка при вводе ID подписки")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID подписки"})
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *UserHandler) subscriptionError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, service.ErrUserSubscriptionNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// Me godoc
+// @Summary Текущий пользователь
+// @Description Возвращает пользователя, которому принадлежит access-токен, с текущим балансом
+// @Tags User
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /user/me [get]
+func (h *UserHandler) Me(c *gin.Context) {
+	user, err := h.user.GetUserByID(currentUserID(c))
+	if err != nil {
+		h.log.Error("Ошибка при получении текущего пользователя", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// Transactions godoc
+// @Summary История операций по балансу
+// @Description Возвращает движения по кошельку пользователя (новые первыми) с остатком после каждой операции
+// @Tags User
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /user/{id}/transactions [get]
+func (h *UserHandler) Transactions(c *gin.Context) {
+	id, ok := h.ownUserID(c, "id")
+	if !ok {
+		return
+	}
+
+	transactions, err := h.ledger.History(id)
+	if err != nil {
+		h.log.Error("Ошибка при получении истории операций", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	balance := 0
+	if len(transactions) > 0 {
+		balance = transactions[0].BalanceAfter
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"total":        len(transactions),
+		"balance":      balance,
+	})
+}
+
+// Adjust godoc
+// @Summary Корректировка баланса
+// @Description Зачисляет (amount > 0) или списывает (amount < 0) средства с указанием причины. Доступно администраторам.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param adjustment body models.AdjustmentRequest true "Сумма и причина"
+// @Success 201 {object} models.JournalEntry
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /user/{id}/adjustments [post]
+func (h *UserHandler) Adjust(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return
+	}
+
+	var req models.AdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	entry, err := h.ledger.Adjust(currentUserID(c), uint(id), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// UpdateRole godoc
+// @Summary Изменить роль пользователя
+// @Description Назначает пользователю роль client, trainer или admin. Доступно администраторам.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param role body models.UpdateRoleRequest true "Новая роль"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /user/{id}/role [patch]
+func (h *UserHandler) UpdateRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return
+	}
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	user, err := h.user.SetRole(uint(id), req.Role)
+	if err != nil {
+		h.log.Error("Ошибка при изменении роли", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.log.Info("Роль пользователя изменена",
+		"id", id,
+		"role", user.Role,
+		"admin_id", currentUserID(c))
+	c.JSON(http.StatusOK, user)
+}
+
+// purchaseRequest разбирает необязательное тело запроса на покупку
+func (h *UserHandler) purchaseRequest(c *gin.Context) (models.PurchaseRequest, bool) {
+	var req models.PurchaseRequest
+	if c.Request.ContentLength == 0 {
+		return req, true
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return req, false
+	}
+
+	return req, true
+}
+
+// ownUserID разбирает ID пользователя из пути и проверяет, что он совпадает
+// с авторизованным пользователем; администраторам доступны любые профили
+func (h *UserHandler) ownUserID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return 0, false
+	}
+
+	if uint(id) != currentUserID(c) && !h.auth.Can(c, service.PermManageUsers) {
+		h.log.Warn("Попытка доступа к чужому профилю",
+			"user_id", currentUserID(c),
+			"target_id", id)
+		c.JSON(http.StatusForbidden, forbiddenResponse(service.PermManageUsers))
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
 func (h *UserHandler) UserRoutes(r *gin.Engine) {
-	userGroup := r.Group("/user")
+	userGroup := r.Group("/user", h.auth.RequireAuth())
 	{
-		userGroup.POST("/", h.Create)
-		userGroup.POST("/payment/:userID/:categoryID", h.Payment)
-		userGroup.POST("/present/:userID/:categoryID/:secondUserID", h.PaymentToAnother)
-		userGroup.POST("sub/:userID/:subID", h.SubPayment)
-		userGroup.GET("/", h.GetAllUser)
+		once := h.idempotency.Handle()
+		userGroup.POST("/payment/:categoryID", once, h.Payment)
+		userGroup.POST("/present/:categoryID/:secondUserID", once, h.PaymentToAnother)
+		userGroup.POST("/sub/:id", once, h.SubPayment)
+		userGroup.POST("/sub/:id/cancel", h.CancelSub)
+		userGroup.POST("/sub/:id/renew", once, h.RenewSub)
+		userGroup.PATCH("/sub/:id/auto-renew", h.SetAutoRenew)
+		userGroup.GET("/sub/:id/events", h.SubEvents)
+		// псевдонимы прежних маршрутов для уже выпущенных клиентов
+		userGroup.POST("/subscriptions/:userSubscriptionID/cancel", h.CancelSub)
+		userGroup.POST("/subscriptions/:userSubscriptionID/renew", once, h.RenewSub)
+		userGroup.PATCH("/subscriptions/:userSubscriptionID/auto-renew", h.SetAutoRenew)
+		userGroup.GET("/subscriptions/:userSubscriptionID/events", h.SubEvents)
+		userGroup.GET("/", h.auth.Require(service.PermManageUsers), h.GetAllUser)
+		userGroup.GET("/me", h.Me)
 		userGroup.GET("/:id", h.GetUserByID)
+		userGroup.GET("/:id/transactions", h.Transactions)
+		userGroup.POST("/:id/adjustments", h.auth.Require(service.PermAdjustBalances), h.Adjust)
 		userGroup.GET("/plan/:id", h.GetUserWithPlan)
 		userGroup.GET("/userplans/:id", h.GetUserCategory)
 		userGroup.GET("/usersub/:userID", h.GetUserSubs)
 		userGroup.PATCH("/:id", h.Update)
+		userGroup.PATCH("/:id/active-programme", h.SetActiveProgramme)
+		userGroup.PUT("/:id/dietary-preferences", h.SetDietaryPreferences)
+		userGroup.PATCH("/:id/role", h.auth.Require(service.PermManageUsers), h.UpdateRole)
 		userGroup.DELETE("/:id", h.Delete)
 	}
 }
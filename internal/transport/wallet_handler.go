@@ -0,0 +1,199 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// signatureHeader — заголовок, в котором провайдер передаёт HMAC-подпись тела уведомления
+const signatureHeader = "X-Signature"
+
+type WalletHandler struct {
+	topUps service.TopUpService
+	fake   *service.FakePaymentProvider
+	auth   *AuthMiddleware
+	log    *slog.Logger
+}
+
+// fake может быть nil — тогда демонстрационная оплата недоступна
+func NewWalletHandler(topUps service.TopUpService, fake *service.FakePaymentProvider, auth *AuthMiddleware, log *slog.Logger) *WalletHandler {
+	return &WalletHandler{topUps: topUps, fake: fake, auth: auth, log: log}
+}
+
+func (h *WalletHandler) RegisterRoutes(r *gin.Engine) {
+	wallet := r.Group("/wallet")
+	{
+		wallet.POST("/topup", h.auth.RequireAuth(), h.CreateTopUp)
+		wallet.GET("/topup", h.auth.RequireAuth(), h.ListTopUps)
+		wallet.POST("/topup/:id/confirm", h.auth.RequireAuth(), h.Confirm)
+		wallet.POST("/callback/:provider", h.Callback)
+
+		if h.fake != nil {
+			wallet.POST("/fake/:paymentID/pay", h.auth.RequireAuth(), h.FakePay)
+		}
+	}
+}
+
+// CreateTopUp godoc
+// @Summary Пополнить баланс
+// @Description Создает платёж у провайдера. Средства зачисляются только после подтверждения оплаты. Если платёжный провайдер не настроен, возвращает 503.
+// @Tags Wallet
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param topup body models.TopUpRequest true "Сумма пополнения"
+// @Success 201 {object} models.TopUp
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /wallet/topup [post]
+func (h *WalletHandler) CreateTopUp(c *gin.Context) {
+	var req models.TopUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	topUp, err := h.topUps.CreateTopUp(currentUserID(c), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrTopUpsDisabled) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, topUp)
+}
+
+// ListTopUps godoc
+// @Summary Мои пополнения
+// @Tags Wallet
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.TopUp
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /wallet/topup [get]
+func (h *WalletHandler) ListTopUps(c *gin.Context) {
+	topUps, err := h.topUps.ListTopUps(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, topUps)
+}
+
+// Confirm godoc
+// @Summary Подтвердить пополнение
+// @Description Проверяет статус платежа у провайдера и зачисляет средства, если платёж оплачен. Повторный вызов безопасен.
+// @Tags Wallet
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пополнения"
+// @Success 200 {object} models.TopUp
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /wallet/topup/{id}/confirm [post]
+func (h *WalletHandler) Confirm(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return
+	}
+
+	topUp, err := h.topUps.Confirm(currentUserID(c), uint(id))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrTopUpNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, topUp)
+}
+
+// Callback godoc
+// @Summary Уведомление платёжного провайдера
+// @Description Принимает подписанное уведомление о статусе платежа. Подпись — HMAC-SHA256 тела запроса в заголовке X-Signature.
+// @Tags Wallet
+// @Accept json
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Param X-Signature header string true "Подпись тела запроса"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /wallet/callback/{provider} [post]
+func (h *WalletHandler) Callback(c *gin.Context) {
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не удалось прочитать тело запроса"})
+		return
+	}
+
+	topUp, err := h.topUps.HandleCallback(c.Param("provider"), payload, c.GetHeader(signatureHeader))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, service.ErrInvalidSignature):
+			status = http.StatusUnauthorized
+		case errors.Is(err, service.ErrTopUpNotFound), errors.Is(err, service.ErrUnknownProvider):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": topUp.Status})
+}
+
+// FakePay godoc
+// @Summary Оплатить платёж тестового провайдера
+// @Description Имитирует оплату на стороне провайдера fake и отправляет подписанное уведомление. Доступно только при PAYMENT_PROVIDER=fake и PAYMENT_FAKE_ENABLED=true и только для своих пополнений.
+// @Tags Wallet
+// @Produce json
+// @Security BearerAuth
+// @Param paymentID path string true "ID платежа у провайдера"
+// @Success 200 {object} models.TopUp
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /wallet/fake/{paymentID}/pay [post]
+func (h *WalletHandler) FakePay(c *gin.Context) {
+	paymentID := c.Param("paymentID")
+	if _, err := h.topUps.GetByPayment(currentUserID(c), h.fake.Name(), paymentID); err != nil {
+		h.log.Warn("Попытка оплатить чужое пополнение",
+			"user_id", currentUserID(c),
+			"payment_id", paymentID)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	payload, signature, err := h.fake.Pay(paymentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	topUp, err := h.topUps.HandleCallback(h.fake.Name(), payload, signature)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, topUp)
+}
@@ -0,0 +1,277 @@
+package transport
+
+import (
+	"errors"
+	"healthy_body/internal/models"
+	"healthy_body/internal/service"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkoutHandler struct {
+	workouts service.WorkoutService
+	auth     *AuthMiddleware
+	log      *slog.Logger
+}
+
+func NewWorkoutHandler(workouts service.WorkoutService, auth *AuthMiddleware, log *slog.Logger) *WorkoutHandler {
+	return &WorkoutHandler{workouts: workouts, auth: auth, log: log}
+}
+
+func (h *WorkoutHandler) RegisterRoutes(r *gin.Engine) {
+	workouts := r.Group("/workouts", h.auth.RequireAuth())
+	{
+		workouts.POST("/", h.Start)
+		workouts.GET("/", h.List)
+		workouts.GET("/:id", h.GetByID)
+		workouts.POST("/:id/sets", h.LogSet)
+		workouts.POST("/:id/finish", h.Finish)
+		workouts.GET("/exercises/:id/progress", h.Progress)
+		workouts.GET("/plans/:id/adherence", h.Adherence)
+	}
+}
+
+// Start godoc
+// @Summary Начать тренировку
+// @Description Начинает тренировку по тренировочному плану. Одновременно может быть только одна незавершённая тренировка.
+// @Tags Workouts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workout body models.StartWorkoutRequest true "Тренировочный план"
+// @Success 201 {object} models.WorkoutSession
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /workouts/ [post]
+func (h *WorkoutHandler) Start(c *gin.Context) {
+	var req models.StartWorkoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	session, err := h.workouts.Start(currentUser(c), req)
+	if err != nil {
+		h.workoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// List godoc
+// @Summary Мои тренировки
+// @Tags Workouts
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id query int false "ID тренировочного плана"
+// @Success 200 {array} models.WorkoutSession
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /workouts/ [get]
+func (h *WorkoutHandler) List(c *gin.Context) {
+	var planID *uint
+	if value := c.Query("plan_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный plan_id"})
+			return
+		}
+		parsed := uint(id)
+		planID = &parsed
+	}
+
+	sessions, err := h.workouts.ListSessions(currentUserID(c), planID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// GetByID godoc
+// @Summary Тренировка с подходами
+// @Tags Workouts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID тренировки"
+// @Success 200 {object} models.WorkoutSession
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /workouts/{id} [get]
+func (h *WorkoutHandler) GetByID(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.workouts.GetSession(currentUserID(c), id)
+	if err != nil {
+		h.workoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// LogSet godoc
+// @Summary Записать подход
+// @Description Записывает фактически выполненный подход по упражнению из плана тренировки
+// @Tags Workouts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID тренировки"
+// @Param set body models.LogWorkoutSetRequest true "Повторы, вес и длительность"
+// @Success 201 {object} models.WorkoutSet
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /workouts/{id}/sets [post]
+func (h *WorkoutHandler) LogSet(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.LogWorkoutSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Warn("Введены неверные данные", "err", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+		return
+	}
+
+	set, err := h.workouts.LogSet(currentUserID(c), id, req)
+	if err != nil {
+		h.workoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, set)
+}
+
+// Finish godoc
+// @Summary Завершить тренировку
+// @Tags Workouts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID тренировки"
+// @Param workout body models.FinishWorkoutRequest false "Заметки"
+// @Success 200 {object} models.WorkoutSession
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /workouts/{id}/finish [post]
+func (h *WorkoutHandler) Finish(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.FinishWorkoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Неверный формат данных", "error": err.Error()})
+			return
+		}
+	}
+
+	session, err := h.workouts.Finish(currentUserID(c), id, req)
+	if err != nil {
+		h.workoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// Progress godoc
+// @Summary Прогресс по упражнению
+// @Description Объём, повторы и максимальный вес по каждой тренировке, а также личные рекорды и оценка разового максимума
+// @Tags Workouts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID упражнения плана"
+// @Success 200 {object} models.ExerciseProgress
+// @Failure 400 {object} map[string]string
+// @Router /workouts/exercises/{id}/progress [get]
+func (h *WorkoutHandler) Progress(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	progress, err := h.workouts.Progress(currentUserID(c), id)
+	if err != nil {
+		h.workoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// Adherence godoc
+// @Summary Выполнение плана за неделю
+// @Description Процент запланированных подходов, выполненных за неделю (с понедельника). Неделя плана отсчитывается от первой тренировки по нему, подходы берутся с учётом прогрессии и разгрузки
+// @Tags Workouts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID тренировочного плана"
+// @Param week query string false "Любая дата недели в формате 2006-01-02, по умолчанию текущая неделя"
+// @Success 200 {object} models.WorkoutAdherence
+// @Failure 400 {object} map[string]string
+// @Router /workouts/plans/{id}/adherence [get]
+func (h *WorkoutHandler) Adherence(c *gin.Context) {
+	id, ok := h.pathID(c)
+	if !ok {
+		return
+	}
+
+	week := time.Now()
+	if value := c.Query("week"); value != "" {
+		parsed, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "некорректная дата недели"})
+			return
+		}
+		week = parsed
+	}
+
+	adherence, err := h.workouts.Adherence(currentUserID(c), id, week)
+	if err != nil {
+		h.workoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, adherence)
+}
+
+func (h *WorkoutHandler) pathID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log.Warn("Некорректный ID")
+		c.JSON(http.StatusBadRequest, gin.H{"message": "некорректный ID"})
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *WorkoutHandler) workoutError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrWorkoutNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrWorkoutAccessDenied):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrWorkoutInProgress):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}